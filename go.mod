@@ -5,6 +5,8 @@ go 1.22.0
 toolchain go1.24.3
 
 require (
+	github.com/briandowns/spinner v1.23.2
+	github.com/fatih/color v1.18.0
 	github.com/spf13/cobra v1.8.1
 	github.com/xeipuuv/gojsonschema v1.2.0
 	k8s.io/apimachinery v0.30.3
@@ -12,8 +14,6 @@ require (
 )
 
 require (
-	github.com/briandowns/spinner v1.23.2 // indirect
-	github.com/fatih/color v1.18.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
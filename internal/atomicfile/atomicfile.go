@@ -0,0 +1,83 @@
+// Package atomicfile guards the small files in ~/.gym that several gymctl
+// commands may update at once: an advisory lock around read-modify-write
+// cycles and writes that never leave a truncated file behind.
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout bounds how long a command waits for another one to finish
+// writing.
+const lockTimeout = 10 * time.Second
+
+// errLocked is returned by tryLock when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// Lock takes the advisory lock guarding read-modify-write cycles of the
+// file at path. The lock lives in a sibling "<path>.lock" file and is
+// released by the returned function or when the process exits.
+func Lock(path string) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+	lockFile, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err := tryLock(lockFile)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) {
+			lockFile.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			lockFile.Close()
+			return nil, fmt.Errorf("%s is locked by another gymctl command", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return func() error {
+		unlockErr := unlock(lockFile)
+		if err := lockFile.Close(); err != nil && unlockErr == nil {
+			unlockErr = err
+		}
+		return unlockErr
+	}, nil
+}
+
+// Write replaces path with data through a synced temporary file in the
+// same directory, so a crash leaves either version intact.
+func Write(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
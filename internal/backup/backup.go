@@ -0,0 +1,433 @@
+// Package backup snapshots an exercise's work directory and progress entry
+// into gzipped tarballs, so the commands that wipe them can be undone.
+//
+// An archive holds the work directory under work/ and a metadata.txt
+// describing the snapshot. Older archives only recorded a few progress
+// fields in metadata.txt; they still restore.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"gymctl/internal/progress"
+)
+
+const (
+	metadataFile    = "metadata.txt"
+	workPrefix      = "work/"
+	suffix          = ".tar.gz"
+	timestampLayout = "20060102-150405"
+)
+
+// Reasons a snapshot was taken.
+const (
+	ReasonManual  = "manual"
+	ReasonReset   = "reset"
+	ReasonRecover = "recover"
+	ReasonStop    = "stop"
+	ReasonRestore = "restore"
+)
+
+// Metadata describes a snapshot. It is stored as YAML in metadata.txt.
+type Metadata struct {
+	Exercise string `json:"exercise"`
+	Created  string `json:"created,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+
+	// The flat progress fields of the original format, kept so older
+	// gymctl versions can still read new archives.
+	Status    string `json:"status,omitempty"`
+	Started   string `json:"started,omitempty"`
+	Completed string `json:"completed,omitempty"`
+	Hints     int    `json:"hints,omitempty"`
+
+	// Progress is the full progress entry at snapshot time.
+	Progress *progress.ExerciseStatus `json:"progress,omitempty"`
+}
+
+// ProgressEntry returns the progress entry to restore, rebuilt from the
+// flat fields for archives that predate Progress. ok is false when the
+// snapshot has no progress at all.
+func (m *Metadata) ProgressEntry() (progress.ExerciseStatus, bool) {
+	if m.Progress != nil {
+		return *m.Progress, true
+	}
+	if m.Status == "" {
+		return progress.ExerciseStatus{}, false
+	}
+	return progress.ExerciseStatus{
+		Status:      m.Status,
+		StartedAt:   m.Started,
+		CompletedAt: m.Completed,
+		HintsUsed:   m.Hints,
+	}, true
+}
+
+// Backup is one archive in the backup directory.
+type Backup struct {
+	Path     string
+	Exercise string
+	Reason   string
+	Created  time.Time
+	Size     int64
+}
+
+// Name is the archive's file name.
+func (b Backup) Name() string {
+	return filepath.Base(b.Path)
+}
+
+// Create archives workDir and the metadata into dir. A missing work
+// directory gives an archive with only the metadata.
+func Create(dir, workDir string, meta Metadata) (Backup, error) {
+	if meta.Exercise == "" {
+		return Backup{}, errors.New("backup: exercise name is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Backup{}, fmt.Errorf("create backup dir: %w", err)
+	}
+	now := time.Now()
+	meta.Created = now.UTC().Format(time.RFC3339)
+	if entry, ok := meta.ProgressEntry(); ok {
+		meta.Status, meta.Started, meta.Completed, meta.Hints = entry.Status, entry.StartedAt, entry.CompletedAt, entry.HintsUsed
+	}
+
+	path, file, err := createArchiveFile(dir, meta.Exercise, now)
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := writeArchive(file, workDir, meta); err != nil {
+		file.Close()
+		os.Remove(path)
+		return Backup{}, err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return Backup{}, fmt.Errorf("write backup: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	return Backup{Path: path, Exercise: meta.Exercise, Reason: meta.Reason, Created: now, Size: info.Size()}, nil
+}
+
+// createArchiveFile creates <exercise>-<timestamp>.tar.gz, adding a counter
+// when several snapshots are taken within the same second.
+func createArchiveFile(dir, exercise string, now time.Time) (string, *os.File, error) {
+	base := exercise + "-" + now.Format(timestampLayout)
+	for i := 1; ; i++ {
+		name := base + suffix
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d%s", base, i, suffix)
+		}
+		path := filepath.Join(dir, name)
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("create backup: %w", err)
+		}
+		return path, file, nil
+	}
+}
+
+func writeArchive(w io.Writer, workDir string, meta Metadata) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: metadataFile, Mode: 0o644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(workDir); err == nil {
+		if err := addTree(tw, workDir); err != nil {
+			return fmt.Errorf("archive work directory: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func addTree(tw *tar.Writer, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = workPrefix + filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+}
+
+// ReadMetadata returns the metadata of an archive.
+func ReadMetadata(path string) (*Metadata, error) {
+	var meta *Metadata
+	err := walkArchive(path, func(header *tar.Header, r io.Reader) error {
+		if header.Name != metadataFile {
+			return nil
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		meta = &Metadata{}
+		return yaml.Unmarshal(data, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, fmt.Errorf("%s has no %s", filepath.Base(path), metadataFile)
+	}
+	return meta, nil
+}
+
+// Restore replaces workDir with the archived work directory and returns
+// the archive's metadata.
+func Restore(path, workDir string) (*Metadata, error) {
+	// Read everything into a staging directory first, so a corrupt archive
+	// leaves the current work directory alone.
+	staging, err := os.MkdirTemp(filepath.Dir(workDir), ".restore-")
+	if err != nil {
+		return nil, fmt.Errorf("create staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	meta := &Metadata{}
+	foundMeta := false
+	err = walkArchive(path, func(header *tar.Header, r io.Reader) error {
+		if header.Name == metadataFile {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			foundMeta = true
+			return yaml.Unmarshal(data, meta)
+		}
+		if !strings.HasPrefix(header.Name, workPrefix) {
+			return nil
+		}
+		return extract(staging, strings.TrimPrefix(header.Name, workPrefix), header, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !foundMeta {
+		meta = nil
+	}
+
+	if err := os.RemoveAll(workDir); err != nil {
+		return nil, fmt.Errorf("clear work directory: %w", err)
+	}
+	if err := os.Rename(staging, workDir); err != nil {
+		return nil, fmt.Errorf("restore work directory: %w", err)
+	}
+	return meta, nil
+}
+
+func extract(root, name string, header *tar.Header, r io.Reader) error {
+	name = filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if name == "" || name == "." {
+		return nil
+	}
+	target := filepath.Join(root, name)
+	if !strings.HasPrefix(target, root+string(filepath.Separator)) {
+		return fmt.Errorf("archive entry %q escapes the work directory", header.Name)
+	}
+	mode := os.FileMode(header.Mode).Perm()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, mode|0o700)
+	case tar.TypeSymlink:
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.Symlink(header.Linkname, target)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, r); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+	return nil
+}
+
+func walkArchive(path string, visit func(*tar.Header, io.Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open backup: %w", err)
+	}
+	defer file.Close()
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("invalid backup %s: %w", filepath.Base(path), err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid backup %s: %w", filepath.Base(path), err)
+		}
+		if err := visit(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// legacyName matches archives named <exercise>-<timestamp>[-n].tar.gz.
+var legacyName = regexp.MustCompile(`^(.+)-(\d{8}-\d{6})(?:-\d+)?\.tar\.gz$`)
+
+// List returns the archives in dir, newest first, optionally only those of
+// one exercise. A missing directory has no backups.
+func List(dir, exercise string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+	var backups []Backup
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backup := Backup{Path: filepath.Join(dir, entry.Name()), Size: info.Size(), Created: info.ModTime()}
+		dated := false
+		if meta, err := ReadMetadata(backup.Path); err == nil {
+			backup.Exercise, backup.Reason = meta.Exercise, meta.Reason
+			if created, err := time.Parse(time.RFC3339, meta.Created); err == nil {
+				backup.Created, dated = created, true
+			}
+		}
+		// Older archives only carry their name and date in the file name.
+		if match := legacyName.FindStringSubmatch(entry.Name()); match != nil {
+			if backup.Exercise == "" {
+				backup.Exercise = match[1]
+			}
+			if created, err := time.ParseInLocation(timestampLayout, match[2], time.Local); err == nil && !dated {
+				backup.Created = created
+			}
+		}
+		if exercise != "" && backup.Exercise != exercise {
+			continue
+		}
+		backups = append(backups, backup)
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		if !backups[i].Created.Equal(backups[j].Created) {
+			return backups[i].Created.After(backups[j].Created)
+		}
+		return backups[i].Path > backups[j].Path
+	})
+	return backups, nil
+}
+
+// Policy is a retention policy, applied per exercise.
+type Policy struct {
+	// Keep is how many snapshots to keep; zero keeps all.
+	Keep int
+	// MaxAge drops snapshots older than this; zero keeps them. The newest
+	// snapshot of an exercise is never dropped for its age.
+	MaxAge time.Duration
+	// Automatic limits the policy to the snapshots gymctl takes on its
+	// own: manual snapshots are neither counted nor removed.
+	Automatic bool
+}
+
+// Prune deletes the archives in dir the policy does not keep, for one
+// exercise or for all, and returns them.
+func Prune(dir, exercise string, policy Policy) ([]Backup, error) {
+	backups, err := List(dir, exercise)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	kept := map[string]int{}
+	var removed []Backup
+	for _, backup := range backups {
+		if policy.Automatic && backup.Reason == ReasonManual {
+			continue
+		}
+		kept[backup.Exercise]++
+		position := kept[backup.Exercise]
+		tooMany := policy.Keep > 0 && position > policy.Keep
+		tooOld := policy.MaxAge > 0 && position > 1 && now.Sub(backup.Created) > policy.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, fmt.Errorf("remove backup: %w", err)
+		}
+		removed = append(removed, backup)
+	}
+	return removed, nil
+}
@@ -0,0 +1,204 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gymctl/internal/progress"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "backups")
+	workDir := filepath.Join(root, "work")
+	writeFile(t, filepath.Join(workDir, "Dockerfile"), "FROM alpine\n")
+	writeFile(t, filepath.Join(workDir, "app", "main.go"), "package main\n")
+
+	status := progress.ExerciseStatus{Status: "in_progress", StartedAt: "2024-01-01T00:00:00Z", HintsUsed: 2, Resets: 1}
+	created, err := Create(dir, workDir, Metadata{Exercise: "01-demo", Reason: ReasonReset, Progress: &status})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Exercise != "01-demo" || created.Size == 0 {
+		t.Errorf("Create returned %+v", created)
+	}
+
+	// The student keeps working after the snapshot.
+	writeFile(t, filepath.Join(workDir, "Dockerfile"), "FROM scratch\n")
+	writeFile(t, filepath.Join(workDir, "notes.txt"), "later\n")
+
+	meta, err := Restore(created.Path, workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, "Dockerfile"))
+	if err != nil || string(data) != "FROM alpine\n" {
+		t.Errorf("Dockerfile = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "app", "main.go")); err != nil {
+		t.Errorf("nested file not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("files created after the snapshot should be gone, got %v", err)
+	}
+
+	entry, ok := meta.ProgressEntry()
+	if !ok || entry.Status != "in_progress" || entry.HintsUsed != 2 || entry.Resets != 1 {
+		t.Errorf("ProgressEntry = %+v, %v", entry, ok)
+	}
+	if meta.Reason != ReasonReset || meta.Status != "in_progress" {
+		t.Errorf("metadata = %+v", meta)
+	}
+}
+
+// writeArchiveFile writes a tarball with the given entries, the way older
+// gymctl versions did.
+func writeArchiveFile(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+	for name, content := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreLegacyArchive(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "01-demo-20240101-120000.tar.gz")
+	writeArchiveFile(t, path, map[string]string{
+		"work/solution.yaml": "kind: Pod\n",
+		"metadata.txt":       "exercise: 01-demo\nstatus: completed\nstarted: 2024-01-01T10:00:00Z\ncompleted: 2024-01-01T11:00:00Z\nhints: 3\n",
+	})
+
+	meta, err := Restore(path, filepath.Join(root, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := meta.ProgressEntry()
+	if !ok || entry.Status != "completed" || entry.HintsUsed != 3 || entry.CompletedAt != "2024-01-01T11:00:00Z" {
+		t.Errorf("ProgressEntry = %+v, %v", entry, ok)
+	}
+
+	backups, err := List(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Exercise != "01-demo" || backups[0].Created.Year() != 2024 {
+		t.Errorf("List = %+v", backups)
+	}
+}
+
+func TestRestoreRejectsEscapingPaths(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "evil.tar.gz")
+	writeArchiveFile(t, path, map[string]string{"work/../../outside": "x"})
+
+	workDir := filepath.Join(root, "work")
+	writeFile(t, filepath.Join(workDir, "keep.txt"), "keep")
+	if _, err := Restore(path, workDir); err == nil {
+		t.Fatal("expected an error for an entry outside the work directory")
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "keep.txt")); err != nil {
+		t.Errorf("a failed restore must leave the work directory alone: %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	workDir := t.TempDir()
+	for i := 0; i < 4; i++ {
+		if _, err := Create(dir, workDir, Metadata{Exercise: "01-demo"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Create(dir, workDir, Metadata{Exercise: "02-other"}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune(dir, "", Policy{Keep: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed %d backups, want 2", len(removed))
+	}
+	remaining, err := List(dir, "01-demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 {
+		t.Errorf("kept %d backups of 01-demo, want 2", len(remaining))
+	}
+	if others, _ := List(dir, "02-other"); len(others) != 1 {
+		t.Errorf("pruning must count per exercise, 02-other has %d", len(others))
+	}
+
+	// Age never removes the newest snapshot.
+	removed, err = Prune(dir, "01-demo", Policy{MaxAge: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 {
+		t.Errorf("removed %d old backups, want 1", len(removed))
+	}
+}
+
+func TestPruneAutomaticKeepsManualSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	workDir := t.TempDir()
+	manual, err := Create(dir, workDir, Metadata{Exercise: "01-demo", Reason: ReasonManual})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := Create(dir, workDir, Metadata{Exercise: "01-demo", Reason: ReasonStop}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Prune(dir, "01-demo", Policy{Keep: 2, MaxAge: time.Nanosecond, Automatic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed %d backups, want the 2 older stop snapshots", len(removed))
+	}
+	for _, b := range removed {
+		if b.Path == manual.Path {
+			t.Error("automatic pruning removed a manual snapshot")
+		}
+	}
+	if _, err := os.Stat(manual.Path); err != nil {
+		t.Errorf("manual snapshot is gone: %v", err)
+	}
+}
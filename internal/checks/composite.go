@@ -0,0 +1,82 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gymctl/internal/scenario"
+)
+
+// runCompositeCheck evaluates the nested checks of an allOf, anyOf or not
+// check once each. Retries belong to the composite: polling a nested check
+// until it passes would defeat a not.
+func runCompositeCheck(ctx context.Context, exercise *scenario.Exercise, workDir string, check scenario.Check) Result {
+	result := Result{Name: check.Name}
+	if check.Name == "" {
+		result.Name = check.Type
+	}
+	if len(check.Checks) == 0 {
+		result.Message = fmt.Sprintf("%s check has no nested checks", check.Type)
+		return result
+	}
+
+	var passed, failed []Result
+	for _, child := range check.Checks {
+		branch := runCheckOnce(ctx, exercise, workDir, child)
+		branch.Type = child.Type
+		branch = withFeedback(branch, child)
+		result.Branches = append(result.Branches, branch)
+		if branch.Passed {
+			passed = append(passed, branch)
+			// One passing branch settles an anyOf.
+			if check.Type == scenario.CheckAnyOf {
+				break
+			}
+		} else {
+			failed = append(failed, branch)
+		}
+	}
+
+	switch check.Type {
+	case scenario.CheckAllOf:
+		result.Passed = len(failed) == 0
+		if result.Passed {
+			result.Message = fmt.Sprintf("all %d passed", len(passed))
+		} else {
+			result.Message = "failed: " + describeBranches(failed, true)
+		}
+	case scenario.CheckAnyOf:
+		result.Passed = len(passed) > 0
+		if result.Passed {
+			result.Message = "satisfied by " + describeBranches(passed, false)
+		} else {
+			result.Message = "none passed: " + describeBranches(failed, true)
+		}
+	case scenario.CheckNot:
+		result.Passed = len(passed) == 0
+		if result.Passed {
+			result.Message = "none of " + describeBranches(failed, false) + " passed, as required"
+		} else {
+			result.Message = "must not pass, but did: " + describeBranches(passed, false)
+		}
+	}
+	return result
+}
+
+// describeBranches names branches, quoting their messages when asked.
+func describeBranches(branches []Result, withMessages bool) string {
+	parts := make([]string, len(branches))
+	for i, branch := range branches {
+		parts[i] = fmt.Sprintf("%q", branch.Name)
+		if withMessages && branch.Message != "" {
+			parts[i] += " (" + firstLine(branch.Message) + ")"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return line
+}
@@ -0,0 +1,125 @@
+package checks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gymctl/internal/scenario"
+)
+
+func TestRunCompositeCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "Dockerfile"), []byte("FROM alpine:3.18\nUSER app\n"), 0644); err != nil {
+		t.Fatalf("failed to write Dockerfile: %v", err)
+	}
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{Type: "docker"},
+	}}
+
+	present := scenario.Check{Name: "Dockerfile exists", Type: "file", Path: "Dockerfile", Exists: boolPtr(true)}
+	missing := scenario.Check{Name: "compose file exists", Type: "file", Path: "compose.yaml", Exists: boolPtr(true)}
+	user := scenario.Check{Name: "USER set", Type: "dockerfile", Path: "Dockerfile", Check: "userInstruction", Operator: "exists"}
+
+	tests := []struct {
+		name         string
+		check        scenario.Check
+		wantPass     bool
+		wantBranches int
+		wantMessage  string
+	}{
+		{
+			name:         "allOf passes",
+			check:        scenario.Check{Name: "both", Type: scenario.CheckAllOf, Checks: []scenario.Check{present, user}},
+			wantPass:     true,
+			wantBranches: 2,
+			wantMessage:  "all 2 passed",
+		},
+		{
+			name:         "allOf names the failing branch",
+			check:        scenario.Check{Name: "both", Type: scenario.CheckAllOf, Checks: []scenario.Check{present, missing}},
+			wantBranches: 2,
+			wantMessage:  `failed: "compose file exists"`,
+		},
+		{
+			name:         "anyOf stops at the first passing branch",
+			check:        scenario.Check{Name: "either", Type: scenario.CheckAnyOf, Checks: []scenario.Check{missing, user, present}},
+			wantPass:     true,
+			wantBranches: 2,
+			wantMessage:  `satisfied by "USER set"`,
+		},
+		{
+			name:         "anyOf fails when nothing passes",
+			check:        scenario.Check{Name: "either", Type: scenario.CheckAnyOf, Checks: []scenario.Check{missing}},
+			wantBranches: 1,
+			wantMessage:  `none passed: "compose file exists"`,
+		},
+		{
+			name:         "not passes when nothing passes",
+			check:        scenario.Check{Name: "no compose", Type: scenario.CheckNot, Checks: []scenario.Check{missing}},
+			wantPass:     true,
+			wantBranches: 1,
+			wantMessage:  `none of "compose file exists" passed`,
+		},
+		{
+			name:         "not fails on a passing branch",
+			check:        scenario.Check{Name: "no Dockerfile", Type: scenario.CheckNot, Checks: []scenario.Check{missing, present}},
+			wantBranches: 2,
+			wantMessage:  `must not pass, but did: "Dockerfile exists"`,
+		},
+		{
+			name: "nested composites",
+			check: scenario.Check{Name: "nested", Type: scenario.CheckAllOf, Checks: []scenario.Check{
+				{Name: "either", Type: scenario.CheckAnyOf, Checks: []scenario.Check{missing, present}},
+				{Name: "no compose", Type: scenario.CheckNot, Checks: []scenario.Check{missing}},
+			}},
+			wantPass:     true,
+			wantBranches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runCheck(context.Background(), exercise, tmpDir, tt.check)
+			if result.Passed != tt.wantPass {
+				t.Errorf("passed = %v, want %v (%s)", result.Passed, tt.wantPass, result.Message)
+			}
+			if len(result.Branches) != tt.wantBranches {
+				t.Errorf("got %d branches, want %d", len(result.Branches), tt.wantBranches)
+			}
+			if !strings.Contains(result.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", result.Message, tt.wantMessage)
+			}
+			if result.Type != tt.check.Type {
+				t.Errorf("type = %q, want %q", result.Type, tt.check.Type)
+			}
+		})
+	}
+}
+
+func TestFeedbackOnlyOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "Dockerfile"), []byte("FROM alpine:3.18\n"), 0644); err != nil {
+		t.Fatalf("failed to write Dockerfile: %v", err)
+	}
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{Type: "docker"},
+	}}
+
+	present := scenario.Check{Name: "Dockerfile exists", Type: "file", Path: "Dockerfile", Exists: boolPtr(true), Feedback: "Create a Dockerfile.", Hint: 1}
+	if result := runCheck(context.Background(), exercise, tmpDir, present); result.Feedback != "" || result.Hint != 0 {
+		t.Errorf("passing check carries feedback: %+v", result)
+	}
+
+	user := scenario.Check{Name: "USER set", Type: "dockerfile", Path: "Dockerfile", Check: "userInstruction", Operator: "exists", Feedback: "Switch to a non-root user.", Hint: 2}
+	either := scenario.Check{Name: "non-root", Type: scenario.CheckAnyOf, Checks: []scenario.Check{user}, Feedback: "The container runs as root."}
+	result := runCheck(context.Background(), exercise, tmpDir, either)
+	if result.Feedback != "The container runs as root." || result.Hint != 0 {
+		t.Errorf("unexpected composite feedback: %+v", result)
+	}
+	if branch := result.Branches[0]; branch.Feedback != "Switch to a non-root user." || branch.Hint != 2 || branch.Message == "" {
+		t.Errorf("unexpected branch feedback: %+v", branch)
+	}
+}
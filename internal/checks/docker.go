@@ -0,0 +1,89 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/runner"
+)
+
+// The helpers below read from the Docker Engine API and fall back to the
+// docker CLI's JSON inspect output when the API client is unavailable, so
+// both paths feed the checks the same structures.
+
+func inspectImage(ctx context.Context, name string) (*dockerapi.Image, error) {
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		return client.ImageInspect(ctx, name)
+	}
+	var images []dockerapi.Image
+	if err := inspectCLI(ctx, &images, "image", "inspect", name); err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no such image: %s", name)
+	}
+	return &images[0], nil
+}
+
+func inspectContainer(ctx context.Context, name string) (*dockerapi.Container, error) {
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		return client.ContainerInspect(ctx, name)
+	}
+	var containers []dockerapi.Container
+	if err := inspectCLI(ctx, &containers, "container", "inspect", name); err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("no such container: %s", name)
+	}
+	return &containers[0], nil
+}
+
+func inspectCLI(ctx context.Context, into interface{}, args ...string) error {
+	output, err := runner.Output(ctx, "docker", args...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(output), into); err != nil {
+		return fmt.Errorf("parse docker %s output: %w", strings.Join(args[:2], " "), err)
+	}
+	return nil
+}
+
+func containerLogs(ctx context.Context, name, since string) (string, error) {
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		var window time.Duration
+		if since != "" {
+			if window, err = time.ParseDuration(since); err != nil {
+				return "", fmt.Errorf("invalid timeout %q: %w", since, err)
+			}
+		}
+		return client.ContainerLogs(ctx, name, window)
+	}
+	args := []string{"logs"}
+	if since != "" {
+		args = append(args, "--since", since)
+	}
+	args = append(args, name)
+	return runner.Run(ctx, "docker", args...)
+}
+
+// formatLabels renders labels the way `{{.Config.Labels}}` does, e.g.
+// "map[maintainer:jerry version:1.0]".
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+":"+labels[key])
+	}
+	return "map[" + strings.Join(pairs, " ") + "]"
+}
@@ -0,0 +1,197 @@
+package checks
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gymctl/internal/dockerfile"
+	"gymctl/internal/scenario"
+)
+
+// runDockerfileCheck parses the Dockerfile and asserts on its structure.
+// Apart from multiStage, assertions look at one stage: check.Stage by name
+// or index, the final stage by default.
+func runDockerfileCheck(check scenario.Check, workDir string) Result {
+	result := Result{Name: check.Name}
+	if check.Path == "" {
+		result.Message = "missing dockerfile path"
+		return result
+	}
+	path := check.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	parsed, err := dockerfile.ParseFile(path)
+	if err != nil {
+		result.Message = fmt.Sprintf("read dockerfile: %s", err)
+		return result
+	}
+	stage, ok := parsed.Stage(check.Stage)
+	if !ok {
+		result.Message = fmt.Sprintf("dockerfile has no stage %q", check.Stage)
+		return result
+	}
+
+	switch check.Check {
+	case "multiStage":
+		result.Passed, result.Message = compareDockerfileBool(check, len(parsed.Stages) > 1,
+			fmt.Sprintf("%d stage(s)", len(parsed.Stages)))
+	case "baseImage":
+		result.Passed, result.Message = compareValue(parsed.BaseImage(stage).String(), check.Operator, check.Value, "string")
+	case "pinnedBase":
+		base := parsed.BaseImage(stage)
+		result.Passed, result.Message = compareDockerfileBool(check, base.Pinned(),
+			fmt.Sprintf("%s is not pinned to a tag or digest", base))
+	case "copyFrom":
+		copied := false
+		for _, instruction := range stage.Find("COPY") {
+			if _, ok := instruction.Flags["from"]; ok {
+				copied = true
+			}
+		}
+		result.Passed, result.Message = compareDockerfileBool(check, copied, "no COPY --from in "+stageLabel(stage))
+	case "userInstruction":
+		_, found := parsed.Last(stage, "USER")
+		result.Passed, result.Message = compareDockerfileBool(check, found, "USER instruction not found")
+	case "user":
+		user, _ := parsed.Last(stage, "USER")
+		result.Passed, result.Message = compareValue(user.Value, check.Operator, check.Value, "string")
+	case "nonRootUser":
+		user, found := parsed.Last(stage, "USER")
+		detail := "USER instruction not found, so " + stageLabel(stage) + " runs as root"
+		if found {
+			detail = fmt.Sprintf("USER %s on line %d is root", user.Value, user.Line)
+		}
+		result.Passed, result.Message = compareDockerfileBool(check, found && !isRootUser(user.Value), detail)
+	case "healthcheck":
+		healthcheck, found := parsed.Last(stage, "HEALTHCHECK")
+		detail := "HEALTHCHECK instruction not found"
+		if found && isHealthcheckNone(healthcheck) {
+			detail = fmt.Sprintf("HEALTHCHECK NONE on line %d disables it", healthcheck.Line)
+		}
+		result.Passed, result.Message = compareDockerfileBool(check, found && !isHealthcheckNone(healthcheck), detail)
+	case "exposedPorts":
+		result.Passed, result.Message = compareValue(strings.Join(exposedPorts(parsed, stage), ","), check.Operator, check.Value, "string")
+	case "addInstruction":
+		added := stage.Find("ADD")
+		detail := "no ADD instruction"
+		if len(added) > 0 {
+			detail = fmt.Sprintf("ADD %s on line %d", added[0].Value, added[0].Line)
+		}
+		result.Passed, result.Message = compareDockerfileBool(check, len(added) > 0, detail)
+	case "contextCopiedBeforeRun":
+		copyAll, run, found := contextCopiedBeforeRun(stage)
+		detail := "no RUN follows a copy of the whole build context"
+		if found {
+			detail = fmt.Sprintf("%s %s on line %d comes before the RUN on line %d, so every change to the source reruns it", copyAll.Command, copyAll.Value, copyAll.Line, run.Line)
+		}
+		result.Passed, result.Message = compareDockerfileBool(check, found, detail)
+	default:
+		result.Message = fmt.Sprintf("unsupported dockerfile check: %s", check.Check)
+	}
+	return result
+}
+
+// compareDockerfileBool compares a yes or no property with the check's
+// value, which defaults to true. The exists operator passes when the
+// property holds. detail explains the actual state when the check fails.
+func compareDockerfileBool(check scenario.Check, actual bool, detail string) (bool, string) {
+	if check.Operator == "exists" {
+		if actual {
+			return true, ""
+		}
+		return false, detail
+	}
+	expected := check.Value
+	if expected == nil {
+		expected = "true"
+	}
+	passed, message := compareValue(fmt.Sprintf("%t", actual), check.Operator, expected, "string")
+	if !passed && detail != "" {
+		message += ": " + detail
+	}
+	return passed, message
+}
+
+func stageLabel(stage *dockerfile.Stage) string {
+	if stage.Name != "" {
+		return fmt.Sprintf("stage %q", stage.Name)
+	}
+	return fmt.Sprintf("stage %d", stage.Index)
+}
+
+// isRootUser reports whether a USER value names root, by name or uid, with
+// or without a group.
+func isRootUser(value string) bool {
+	user, _, _ := strings.Cut(value, ":")
+	return user == "" || user == "root" || user == "0"
+}
+
+func isHealthcheckNone(healthcheck dockerfile.Instruction) bool {
+	return len(healthcheck.Args) > 0 && strings.EqualFold(healthcheck.Args[0], "NONE")
+}
+
+// exposedPorts lists the ports a stage exposes, including those of the
+// stages it builds on.
+func exposedPorts(parsed *dockerfile.Dockerfile, stage *dockerfile.Stage) []string {
+	var ports []string
+	for current := stage; current != nil; {
+		var own []string
+		for _, instruction := range current.Find("EXPOSE") {
+			own = append(own, instruction.Args...)
+		}
+		ports = append(own, ports...)
+		if current.BaseStage < 0 {
+			break
+		}
+		current = &parsed.Stages[current.BaseStage]
+	}
+	return ports
+}
+
+// contextCopiedBeforeRun finds a COPY or ADD of the whole build context
+// followed by a RUN, which then never stays cached when any source file
+// changes. Copying only the files a RUN needs, such as the dependency
+// manifests, and running it before the rest is copied avoids that.
+func contextCopiedBeforeRun(stage *dockerfile.Stage) (dockerfile.Instruction, dockerfile.Instruction, bool) {
+	var copyAll *dockerfile.Instruction
+	copiedFiles := false
+	for i, instruction := range stage.Instructions {
+		switch instruction.Command {
+		case "COPY", "ADD":
+			if _, ok := instruction.Flags["from"]; ok || copyAll != nil {
+				continue
+			}
+			if copiesContext(instruction) {
+				copyAll = &stage.Instructions[i]
+			} else {
+				copiedFiles = true
+			}
+		case "RUN":
+			if copyAll != nil {
+				return *copyAll, instruction, true
+			}
+			if copiedFiles {
+				// Dependencies are installed from the files they need.
+				return dockerfile.Instruction{}, dockerfile.Instruction{}, false
+			}
+		}
+	}
+	return dockerfile.Instruction{}, dockerfile.Instruction{}, false
+}
+
+// copiesContext reports whether a COPY or ADD takes the whole build
+// context rather than named files.
+func copiesContext(instruction dockerfile.Instruction) bool {
+	if _, ok := instruction.Flags["from"]; ok || len(instruction.Args) < 2 {
+		return false
+	}
+	for _, source := range instruction.Args[:len(instruction.Args)-1] {
+		switch strings.TrimSuffix(source, "/") {
+		case ".", "*", "./*":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package checks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gymctl/internal/scenario"
+)
+
+func TestRunDockerfileAssertions(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		// Good practice throughout, written the ways the old line matching
+		// could not follow.
+		"Dockerfile.good": `# syntax=docker/dockerfile:1
+ARG ALPINE=3.19
+FROM golang:1.22-alpine AS build
+WORKDIR /src
+COPY go.mod go.sum ./
+RUN go mod download
+COPY . .
+RUN go build -o /app
+
+FROM alpine:${ALPINE}@sha256:0123abcd AS runtime
+COPY --from=build \
+     /app /app
+USER 10001:10001
+HEALTHCHECK --interval=30s CMD wget -q -O- http://localhost:8080/health || exit 1
+EXPOSE 8080
+ENTRYPOINT ["/app"]
+`,
+		// A builder that runs as a user while the final image runs as root.
+		"Dockerfile.bad": `FROM node:latest AS deps
+USER node
+COPY . .
+RUN npm ci
+
+FROM node
+ADD . /app
+USER root
+HEALTHCHECK NONE
+`,
+		// Packages installed first, but the dependencies after the source.
+		"Dockerfile.packages": `FROM node:20-alpine
+RUN apk add --no-cache git
+WORKDIR /app
+COPY . .
+RUN npm ci
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		check       scenario.Check
+		wantPass    bool
+		wantMessage string
+	}{
+		{name: "multi-stage", check: scenario.Check{Path: "Dockerfile.good", Check: "multiStage"}, wantPass: true},
+		{name: "final base image with ARG", check: scenario.Check{Path: "Dockerfile.good", Check: "baseImage", Value: "alpine:3.19@sha256:0123abcd"}, wantPass: true},
+		{name: "named stage base image", check: scenario.Check{Path: "Dockerfile.good", Check: "baseImage", Stage: "build", Operator: "contains", Value: "golang"}, wantPass: true},
+		{name: "digest is pinned", check: scenario.Check{Path: "Dockerfile.good", Check: "pinnedBase"}, wantPass: true},
+		{name: "latest is not pinned", check: scenario.Check{Path: "Dockerfile.bad", Check: "pinnedBase", Stage: "deps"}, wantMessage: "node:latest is not pinned"},
+		{name: "untagged is not pinned", check: scenario.Check{Path: "Dockerfile.bad", Check: "pinnedBase"}, wantMessage: "node is not pinned"},
+		{name: "continued COPY --from", check: scenario.Check{Path: "Dockerfile.good", Check: "copyFrom"}, wantPass: true},
+		{name: "non-root uid", check: scenario.Check{Path: "Dockerfile.good", Check: "nonRootUser"}, wantPass: true},
+		{name: "final stage root", check: scenario.Check{Path: "Dockerfile.bad", Check: "nonRootUser"}, wantMessage: "USER root on line 8 is root"},
+		{name: "builder stage user", check: scenario.Check{Path: "Dockerfile.bad", Check: "nonRootUser", Stage: "0"}, wantPass: true},
+		{name: "user value", check: scenario.Check{Path: "Dockerfile.good", Check: "user", Value: "10001:10001"}, wantPass: true},
+		{name: "healthcheck", check: scenario.Check{Path: "Dockerfile.good", Check: "healthcheck", Operator: "exists"}, wantPass: true},
+		{name: "healthcheck none", check: scenario.Check{Path: "Dockerfile.bad", Check: "healthcheck"}, wantMessage: "HEALTHCHECK NONE on line 9"},
+		{name: "exposed ports", check: scenario.Check{Path: "Dockerfile.good", Check: "exposedPorts", Operator: "contains", Value: "8080"}, wantPass: true},
+		{name: "no ADD", check: scenario.Check{Path: "Dockerfile.good", Check: "addInstruction", Value: "false"}, wantPass: true},
+		{name: "ADD instead of COPY", check: scenario.Check{Path: "Dockerfile.bad", Check: "addInstruction", Value: "false"}, wantMessage: "ADD . /app on line 7"},
+		{name: "dependencies copied first", check: scenario.Check{Path: "Dockerfile.good", Check: "contextCopiedBeforeRun", Stage: "build", Value: "false"}, wantPass: true},
+		{name: "whole context before RUN", check: scenario.Check{Path: "Dockerfile.bad", Check: "contextCopiedBeforeRun", Stage: "deps", Value: "false"}, wantMessage: "COPY . . on line 3 comes before the RUN on line 4"},
+		{name: "whole context after a RUN", check: scenario.Check{Path: "Dockerfile.packages", Check: "contextCopiedBeforeRun", Value: "false"}, wantMessage: "COPY . . on line 4 comes before the RUN on line 5"},
+		{name: "whole context copied", check: scenario.Check{Path: "Dockerfile.packages", Check: "contextCopiedBeforeRun", Operator: "exists"}, wantPass: true},
+		{name: "no RUN after the context", check: scenario.Check{Path: "Dockerfile.good", Check: "contextCopiedBeforeRun", Operator: "exists"}, wantMessage: "no RUN follows a copy of the whole build context"},
+		{name: "unknown stage", check: scenario.Check{Path: "Dockerfile.good", Check: "baseImage", Stage: "release"}, wantMessage: `no stage "release"`},
+		{name: "unsupported assertion", check: scenario.Check{Path: "Dockerfile.good", Check: "hasUser"}, wantMessage: "unsupported dockerfile check"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runDockerfileCheck(tt.check, tmpDir)
+			if result.Passed != tt.wantPass {
+				t.Errorf("passed = %v, want %v, msg = %s", result.Passed, tt.wantPass, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", result.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -2,12 +2,10 @@ package checks
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -16,22 +14,36 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
+	"gymctl/internal/kube"
 	"gymctl/internal/runner"
 	"gymctl/internal/scenario"
 )
 
 type Result struct {
-	Name    string
-	Passed  bool
-	Message string
+	Name     string
+	Type     string
+	Passed   bool
+	Message  string
+	Attempts int
+	Duration time.Duration
+	// Branches holds the results of a composite check's nested checks, in
+	// declaration order. Nested checks an anyOf did not need to try are
+	// left out.
+	Branches []Result
+	// Optional marks a check the exercise can be completed without.
+	Optional bool
+	// Feedback explains a failure to the student and Hint is the 1-based
+	// index of the hint that helps with it. Both are only set on failure.
+	Feedback string
+	Hint     int
 }
 
+// RunExerciseChecks runs the exercise checks with the default executor and
+// reports whether all of them passed.
 func RunExerciseChecks(ctx context.Context, exercise *scenario.Exercise, workDir string) ([]Result, bool) {
-	var results []Result
+	results := Executor{Workers: DefaultWorkers}.Run(ctx, exercise, workDir)
 	allPassed := true
-	for _, check := range exercise.Spec.Checks {
-		result := runCheck(ctx, exercise, workDir, check)
-		results = append(results, result)
+	for _, result := range results {
 		if !result.Passed {
 			allPassed = false
 		}
@@ -39,7 +51,48 @@ func RunExerciseChecks(ctx context.Context, exercise *scenario.Exercise, workDir
 	return results, allPassed
 }
 
+// RequiredPassed reports whether every check the exercise cannot be
+// completed without passed.
+func RequiredPassed(results []Result) bool {
+	for _, result := range results {
+		if !result.Passed && !result.Optional {
+			return false
+		}
+	}
+	return true
+}
+
+// Passed returns whether each result passed, in order.
+func Passed(results []Result) []bool {
+	passed := make([]bool, len(results))
+	for i, result := range results {
+		passed[i] = result.Passed
+	}
+	return passed
+}
+
+// runCheck runs a check, polling it according to its retries, interval and
+// timeout until it passes.
 func runCheck(ctx context.Context, exercise *scenario.Exercise, workDir string, check scenario.Check) Result {
+	started := time.Now()
+	result := runWithRetry(ctx, policyFor(check), func() Result {
+		return runCheckOnce(ctx, exercise, workDir, check)
+	})
+	result.Type = check.Type
+	result.Duration = time.Since(started)
+	return withFeedback(result, check)
+}
+
+// withFeedback attaches the check's student-facing feedback to a failure.
+func withFeedback(result Result, check scenario.Check) Result {
+	if !result.Passed {
+		result.Feedback = check.Feedback
+		result.Hint = check.Hint
+	}
+	return result
+}
+
+func runCheckOnce(ctx context.Context, exercise *scenario.Exercise, workDir string, check scenario.Check) Result {
 	result := Result{Name: check.Name, Passed: false}
 	if check.Name == "" {
 		result.Name = check.Type
@@ -47,6 +100,8 @@ func runCheck(ctx context.Context, exercise *scenario.Exercise, workDir string,
 
 	// Environment-agnostic checks
 	switch check.Type {
+	case scenario.CheckAllOf, scenario.CheckAnyOf, scenario.CheckNot:
+		return runCompositeCheck(ctx, exercise, workDir, check)
 	case "script":
 		return runScriptCheck(ctx, check, workDir)
 	case "http":
@@ -57,66 +112,89 @@ func runCheck(ctx context.Context, exercise *scenario.Exercise, workDir string,
 
 	switch exercise.Spec.Environment.Type {
 	case "kubernetes":
-		if exercise.Spec.Environment.Kubernetes == nil {
-			result.Message = "missing kubernetes config"
-			return result
-		}
-		namespace := exercise.Spec.Environment.Kubernetes.Namespace
-		if namespace == "" {
-			namespace = "default"
-		}
-		if check.Namespace != "" {
-			namespace = check.Namespace
-		}
-
-		switch check.Type {
-		case "jsonpath":
-			return runJSONPathCheck(ctx, namespace, check)
-		case "condition":
-			return runConditionCheck(ctx, namespace, check)
-		case "resourceExists":
-			return runResourceExistsCheck(ctx, namespace, check)
-		case "podLogs":
-			return runPodLogsCheck(ctx, namespace, check)
-		case "exec":
-			return runKubernetesExecCheck(ctx, namespace, check)
-		default:
-			result.Message = fmt.Sprintf("unsupported check type: %s", check.Type)
-			return result
-		}
+		return runKubernetesCheck(ctx, exercise, check)
 	case "docker":
-		switch check.Type {
-		case "docker-image":
-			return runDockerImageCheck(ctx, check)
-		case "docker-container":
-			return runDockerContainerCheck(ctx, check)
-		case "docker-logs":
-			return runDockerLogsCheck(ctx, check)
-		case "dockerfile":
-			return runDockerfileCheck(check, workDir)
-		case "exec":
-			return runDockerExecCheck(ctx, check)
-		default:
-			result.Message = fmt.Sprintf("unsupported check type: %s", check.Type)
-			return result
-		}
+		return runDockerCheck(ctx, check, workDir)
+	case "hybrid":
+		// Hybrid exercises accept both families; docker-* checks and exec
+		// checks that target a container rather than a resource go to Docker.
+		if isDockerCheck(check) {
+			return runDockerCheck(ctx, check, workDir)
+		}
+		return runKubernetesCheck(ctx, exercise, check)
 	default:
 		result.Message = "unsupported environment for checks"
 		return result
 	}
 }
 
+func isDockerCheck(check scenario.Check) bool {
+	switch check.Type {
+	case "docker-image", "docker-container", "docker-logs", "dockerfile":
+		return true
+	case "exec":
+		return check.Resource == "" && check.Container != ""
+	}
+	return false
+}
+
+func runKubernetesCheck(ctx context.Context, exercise *scenario.Exercise, check scenario.Check) Result {
+	result := Result{Name: check.Name}
+	if exercise.Spec.Environment.Kubernetes == nil {
+		result.Message = "missing kubernetes config"
+		return result
+	}
+	namespace := exercise.Spec.Environment.Kubernetes.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	if check.Namespace != "" {
+		namespace = check.Namespace
+	}
+
+	switch check.Type {
+	case "jsonpath":
+		return runJSONPathCheck(ctx, namespace, check)
+	case "condition":
+		return runConditionCheck(ctx, namespace, check)
+	case "resourceExists":
+		return runResourceExistsCheck(ctx, namespace, check)
+	case "podLogs":
+		return runPodLogsCheck(ctx, namespace, check)
+	case "exec":
+		return runKubernetesExecCheck(ctx, namespace, check)
+	default:
+		result.Message = fmt.Sprintf("unsupported check type: %s", check.Type)
+		return result
+	}
+}
+
+func runDockerCheck(ctx context.Context, check scenario.Check, workDir string) Result {
+	result := Result{Name: check.Name}
+	switch check.Type {
+	case "docker-image":
+		return runDockerImageCheck(ctx, check)
+	case "docker-container":
+		return runDockerContainerCheck(ctx, check)
+	case "docker-logs":
+		return runDockerLogsCheck(ctx, check)
+	case "dockerfile":
+		return runDockerfileCheck(check, workDir)
+	case "exec":
+		return runDockerExecCheck(ctx, check)
+	default:
+		result.Message = fmt.Sprintf("unsupported check type: %s", check.Type)
+		return result
+	}
+}
+
 func runJSONPathCheck(ctx context.Context, namespace string, check scenario.Check) Result {
 	result := Result{Name: check.Name}
 	if check.Resource == "" || check.Jsonpath == "" {
 		result.Message = "missing resource or jsonpath"
 		return result
 	}
-	args := []string{"get", check.Resource, "-o", fmt.Sprintf("jsonpath=%s", check.Jsonpath)}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
-	}
-	output, err := runner.Run(ctx, "kubectl", args...)
+	output, err := kubeJSONPath(ctx, namespace, check.Resource, check.Jsonpath)
 	if err != nil {
 		result.Message = err.Error()
 		return result
@@ -139,11 +217,7 @@ func runConditionCheck(ctx context.Context, namespace string, check scenario.Che
 		status = "True"
 	}
 	jsonpath := fmt.Sprintf("{.status.conditions[?(@.type==\"%s\")].status}", check.Condition)
-	args := []string{"get", check.Resource, "-o", "jsonpath=" + jsonpath}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
-	}
-	output, err := runner.Run(ctx, "kubectl", args...)
+	output, err := kubeJSONPath(ctx, namespace, check.Resource, jsonpath)
 	if err != nil {
 		result.Message = err.Error()
 		return result
@@ -164,12 +238,7 @@ func runResourceExistsCheck(ctx context.Context, namespace string, check scenari
 		return result
 	}
 
-	args := []string{"get", check.Resource}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
-	}
-	_, err := runner.Run(ctx, "kubectl", args...)
-	exists := err == nil
+	exists := kubeExists(ctx, namespace, check.Resource)
 	expected := true
 	if check.Exists != nil {
 		expected = *check.Exists
@@ -284,64 +353,40 @@ func runDockerImageCheck(ctx context.Context, check scenario.Check) Result {
 		return result
 	}
 
+	image, err := inspectImage(ctx, check.Image)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
 	switch check.Property {
 	case "size":
-		output, err := runner.Run(ctx, "docker", "image", "inspect", check.Image, "--format", "{{.Size}}")
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		actualSize, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
-		if err != nil {
-			result.Message = fmt.Sprintf("invalid image size: %s", output)
-			return result
-		}
 		expectedSize, err := parseSize(fmt.Sprintf("%v", check.Value))
 		if err != nil {
 			result.Message = err.Error()
 			return result
 		}
-		passed, msg := compareInt(actualSize, expectedSize, check.Operator)
+		passed, msg := compareInt(image.Size, expectedSize, check.Operator)
 		result.Passed = passed
 		result.Message = msg
 		return result
 	case "layers":
-		output, err := runner.Run(ctx, "docker", "image", "inspect", check.Image, "--format", "{{len .RootFS.Layers}}")
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		actualLayers, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
-		if err != nil {
-			result.Message = fmt.Sprintf("invalid layer count: %s", output)
-			return result
-		}
 		expectedLayers, err := strconv.ParseInt(fmt.Sprintf("%v", check.Value), 10, 64)
 		if err != nil {
 			result.Message = fmt.Sprintf("invalid expected layers: %v", check.Value)
 			return result
 		}
-		passed, msg := compareInt(actualLayers, expectedLayers, check.Operator)
+		passed, msg := compareInt(int64(len(image.RootFS.Layers)), expectedLayers, check.Operator)
 		result.Passed = passed
 		result.Message = msg
 		return result
 	case "baseImage":
-		output, err := runner.Run(ctx, "docker", "image", "inspect", check.Image, "--format", "{{.ContainerConfig.Image}}")
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		passed, msg := compareValue(strings.TrimSpace(output), check.Operator, check.Value, "string")
+		passed, msg := compareValue(image.ContainerConfig.Image, check.Operator, check.Value, "string")
 		result.Passed = passed
 		result.Message = msg
 		return result
 	case "labels":
-		output, err := runner.Run(ctx, "docker", "image", "inspect", check.Image, "--format", "{{.Config.Labels}}")
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		passed, msg := compareValue(strings.TrimSpace(output), check.Operator, check.Value, "string")
+		passed, msg := compareValue(formatLabels(image.Config.Labels), check.Operator, check.Value, "string")
 		result.Passed = passed
 		result.Message = msg
 		return result
@@ -358,24 +403,20 @@ func runDockerContainerCheck(ctx context.Context, check scenario.Check) Result {
 		return result
 	}
 
+	container, err := inspectContainer(ctx, check.Container)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
 	switch check.Property {
 	case "state":
-		output, err := runner.Run(ctx, "docker", "inspect", check.Container, "--format", "{{.State.Status}}")
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		passed, msg := compareValue(strings.TrimSpace(output), check.Operator, check.Value, "string")
+		passed, msg := compareValue(container.State.Status, check.Operator, check.Value, "string")
 		result.Passed = passed
 		result.Message = msg
 		return result
 	case "health":
-		output, err := runner.Run(ctx, "docker", "inspect", check.Container, "--format", "{{.State.Health.Status}}")
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		value := strings.TrimSpace(output)
+		value := container.HealthStatus()
 		if check.Operator == "exists" {
 			if value != "" {
 				result.Passed = true
@@ -389,23 +430,12 @@ func runDockerContainerCheck(ctx context.Context, check scenario.Check) Result {
 		result.Message = msg
 		return result
 	case "exitCode":
-		output, err := runner.Run(ctx, "docker", "inspect", check.Container, "--format", "{{.State.ExitCode}}")
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		actual := strings.TrimSpace(output)
-		passed, msg := compareValue(actual, check.Operator, check.Value, "number")
+		passed, msg := compareValue(strconv.Itoa(container.State.ExitCode), check.Operator, check.Value, "number")
 		result.Passed = passed
 		result.Message = msg
 		return result
 	case "ports":
-		output, err := runner.Run(ctx, "docker", "port", check.Container)
-		if err != nil {
-			result.Message = err.Error()
-			return result
-		}
-		passed, msg := compareValue(strings.TrimSpace(output), check.Operator, check.Value, "string")
+		passed, msg := compareValue(container.PortSummary(), check.Operator, check.Value, "string")
 		result.Passed = passed
 		result.Message = msg
 		return result
@@ -421,12 +451,7 @@ func runDockerLogsCheck(ctx context.Context, check scenario.Check) Result {
 		result.Message = "missing container"
 		return result
 	}
-	args := []string{"logs"}
-	if check.Timeout != "" {
-		args = append(args, "--since", check.Timeout)
-	}
-	args = append(args, check.Container)
-	output, err := runner.Run(ctx, "docker", args...)
+	output, err := containerLogs(ctx, check.Container, check.Timeout)
 	if err != nil {
 		result.Message = err.Error()
 		return result
@@ -437,83 +462,6 @@ func runDockerLogsCheck(ctx context.Context, check scenario.Check) Result {
 	return result
 }
 
-func runDockerfileCheck(check scenario.Check, workDir string) Result {
-	result := Result{Name: check.Name}
-	if check.Path == "" {
-		result.Message = "missing dockerfile path"
-		return result
-	}
-	path := check.Path
-	if !filepath.IsAbs(path) {
-		path = filepath.Join(workDir, path)
-	}
-	data, err := os.ReadFile(path)
-	if err != nil {
-		result.Message = fmt.Sprintf("read dockerfile: %s", err)
-		return result
-	}
-	content := string(data)
-	lines := strings.Split(content, "\n")
-	fromCount := 0
-	userFound := false
-	copyFromFound := false
-	firstFrom := ""
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			continue
-		}
-		upper := strings.ToUpper(trimmed)
-		if strings.HasPrefix(upper, "FROM ") {
-			fromCount++
-			if firstFrom == "" {
-				firstFrom = strings.TrimSpace(trimmed[5:])
-			}
-		}
-		if strings.HasPrefix(upper, "USER ") {
-			userFound = true
-		}
-		if strings.Contains(upper, "COPY --FROM=") {
-			copyFromFound = true
-		}
-	}
-
-	switch check.Check {
-	case "multiStage":
-		actual := fromCount > 1
-		passed, msg := compareValue(fmt.Sprintf("%t", actual), check.Operator, check.Value, "string")
-		result.Passed = passed
-		result.Message = msg
-		return result
-	case "baseImage":
-		passed, msg := compareValue(firstFrom, check.Operator, check.Value, "string")
-		result.Passed = passed
-		result.Message = msg
-		return result
-	case "copyFrom":
-		passed, msg := compareValue(fmt.Sprintf("%t", copyFromFound), check.Operator, check.Value, "string")
-		result.Passed = passed
-		result.Message = msg
-		return result
-	case "userInstruction":
-		if check.Operator == "exists" {
-			if userFound {
-				result.Passed = true
-				return result
-			}
-			result.Message = "USER instruction not found"
-			return result
-		}
-		passed, msg := compareValue(fmt.Sprintf("%t", userFound), check.Operator, check.Value, "string")
-		result.Passed = passed
-		result.Message = msg
-		return result
-	default:
-		result.Message = fmt.Sprintf("unsupported dockerfile check: %s", check.Check)
-		return result
-	}
-}
-
 func compareInt(actual int64, expected int64, operator string) (bool, string) {
 	if operator == "" {
 		operator = "equals"
@@ -581,23 +529,7 @@ func runPodLogsCheck(ctx context.Context, namespace string, check scenario.Check
 		return result
 	}
 
-	args := []string{"logs"}
-	if check.Selector != "" {
-		args = append(args, "-l", check.Selector)
-	} else {
-		args = append(args, check.Resource)
-	}
-	if namespace != "" {
-		args = append(args, "-n", namespace)
-	}
-	if check.Container != "" {
-		args = append(args, "-c", check.Container)
-	}
-	if check.Timeout != "" {
-		args = append(args, "--since", check.Timeout)
-	}
-
-	output, err := runner.Run(ctx, "kubectl", args...)
+	output, err := kubeLogs(ctx, namespace, check.Selector, check.Resource, check.Container, check.Timeout)
 	if err != nil {
 		result.Message = err.Error()
 		return result
@@ -609,7 +541,9 @@ func runPodLogsCheck(ctx context.Context, namespace string, check scenario.Check
 	return result
 }
 
-// runKubernetesExecCheck runs a command in a Kubernetes pod
+// runKubernetesExecCheck runs a command in a Kubernetes pod. Exec needs a
+// streaming protocol the native client does not speak, so it always goes
+// through kubectl.
 func runKubernetesExecCheck(ctx context.Context, namespace string, check scenario.Check) Result {
 	result := Result{Name: check.Name}
 	if check.Resource == "" || len(check.Command) == 0 {
@@ -627,19 +561,14 @@ func runKubernetesExecCheck(ctx context.Context, namespace string, check scenari
 	args = append(args, "--")
 	args = append(args, check.Command...)
 
-	output, err := runner.Run(ctx, "kubectl", args...)
+	output, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...)
 
 	// Check exit code if specified
 	if check.ExpectExitCode != nil {
-		exitCode := 0
-		if err != nil {
-			var exitErr *exec.ExitError
-			if errors.As(err, &exitErr) {
-				exitCode = exitErr.ExitCode()
-			} else {
-				result.Message = err.Error()
-				return result
-			}
+		exitCode, ok := runner.ExitCode(err)
+		if !ok {
+			result.Message = err.Error()
+			return result
 		}
 		if exitCode != *check.ExpectExitCode {
 			result.Message = fmt.Sprintf("expected exit code %d, got %d", *check.ExpectExitCode, exitCode)
@@ -674,15 +603,10 @@ func runDockerExecCheck(ctx context.Context, check scenario.Check) Result {
 
 	// Check exit code if specified
 	if check.ExpectExitCode != nil {
-		exitCode := 0
-		if err != nil {
-			var exitErr *exec.ExitError
-			if errors.As(err, &exitErr) {
-				exitCode = exitErr.ExitCode()
-			} else {
-				result.Message = err.Error()
-				return result
-			}
+		exitCode, ok := runner.ExitCode(err)
+		if !ok {
+			result.Message = err.Error()
+			return result
 		}
 		if exitCode != *check.ExpectExitCode {
 			result.Message = fmt.Sprintf("expected exit code %d, got %d", *check.ExpectExitCode, exitCode)
@@ -702,7 +626,8 @@ func runDockerExecCheck(ctx context.Context, check scenario.Check) Result {
 	return result
 }
 
-// runScriptCheck runs a custom shell script
+// runScriptCheck runs a custom shell script, with kubectl pinned to the
+// exercise's cluster.
 func runScriptCheck(ctx context.Context, check scenario.Check, workDir string) Result {
 	result := Result{Name: check.Name}
 	if check.Script == "" {
@@ -710,19 +635,20 @@ func runScriptCheck(ctx context.Context, check scenario.Check, workDir string) R
 		return result
 	}
 
-	output, err := runner.RunInDir(ctx, workDir, "bash", "-c", check.Script)
+	env, cleanup, err := kube.ScriptEnv(ctx)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	defer cleanup()
+	output, err := runner.RunWithEnv(ctx, workDir, env, "bash", "-c", check.Script)
 
 	// Check exit code if specified
 	if check.ExpectExitCode != nil {
-		exitCode := 0
-		if err != nil {
-			var exitErr *exec.ExitError
-			if errors.As(err, &exitErr) {
-				exitCode = exitErr.ExitCode()
-			} else {
-				result.Message = err.Error()
-				return result
-			}
+		exitCode, ok := runner.ExitCode(err)
+		if !ok {
+			result.Message = err.Error()
+			return result
 		}
 		if exitCode != *check.ExpectExitCode {
 			result.Message = fmt.Sprintf("expected exit code %d, got %d", *check.ExpectExitCode, exitCode)
@@ -8,6 +8,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/dockerapi/dockertest"
+	"gymctl/internal/kube"
+	"gymctl/internal/kube/kubetest"
+	"gymctl/internal/runner"
 	"gymctl/internal/scenario"
 )
 
@@ -580,3 +585,227 @@ func TestRunScriptCheck(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestRunCheckHybridDispatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "Dockerfile"), []byte("FROM alpine:3.18\n"), 0644); err != nil {
+		t.Fatalf("failed to write Dockerfile: %v", err)
+	}
+
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{Type: "hybrid"},
+	}}
+
+	// dockerfile checks run against the work directory in hybrid exercises
+	result := runCheck(context.Background(), exercise, tmpDir, scenario.Check{
+		Name: "base", Type: "dockerfile", Path: "Dockerfile", Check: "baseImage", Value: "alpine:3.18",
+	})
+	if !result.Passed {
+		t.Errorf("expected dockerfile check to pass in hybrid exercise, got %q", result.Message)
+	}
+
+	// kubernetes checks still need their environment config
+	result = runCheck(context.Background(), exercise, tmpDir, scenario.Check{Name: "pod", Type: "jsonpath"})
+	if result.Passed || result.Message != "missing kubernetes config" {
+		t.Errorf("expected kubernetes dispatch, got %+v", result)
+	}
+}
+
+func TestIsDockerCheck(t *testing.T) {
+	tests := []struct {
+		check scenario.Check
+		want  bool
+	}{
+		{check: scenario.Check{Type: "docker-image"}, want: true},
+		{check: scenario.Check{Type: "dockerfile"}, want: true},
+		{check: scenario.Check{Type: "exec", Container: "web"}, want: true},
+		{check: scenario.Check{Type: "exec", Resource: "pod/web", Container: "app"}, want: false},
+		{check: scenario.Check{Type: "jsonpath"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := isDockerCheck(tt.check); got != tt.want {
+			t.Errorf("isDockerCheck(%+v) = %v, want %v", tt.check, got, tt.want)
+		}
+	}
+}
+
+func TestKubernetesChecksWithReplayedCommands(t *testing.T) {
+	replayer := runner.NewReplayer(runner.Fixture{Interactions: []runner.Interaction{
+		{
+			Command:  "kubectl",
+			Args:     []string{"get", "deployment/web", "-o", "jsonpath={.spec.template.spec.containers[0].resources.limits.memory}", "-n", "jerry"},
+			Combined: "256Mi",
+		},
+		{
+			Command:  "kubectl",
+			Args:     []string{"exec", "pod/web", "-n", "jerry", "--", "cat", "/etc/config"},
+			Combined: "permission denied",
+			ExitCode: 1,
+		},
+	}})
+	ctx := kube.WithKubectl(runner.WithExecutor(context.Background(), replayer))
+
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{
+			Type:       "kubernetes",
+			Kubernetes: &scenario.KubernetesSpec{Namespace: "jerry"},
+		},
+	}}
+	expectExit := 1
+
+	limit := runCheck(ctx, exercise, "", scenario.Check{
+		Name:     "memory limit",
+		Type:     "jsonpath",
+		Resource: "deployment/web",
+		Jsonpath: "{.spec.template.spec.containers[0].resources.limits.memory}",
+		Operator: "equals",
+		Value:    "256Mi",
+	})
+	if !limit.Passed {
+		t.Errorf("jsonpath check failed: %s", limit.Message)
+	}
+
+	denied := runCheck(ctx, exercise, "", scenario.Check{
+		Name:           "config unreadable",
+		Type:           "exec",
+		Resource:       "pod/web",
+		Command:        []string{"cat", "/etc/config"},
+		ExpectExitCode: &expectExit,
+	})
+	if !denied.Passed {
+		t.Errorf("exec check failed: %s", denied.Message)
+	}
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("expected commands were not run: %+v", unused)
+	}
+}
+
+func TestJSONPathFallsBackToKubectl(t *testing.T) {
+	server := kubetest.NewServer(t)
+	server.Set(kube.Object{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "jerry"},
+	})
+	replayer := runner.NewReplayer(runner.Fixture{Interactions: []runner.Interaction{
+		{
+			Command:  "kubectl",
+			Args:     []string{"get", "pods", "-o", "jsonpath={.items[*].metadata.name}", "-n", "jerry"},
+			Combined: "web",
+		},
+		{
+			Command:  "kubectl",
+			Args:     []string{"get", "pod/web", "-o", "jsonpath={range .status.conditions[*]}{.type}{end}", "-n", "jerry"},
+			Combined: "Ready",
+		},
+	}})
+	ctx := kube.WithClient(runner.WithExecutor(context.Background(), replayer), server.Client())
+
+	tests := []struct {
+		resource, template, want string
+	}{
+		{"pod/web", "{.metadata.name}", "web"},
+		{"pods", "{.items[*].metadata.name}", "web"},
+		{"pod/web", "{range .status.conditions[*]}{.type}{end}", "Ready"},
+	}
+	for _, tt := range tests {
+		got, err := kubeJSONPath(ctx, "jerry", tt.resource, tt.template)
+		if err != nil || got != tt.want {
+			t.Errorf("kubeJSONPath(%q, %q) = %q, %v, want %q", tt.resource, tt.template, got, err, tt.want)
+		}
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("expected commands were not run: %+v", unused)
+	}
+}
+
+func TestKubernetesChecksWithFakeAPIServer(t *testing.T) {
+	server := kubetest.NewServer(t)
+	server.Set(kube.Object{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "app-config", "namespace": "jerry"},
+		"data":       map[string]interface{}{"DATABASE_HOST": "postgres"},
+	})
+	server.Set(kube.Object{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "jerry"},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "False"},
+		}},
+	})
+	server.Set(kube.Object{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "web-1", "namespace": "jerry", "labels": map[string]interface{}{"app": "web"}},
+	})
+	server.SetLogs("jerry", "web-1", "listening on :8080\n")
+	ctx := kube.WithClient(context.Background(), server.Client())
+
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{
+			Type:       "kubernetes",
+			Kubernetes: &scenario.KubernetesSpec{Namespace: "jerry"},
+		},
+	}}
+	notExists := false
+
+	tests := []struct {
+		check scenario.Check
+		want  bool
+	}{
+		{scenario.Check{Name: "host", Type: "jsonpath", Resource: "configmap/app-config", Jsonpath: "{.data.DATABASE_HOST}", Value: "postgres"}, true},
+		{scenario.Check{Name: "missing key", Type: "jsonpath", Resource: "configmap/app-config", Jsonpath: "{.data.PORT}", Operator: "exists"}, false},
+		{scenario.Check{Name: "available", Type: "condition", Resource: "deployment/web", Condition: "Available"}, false},
+		{scenario.Check{Name: "unavailable", Type: "condition", Resource: "deployment/web", Condition: "Available", Status: "False"}, true},
+		{scenario.Check{Name: "namespace gone", Type: "resourceExists", Resource: "namespace/backend", Exists: &notExists}, true},
+		{scenario.Check{Name: "logs", Type: "podLogs", Selector: "app=web", Operator: "contains", Value: "listening"}, true},
+	}
+	for _, tt := range tests {
+		result := runCheck(ctx, exercise, "", tt.check)
+		if result.Passed != tt.want {
+			t.Errorf("%s: passed = %t, want %t (%s)", tt.check.Name, result.Passed, tt.want, result.Message)
+		}
+	}
+}
+
+func TestDockerChecksWithFakeEngine(t *testing.T) {
+	server := dockertest.NewServer(t)
+	image := dockerapi.Image{Size: 48 * 1024 * 1024}
+	image.RootFS.Layers = []string{"sha256:a", "sha256:b", "sha256:c"}
+	image.Config.Labels = map[string]string{"version": "1.0", "maintainer": "jerry"}
+	server.AddImage("jerry-app:latest", image)
+
+	container := dockerapi.Container{}
+	container.State.Status = "exited"
+	container.State.ExitCode = 137
+	container.NetworkSettings.Ports = map[string][]dockerapi.PortBinding{"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}}}
+	server.AddContainer("jerry-web", container, "OOMKilled while loading cache\n")
+	ctx := dockerapi.WithClient(context.Background(), server.Client())
+
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{Type: "docker"},
+	}}
+	tests := []struct {
+		check scenario.Check
+		want  bool
+	}{
+		{scenario.Check{Name: "small image", Type: "docker-image", Image: "jerry-app", Property: "size", Operator: "lessThan", Value: "50MB"}, true},
+		{scenario.Check{Name: "few layers", Type: "docker-image", Image: "jerry-app", Property: "layers", Operator: "lessThan", Value: 3}, false},
+		{scenario.Check{Name: "labels", Type: "docker-image", Image: "jerry-app", Property: "labels", Operator: "contains", Value: "version:1.0"}, true},
+		{scenario.Check{Name: "state", Type: "docker-container", Container: "jerry-web", Property: "state", Value: "exited"}, true},
+		{scenario.Check{Name: "exit code", Type: "docker-container", Container: "jerry-web", Property: "exitCode", Value: 137}, true},
+		{scenario.Check{Name: "no health", Type: "docker-container", Container: "jerry-web", Property: "health", Operator: "exists"}, false},
+		{scenario.Check{Name: "ports", Type: "docker-container", Container: "jerry-web", Property: "ports", Operator: "contains", Value: "0.0.0.0:8080"}, true},
+		{scenario.Check{Name: "logs", Type: "docker-logs", Container: "jerry-web", Operator: "contains", Value: "OOMKilled"}, true},
+		{scenario.Check{Name: "missing", Type: "docker-container", Container: "nope", Property: "state", Value: "running"}, false},
+	}
+	for _, tt := range tests {
+		result := runCheck(ctx, exercise, "", tt.check)
+		if result.Passed != tt.want {
+			t.Errorf("%s: passed = %t, want %t (%s)", tt.check.Name, result.Passed, tt.want, result.Message)
+		}
+	}
+}
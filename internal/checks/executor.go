@@ -0,0 +1,194 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gymctl/internal/scenario"
+)
+
+// DefaultWorkers bounds how many checks RunExerciseChecks runs at once.
+const DefaultWorkers = 4
+
+// Executor runs the checks of an exercise on a bounded worker pool.
+//
+// Checks run concurrently unless they say otherwise: a check waits for the
+// checks named in its dependsOn and is skipped when one of them fails, and
+// serial checks run alone, one after another in declaration order. Results
+// are always returned in declaration order.
+type Executor struct {
+	Workers int
+}
+
+type checkNode struct {
+	check scenario.Check
+	// deps must pass before the check runs; after only has to finish.
+	deps  []int
+	after []int
+	err   error
+	done  chan struct{}
+}
+
+// Run executes the exercise checks and returns their results in declaration
+// order.
+func (e Executor) Run(ctx context.Context, exercise *scenario.Exercise, workDir string) []Result {
+	nodes := planChecks(exercise.Spec.Checks)
+	results := make([]Result, len(nodes))
+
+	workers := e.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	slots := make(chan struct{}, workers)
+	var exclusive sync.RWMutex
+	var wg sync.WaitGroup
+
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			node := nodes[i]
+			defer close(node.done)
+
+			name := node.check.Name
+			if name == "" {
+				name = node.check.Type
+			}
+			if node.err != nil {
+				results[i] = Result{Name: name, Type: node.check.Type, Message: node.err.Error()}
+				return
+			}
+
+			for _, dep := range node.after {
+				<-nodes[dep].done
+			}
+			for _, dep := range node.deps {
+				<-nodes[dep].done
+				if !results[dep].Passed {
+					results[i] = Result{Name: name, Type: node.check.Type, Message: fmt.Sprintf("skipped: dependency %q did not pass", results[dep].Name)}
+					return
+				}
+			}
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if node.check.Serial {
+				exclusive.Lock()
+				defer exclusive.Unlock()
+			} else {
+				exclusive.RLock()
+				defer exclusive.RUnlock()
+			}
+
+			results[i] = runGuarded(name, node.check.Type, func() Result {
+				return runCheck(ctx, exercise, workDir, node.check)
+			})
+		}(i)
+	}
+
+	wg.Wait()
+	for i, node := range nodes {
+		results[i].Optional = !node.check.IsRequired()
+	}
+	return results
+}
+
+// runGuarded turns a panic in a check into a failed result. Checks run on
+// their own goroutines, where a panic would otherwise take down gymctl.
+func runGuarded(name, checkType string, run func() Result) (result Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = Result{Name: name, Type: checkType, Message: fmt.Sprintf("check panicked: %v", r)}
+		}
+	}()
+	return run()
+}
+
+// planChecks resolves dependsOn names and the implicit ordering of serial
+// checks into indexes. Checks with unknown dependencies or that take part in
+// a dependency cycle are marked with an error instead of being scheduled.
+func planChecks(checks []scenario.Check) []*checkNode {
+	nodes := make([]*checkNode, len(checks))
+	byName := make(map[string]int, len(checks))
+	for i, check := range checks {
+		nodes[i] = &checkNode{check: check, done: make(chan struct{})}
+		if _, seen := byName[check.Name]; !seen && check.Name != "" {
+			byName[check.Name] = i
+		}
+	}
+
+	lastSerial := -1
+	for i, node := range nodes {
+		for _, name := range node.check.DependsOn {
+			dep, ok := byName[name]
+			if !ok {
+				node.err = fmt.Errorf("unknown dependency %q", name)
+				break
+			}
+			node.deps = append(node.deps, dep)
+		}
+		if node.check.Serial {
+			if lastSerial >= 0 {
+				node.after = append(node.after, lastSerial)
+			}
+			lastSerial = i
+		}
+	}
+
+	markCycles(nodes)
+	return nodes
+}
+
+// markCycles fails every check on a dependency cycle, so that no goroutine
+// waits on a check that can never finish.
+func markCycles(nodes []*checkNode) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(nodes))
+	var stack []int
+
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = visiting
+		stack = append(stack, i)
+		edges := append(append([]int{}, nodes[i].deps...), nodes[i].after...)
+		for _, next := range edges {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				start := 0
+				for j := range stack {
+					if stack[j] == next {
+						start = j
+						break
+					}
+				}
+				cycle := append(append([]int{}, stack[start:]...), next)
+				names := make([]string, len(cycle))
+				for j, index := range cycle {
+					names[j] = nodes[index].check.Name
+				}
+				err := fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+				for _, index := range cycle {
+					if nodes[index].err == nil {
+						nodes[index].err = err
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+	}
+
+	for i := range nodes {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package checks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gymctl/internal/scenario"
+)
+
+func scriptCheck(name, script string) scenario.Check {
+	return scenario.Check{Name: name, Type: "script", Script: script}
+}
+
+func runChecks(t *testing.T, workers int, checks ...scenario.Check) []Result {
+	t.Helper()
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{Type: "docker"},
+		Checks:      checks,
+	}}
+	return Executor{Workers: workers}.Run(context.Background(), exercise, t.TempDir())
+}
+
+func TestExecutorKeepsDeclarationOrder(t *testing.T) {
+	results := runChecks(t, 4,
+		scriptCheck("slow", "sleep 0.2"),
+		scriptCheck("fast", "true"),
+		scriptCheck("failing", "exit 1"),
+	)
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	if strings.Join(names, ",") != "slow,fast,failing" {
+		t.Errorf("results out of order: %v", names)
+	}
+	if !results[0].Passed || !results[1].Passed || results[2].Passed {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestExecutorRunsConcurrently(t *testing.T) {
+	start := time.Now()
+	results := runChecks(t, 4,
+		scriptCheck("a", "sleep 0.3"),
+		scriptCheck("b", "sleep 0.3"),
+		scriptCheck("c", "sleep 0.3"),
+		scriptCheck("d", "sleep 0.3"),
+	)
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("checks did not run concurrently, took %s", elapsed)
+	}
+	for _, result := range results {
+		if !result.Passed {
+			t.Errorf("%s failed: %s", result.Name, result.Message)
+		}
+	}
+}
+
+func TestExecutorDependsOn(t *testing.T) {
+	dependent := scriptCheck("reads marker", "test -f marker")
+	dependent.DependsOn = []string{"writes marker"}
+	skipped := scriptCheck("after failure", "true")
+	skipped.DependsOn = []string{"fails"}
+
+	results := runChecks(t, 4,
+		dependent,
+		scriptCheck("writes marker", "sleep 0.1 && touch marker"),
+		scriptCheck("fails", "exit 1"),
+		skipped,
+	)
+	if !results[0].Passed {
+		t.Errorf("dependent check ran before its dependency: %s", results[0].Message)
+	}
+	if results[3].Passed || !strings.Contains(results[3].Message, `dependency "fails"`) {
+		t.Errorf("expected check to be skipped, got %+v", results[3])
+	}
+}
+
+func TestExecutorSerialChecksRunAlone(t *testing.T) {
+	workDir := t.TempDir()
+	exclusive := "mkdir lock && sleep 0.1 && rmdir lock"
+	first := scriptCheck("first", exclusive+" && echo first >> order")
+	first.Serial = true
+	second := scriptCheck("second", exclusive+" && echo second >> order")
+	second.Serial = true
+
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Environment: scenario.EnvironmentSpec{Type: "docker"},
+		Checks: []scenario.Check{
+			first,
+			scriptCheck("parallel", "sleep 0.05 && test ! -d lock"),
+			second,
+			scriptCheck("parallel again", "test ! -d lock"),
+		},
+	}}
+	results := Executor{Workers: 4}.Run(context.Background(), exercise, workDir)
+	for _, result := range results {
+		if !result.Passed {
+			t.Errorf("%s failed: %s", result.Name, result.Message)
+		}
+	}
+
+	order, err := os.ReadFile(filepath.Join(workDir, "order"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(order) != "first\nsecond\n" {
+		t.Errorf("serial checks ran out of order: %q", order)
+	}
+}
+
+func TestExecutorInvalidDependencies(t *testing.T) {
+	unknown := scriptCheck("unknown", "true")
+	unknown.DependsOn = []string{"missing"}
+	a := scriptCheck("a", "true")
+	a.DependsOn = []string{"b"}
+	b := scriptCheck("b", "true")
+	b.DependsOn = []string{"a"}
+	dependent := scriptCheck("dependent", "true")
+	dependent.DependsOn = []string{"a"}
+
+	done := make(chan []Result, 1)
+	go func() { done <- runChecks(t, 2, unknown, a, b, dependent, scriptCheck("independent", "true")) }()
+
+	var results []Result
+	select {
+	case results = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("executor deadlocked on a dependency cycle")
+	}
+
+	if results[0].Passed || results[0].Message != `unknown dependency "missing"` {
+		t.Errorf("unexpected result for unknown dependency: %+v", results[0])
+	}
+	if results[1].Passed || !strings.Contains(results[1].Message, "dependency cycle: a -> b -> a") {
+		t.Errorf("unexpected result for cycle: %+v", results[1])
+	}
+	if results[2].Passed || !strings.Contains(results[2].Message, "dependency cycle") {
+		t.Errorf("unexpected result for cycle: %+v", results[2])
+	}
+	if results[3].Passed {
+		t.Errorf("check depending on a cycle should not pass")
+	}
+	if !results[4].Passed {
+		t.Errorf("independent check should pass: %s", results[4].Message)
+	}
+}
+
+func TestExecutorMarksOptionalChecks(t *testing.T) {
+	optional := false
+	extra := scriptCheck("extra credit", "exit 1")
+	extra.Required = &optional
+
+	results := runChecks(t, 2, scriptCheck("required", "true"), extra)
+	if results[0].Optional || !results[1].Optional {
+		t.Errorf("unexpected optional flags: %+v", results)
+	}
+	if !RequiredPassed(results) {
+		t.Error("a failed optional check must not block completion")
+	}
+
+	results = runChecks(t, 2, scriptCheck("required", "exit 1"), extra)
+	if RequiredPassed(results) {
+		t.Error("a failed required check must block completion")
+	}
+}
+
+func TestRunGuardedRecoversPanics(t *testing.T) {
+	result := runGuarded("boom", "script", func() Result {
+		var check *scenario.Check
+		return Result{Name: check.Name}
+	})
+	if result.Passed || result.Name != "boom" || !strings.Contains(result.Message, "check panicked") {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result := runGuarded("fine", "script", func() Result { return Result{Name: "fine", Passed: true} }); !result.Passed {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
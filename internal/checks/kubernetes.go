@@ -0,0 +1,86 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gymctl/internal/kube"
+	"gymctl/internal/runner"
+)
+
+// The helpers below use the native client when a kubeconfig is available
+// and fall back to kubectl otherwise.
+
+// kubeJSONPath evaluates template natively for a named object. kubectl
+// handles collections such as "pods" and the templates kube.JSONPath does
+// not implement.
+func kubeJSONPath(ctx context.Context, namespace, resource, template string) (string, error) {
+	if client, err := kube.ClientFor(ctx); err == nil {
+		if _, name := kube.SplitRef(resource); name != "" {
+			obj, err := client.Get(ctx, namespace, resource)
+			if err != nil {
+				return "", err
+			}
+			output, err := kube.JSONPath(template, obj)
+			if !errors.Is(err, kube.ErrUnsupportedJSONPath) {
+				return output, err
+			}
+		}
+	}
+
+	args := []string{"get", resource, "-o", "jsonpath=" + template}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	return runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...)
+}
+
+func kubeExists(ctx context.Context, namespace, resource string) bool {
+	if client, err := kube.ClientFor(ctx); err == nil {
+		exists, _ := client.Exists(ctx, namespace, resource)
+		return exists
+	}
+
+	args := []string{"get", resource}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	_, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...)
+	return err == nil
+}
+
+func kubeLogs(ctx context.Context, namespace, selector, resource, container, since string) (string, error) {
+	if client, err := kube.ClientFor(ctx); err == nil {
+		opts := kube.LogOptions{Container: container}
+		if since != "" {
+			duration, err := time.ParseDuration(since)
+			if err != nil {
+				return "", fmt.Errorf("invalid timeout %q: %w", since, err)
+			}
+			opts.Since = duration
+		}
+		if selector != "" {
+			return client.SelectorLogs(ctx, namespace, selector, opts)
+		}
+		return client.Logs(ctx, namespace, resource, opts)
+	}
+
+	args := []string{"logs"}
+	if selector != "" {
+		args = append(args, "-l", selector)
+	} else {
+		args = append(args, resource)
+	}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	if since != "" {
+		args = append(args, "--since", since)
+	}
+	return runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...)
+}
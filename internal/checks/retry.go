@@ -0,0 +1,85 @@
+package checks
+
+import (
+	"context"
+	"time"
+
+	"gymctl/internal/scenario"
+)
+
+const (
+	defaultRetryInterval = time.Second
+	maxRetryInterval     = 10 * time.Second
+)
+
+// retryPolicy describes how a check is polled before its result is final.
+// Checks without retries or a deadline are attempted exactly once.
+type retryPolicy struct {
+	retries  int
+	deadline time.Duration
+	interval time.Duration
+}
+
+// pollsUntilTimeout reports whether a check type treats its timeout as a
+// polling deadline. Log checks use the timeout as their --since window and
+// file checks cannot change while gymctl is waiting.
+func pollsUntilTimeout(checkType string) bool {
+	switch checkType {
+	case "podLogs", "docker-logs", "file", "dockerfile":
+		return false
+	}
+	return true
+}
+
+func policyFor(check scenario.Check) retryPolicy {
+	policy := retryPolicy{retries: check.Retries, interval: defaultRetryInterval}
+	if check.Interval != "" {
+		if d, err := time.ParseDuration(check.Interval); err == nil && d > 0 {
+			policy.interval = d
+		}
+	}
+	if check.Timeout != "" && pollsUntilTimeout(check.Type) {
+		if d, err := time.ParseDuration(check.Timeout); err == nil && d > 0 {
+			policy.deadline = d
+		}
+	}
+	return policy
+}
+
+// runWithRetry attempts a check until it passes, its retries are used up or
+// its deadline expires, doubling the wait between attempts up to
+// maxRetryInterval. The last result is returned with the attempt count.
+func runWithRetry(ctx context.Context, policy retryPolicy, attempt func() Result) Result {
+	deadline := time.Now().Add(policy.deadline)
+	wait := policy.interval
+	for attempts := 1; ; attempts++ {
+		result := attempt()
+		result.Attempts = attempts
+		if result.Passed {
+			return result
+		}
+
+		if policy.retries == 0 && policy.deadline == 0 {
+			return result
+		}
+		if policy.retries > 0 && attempts > policy.retries {
+			return result
+		}
+		if policy.deadline > 0 && time.Now().Add(wait).After(deadline) {
+			return result
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result
+		case <-timer.C:
+		}
+
+		wait *= 2
+		if wait > maxRetryInterval {
+			wait = maxRetryInterval
+		}
+	}
+}
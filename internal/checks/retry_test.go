@@ -0,0 +1,80 @@
+package checks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gymctl/internal/scenario"
+)
+
+func TestPolicyFor(t *testing.T) {
+	policy := policyFor(scenario.Check{Type: "condition", Timeout: "30s", Retries: 2, Interval: "500ms"})
+	if policy.deadline != 30*time.Second || policy.retries != 2 || policy.interval != 500*time.Millisecond {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+
+	// podLogs uses its timeout as the --since window, not as a deadline
+	if policy := policyFor(scenario.Check{Type: "podLogs", Timeout: "5m"}); policy.deadline != 0 {
+		t.Errorf("expected no deadline for podLogs, got %s", policy.deadline)
+	}
+
+	if policy := policyFor(scenario.Check{Type: "http", Interval: "soon"}); policy.interval != defaultRetryInterval {
+		t.Errorf("expected default interval for invalid value, got %s", policy.interval)
+	}
+}
+
+func TestRunWithRetry(t *testing.T) {
+	passOn := func(n int, calls *int) func() Result {
+		return func() Result {
+			*calls++
+			return Result{Passed: *calls >= n}
+		}
+	}
+
+	t.Run("single attempt without policy", func(t *testing.T) {
+		calls := 0
+		result := runWithRetry(context.Background(), retryPolicy{interval: time.Millisecond}, passOn(3, &calls))
+		if result.Passed || calls != 1 || result.Attempts != 1 {
+			t.Errorf("got passed=%t calls=%d attempts=%d", result.Passed, calls, result.Attempts)
+		}
+	})
+
+	t.Run("retries until pass", func(t *testing.T) {
+		calls := 0
+		result := runWithRetry(context.Background(), retryPolicy{retries: 5, interval: time.Millisecond}, passOn(3, &calls))
+		if !result.Passed || result.Attempts != 3 {
+			t.Errorf("got passed=%t attempts=%d", result.Passed, result.Attempts)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		calls := 0
+		result := runWithRetry(context.Background(), retryPolicy{retries: 2, interval: time.Millisecond}, passOn(10, &calls))
+		if result.Passed || result.Attempts != 3 {
+			t.Errorf("got passed=%t attempts=%d", result.Passed, result.Attempts)
+		}
+	})
+
+	t.Run("polls until deadline", func(t *testing.T) {
+		calls := 0
+		start := time.Now()
+		result := runWithRetry(context.Background(), retryPolicy{deadline: 50 * time.Millisecond, interval: 5 * time.Millisecond}, passOn(1000, &calls))
+		if result.Passed || result.Attempts < 2 {
+			t.Errorf("got passed=%t attempts=%d", result.Passed, result.Attempts)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("polling overran its deadline: %s", elapsed)
+		}
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		calls := 0
+		result := runWithRetry(ctx, retryPolicy{retries: 5, interval: time.Second}, passOn(10, &calls))
+		if result.Attempts != 1 {
+			t.Errorf("expected a single attempt, got %d", result.Attempts)
+		}
+	})
+}
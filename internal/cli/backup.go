@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gymctl/internal/backup"
+	"gymctl/internal/config"
+	"gymctl/internal/progress"
+)
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "List, create and prune snapshots of exercise work",
+		Long: `Snapshots hold an exercise's work directory and its progress entry.
+gymctl takes one automatically before reset, recover and stop remove a
+work directory, and keeps the newest few of those per exercise
+(backups.keep and backups.maxAge in ~/.gym/config.yaml). Snapshots taken
+with backup create are only removed by backup prune. Restore one with
+gymctl recover <exercise> --backup <name>.`,
+	}
+	cmd.AddCommand(newBackupListCmd(), newBackupCreateCmd(), newBackupPruneCmd())
+	return cmd
+}
+
+func newBackupListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [exercise-name]",
+		Short: "List snapshots, newest first",
+		Args:  cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := resolveBackupDir()
+			if err != nil {
+				return err
+			}
+			exercise := ""
+			if len(args) == 1 {
+				exercise = args[0]
+			}
+			backups, err := backup.List(dir, exercise)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if len(backups) == 0 {
+				fmt.Fprintln(out, "No backups found.")
+				return nil
+			}
+			now := time.Now()
+			fmt.Fprintf(out, "%-50s %-30s %-8s %8s %10s\n", "NAME", "EXERCISE", "REASON", "AGE", "SIZE")
+			for _, b := range backups {
+				reason := b.Reason
+				if reason == "" {
+					reason = "-"
+				}
+				fmt.Fprintf(out, "%-50s %-30s %-8s %8s %10s\n", b.Name(), b.Exercise, reason, humanizeAge(now.Sub(b.Created)), humanizeBytes(b.Size))
+			}
+			ColorDim.Fprintf(out, "\nStored in %s\n", dir)
+			return nil
+		},
+	}
+}
+
+func newBackupCreateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create [exercise-name]",
+		Short: "Snapshot an exercise's work directory and progress",
+		Args:  cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			} else {
+				current, err := loadCurrentExercise()
+				if err != nil {
+					return fmt.Errorf("no exercise specified and no current exercise set")
+				}
+				name = current
+			}
+			created, err := snapshotExercise(name, backup.ReasonManual)
+			if err != nil {
+				return err
+			}
+			ColorSuccess.Fprintf(cmd.OutOrStdout(), "✓ Created backup %s (%s)\n", created.Name(), humanizeBytes(created.Size))
+			return nil
+		},
+	}
+}
+
+func newBackupPruneCmd() *cobra.Command {
+	var keep int
+	var olderThan string
+	cmd := &cobra.Command{
+		Use:   "prune [exercise-name]",
+		Short: "Delete snapshots beyond the retention policy",
+		Args:  cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy, err := backupPolicy()
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("keep") {
+				policy.Keep = keep
+			}
+			if olderThan != "" {
+				if policy.MaxAge, err = config.ParseAge(olderThan); err != nil {
+					return fmt.Errorf("--older-than: %w", err)
+				}
+			}
+			dir, err := resolveBackupDir()
+			if err != nil {
+				return err
+			}
+			exercise := ""
+			if len(args) == 1 {
+				exercise = args[0]
+			}
+			removed, err := backup.Prune(dir, exercise, policy)
+			for _, b := range removed {
+				ColorDim.Fprintf(cmd.OutOrStdout(), "  removed %s\n", b.Name())
+			}
+			if err != nil {
+				return err
+			}
+			ColorSuccess.Fprintf(cmd.OutOrStdout(), "✓ Pruned %d backup(s)\n", len(removed))
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&keep, "keep", 0, "Snapshots to keep per exercise (0 keeps all)")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Also delete snapshots older than this, e.g. 72h or 30d")
+	return cmd
+}
+
+// backupPolicy returns the retention policy from the config file.
+func backupPolicy() (backup.Policy, error) {
+	cfg, err := loadGymConfig()
+	if err != nil {
+		return backup.Policy{}, err
+	}
+	maxAge, err := cfg.Backups.MaxAgeDuration()
+	if err != nil {
+		return backup.Policy{}, err
+	}
+	return backup.Policy{Keep: cfg.Backups.KeepCount(), MaxAge: maxAge}, nil
+}
+
+// snapshotExercise archives the exercise's work directory together with its
+// progress entry.
+func snapshotExercise(name, reason string) (backup.Backup, error) {
+	dir, err := resolveBackupDir()
+	if err != nil {
+		return backup.Backup{}, err
+	}
+	workDir, err := resolveWorkDir(name)
+	if err != nil {
+		return backup.Backup{}, err
+	}
+	meta := backup.Metadata{Exercise: name, Reason: reason}
+	if path, err := resolveProgressFile(); err == nil {
+		if file, err := progress.Load(path); err == nil {
+			if status, ok := file.Exercises[name]; ok {
+				meta.Progress = &status
+			}
+		}
+	}
+	return backup.Create(dir, workDir, meta)
+}
+
+// snapshotBeforeWipe snapshots a work directory that is about to be removed
+// and applies the retention policy. Failing to take a snapshot stops the
+// destructive command.
+func snapshotBeforeWipe(cmd *cobra.Command, name, reason string) error {
+	if err := snapshotWork(cmd, name, reason); err != nil {
+		return err
+	}
+	pruneBackups(cmd, name)
+	return nil
+}
+
+// snapshotWork snapshots a work directory without pruning. An empty or
+// missing work directory is not worth a snapshot.
+func snapshotWork(cmd *cobra.Command, name, reason string) error {
+	workDir, err := resolveWorkDir(name)
+	if err != nil {
+		return err
+	}
+	if entries, err := os.ReadDir(workDir); err != nil || len(entries) == 0 {
+		return nil
+	}
+	created, err := snapshotExercise(name, reason)
+	if err != nil {
+		return fmt.Errorf("back up work directory: %w", err)
+	}
+	ColorDim.Fprintf(cmd.OutOrStdout(), "Saved a backup of your work: %s\n", created.Name())
+	return nil
+}
+
+// pruneBackups applies the configured retention policy to the automatic
+// snapshots of one exercise, warning instead of failing.
+func pruneBackups(cmd *cobra.Command, name string) {
+	policy, err := backupPolicy()
+	if err == nil {
+		policy.Automatic = true
+		var dir string
+		if dir, err = resolveBackupDir(); err == nil {
+			_, err = backup.Prune(dir, name, policy)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to prune backups: %v\n", err)
+	}
+}
+
+// resolveBackupFile accepts a path to an archive or the name of one in the
+// backup directory.
+func resolveBackupFile(value string) (string, error) {
+	if _, err := os.Stat(value); err == nil {
+		return value, nil
+	}
+	dir, err := resolveBackupDir()
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range []string{value, value + ".tar.gz"} {
+		path := filepath.Join(dir, filepath.Base(candidate))
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("backup not found: %s (see gymctl backup list)", value)
+}
+
+// restoreBackup puts the archived work directory and progress entry of an
+// exercise back, after snapshotting what is there now.
+func restoreBackup(cmd *cobra.Command, exerciseName, value string) error {
+	path, err := resolveBackupFile(value)
+	if err != nil {
+		return err
+	}
+	meta, err := backup.ReadMetadata(path)
+	if err != nil {
+		return err
+	}
+	if meta.Exercise != "" && meta.Exercise != exerciseName {
+		return fmt.Errorf("backup %s belongs to %s, not %s", filepath.Base(path), meta.Exercise, exerciseName)
+	}
+	// Pruning waits until the restore is done: it may remove the very
+	// archive being restored.
+	if err := snapshotWork(cmd, exerciseName, backup.ReasonRestore); err != nil {
+		return err
+	}
+
+	workDir, err := resolveWorkDir(exerciseName)
+	if err != nil {
+		return err
+	}
+	ColorInfo.Fprintf(cmd.OutOrStdout(), "Restoring from backup: %s\n", path)
+	if err := os.MkdirAll(filepath.Dir(workDir), 0o755); err != nil {
+		return err
+	}
+	if _, err := backup.Restore(path, workDir); err != nil {
+		return err
+	}
+
+	if entry, ok := meta.ProgressEntry(); ok {
+		progressPath, err := resolveProgressFile()
+		if err != nil {
+			return err
+		}
+		err = progress.Update(progressPath, func(progressFile *progress.File) error {
+			progressFile.Exercises[exerciseName] = entry
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		ColorSuccess.Fprintf(cmd.OutOrStdout(), "✓ Progress restored (%s)\n", entry.Status)
+	} else {
+		ColorWarning.Fprintln(cmd.OutOrStdout(), "Backup has no progress entry; progress left unchanged")
+	}
+	ColorSuccess.Fprintln(cmd.OutOrStdout(), "✓ Backup restored successfully")
+	pruneBackups(cmd, exerciseName)
+	return nil
+}
+
+// backupExercise returns the exercise an archive belongs to.
+func backupExercise(value string) (string, error) {
+	path, err := resolveBackupFile(value)
+	if err != nil {
+		return "", err
+	}
+	meta, err := backup.ReadMetadata(path)
+	if err != nil {
+		return "", err
+	}
+	if meta.Exercise == "" {
+		return "", errors.New("backup does not name its exercise; pass it as an argument")
+	}
+	return meta.Exercise, nil
+}
@@ -3,18 +3,25 @@ package cli
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"gymctl/internal/checks"
 	"gymctl/internal/progress"
+	"gymctl/internal/report"
 	"gymctl/internal/scenario"
+	"gymctl/internal/scoring"
 )
 
 type checkOptions struct {
 	verbose   bool
 	noCleanup bool
+	noNext    bool
+	output    string
+	watch     bool
 }
 
 func newCheckCmd() *cobra.Command {
@@ -22,129 +29,360 @@ func newCheckCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "check [exercise-name]",
 		Short: "Check if the current exercise is solved",
-		Args:  cobra.RangeArgs(0, 1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			defer RecoverFromPanic(cmd)
-
-			name := ""
-			if len(args) == 1 {
-				name = args[0]
-			} else {
-				current, err := loadCurrentExercise()
-				if err != nil {
-					return WrapErrorWithHint(
-						fmt.Errorf("no exercise specified and no current exercise set"),
-						"Start an exercise first or specify one",
-						"gymctl start <exercise-name>",
-					)
-				}
-				name = current
-			}
-
-			entries, err := scenario.LoadCatalog(tasksDir)
-			if err != nil {
-				return err
-			}
-			entry, found := scenario.FindByName(entries, name)
-			if !found {
-				return fmt.Errorf("exercise not found: %s", name)
-			}
+		Long: `Check runs the exercise checks and reports which of them pass.
 
-			ctx := cmd.Context()
-			if ctx == nil {
-				ctx = context.Background()
-			}
+With --output json, junit or tap the results are written to stdout in that
+format instead, without prompts, for use in CI pipelines.
 
-			workDir := ""
-			if entry.Exercise.Spec.Environment.Type == "docker" {
-				resolved, err := resolveWorkDir(entry.Exercise.Metadata.Name)
-				if err != nil {
-					return err
-				}
-				workDir = resolved
-			}
-			// Show checking header
-			ColorInfo.Fprintf(cmd.OutOrStdout(), "🔍 Checking: %s\n", entry.Exercise.Metadata.Name)
-			fmt.Fprintln(cmd.OutOrStdout())
-
-			results, allPassed := checks.RunExerciseChecks(ctx, entry.Exercise, workDir)
-
-			// Count passed checks
-			passedCount := 0
-			for _, result := range results {
-				if result.Passed {
-					passedCount++
-				}
-			}
+With --watch the checks re-run whenever the work directory, the
+Kubernetes objects the checks read or the Docker containers change, and the
+results are redrawn in place until everything passes or Ctrl-C is pressed.
 
-			// Show progress bar
-			progressBar := ProgressBar(passedCount, len(results), 20)
-			fmt.Fprintln(cmd.OutOrStdout(), progressBar)
-			fmt.Fprintln(cmd.OutOrStdout())
-
-			// Show individual check results
-			for _, result := range results {
-				checkLine := FormatCheckResult(result.Name, result.Passed, "")
-				if opts.verbose && result.Message != "" {
-					checkLine = FormatCheckResult(result.Name, result.Passed, result.Message)
-				}
-				fmt.Fprintln(cmd.OutOrStdout(), checkLine)
-			}
+Checks marked required: false are optional: the exercise completes once the
+other checks pass, and each failed optional check costs the points it is
+worth. The best score is kept when an exercise is checked again.
 
-			fmt.Fprintln(cmd.OutOrStdout())
-
-			if allPassed {
-				if err := markCompleted(entry.Exercise); err != nil {
-					return err
-				}
-				ColorSuccess.Fprintln(cmd.OutOrStdout(), "🎉 Exercise complete! Well done!")
-
-				// Run cleanup hook if not disabled
-				if !opts.noCleanup {
-					cleanupConfig := &CleanupConfig{
-						AutoClean:       false, // Prompt by default
-						SkipClean:       false,
-						CleanImages:     true,
-						CleanContainers: true,
-						CleanVolumes:    true,
-						Exercise:        entry.Exercise.Metadata.Name,
-					}
-					CleanupHook(cmd, entry.Exercise, cleanupConfig)
-				}
-
-				return nil
-			}
+Exit codes:
+  0  all required checks passed
+  1  one or more required checks failed
+  2  the checks could not be run (unknown exercise, bad flags, setup errors)`,
+		Args: cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer RecoverFromPanic(cmd, &err)
+			cmd.SilenceUsage = true
 
-			ColorWarning.Fprintf(cmd.OutOrStdout(), "⚠ Exercise not complete. %d/%d checks passed.\n", passedCount, len(results))
-			if !opts.verbose {
-				ColorDim.Fprintln(cmd.OutOrStdout(), "Use --verbose flag for detailed error messages.")
-			}
-			return fmt.Errorf("checks failed")
+			return runCheckCommand(cmd, args, opts)
 		},
 	}
 
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Show check details")
 	cmd.Flags().BoolVar(&opts.noCleanup, "no-cleanup", false, "Skip cleanup after successful check")
+	cmd.Flags().BoolVar(&opts.noNext, "no-next", false, "Don't offer to start the next exercise after a successful check")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "text", "Output format: text, json, junit or tap")
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "Re-run the checks whenever the exercise changes")
 
 	return cmd
 }
 
-func markCompleted(exercise *scenario.Exercise) error {
-	path, err := resolveProgressFile()
+func runCheckCommand(cmd *cobra.Command, args []string, opts *checkOptions) error {
+	structured := opts.output != "text"
+	if structured && !slices.Contains(report.Formats, opts.output) {
+		return fmt.Errorf("unsupported output format: %s (use text, %s)", opts.output, strings.Join(report.Formats, ", "))
+	}
+	if structured && opts.watch {
+		return fmt.Errorf("--watch only works with text output")
+	}
+
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		current, err := loadCurrentExercise()
+		if err != nil {
+			return WrapErrorWithHint(
+				fmt.Errorf("no exercise specified and no current exercise set"),
+				"Start an exercise first or specify one",
+				"gymctl start <exercise-name>",
+			)
+		}
+		name = current
+	}
+
+	entries, err := scenario.LoadCatalog(tasksDir)
+	if err != nil {
+		return err
+	}
+	entry, found := scenario.FindByName(entries, name)
+	if !found {
+		return fmt.Errorf("exercise not found: %s", name)
+	}
+	exercise, err := withRecordedVariant(entry.Exercise)
 	if err != nil {
 		return err
 	}
 
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if env, ok := recordedEnvironment(exercise.Metadata.Name); ok {
+		ctx = withExerciseCluster(ctx, env)
+	}
+
+	workDir := ""
+	if exercise.Spec.Environment.UsesDocker() {
+		resolved, err := resolveWorkDir(exercise.Metadata.Name)
+		if err != nil {
+			return err
+		}
+		workDir = resolved
+	}
+
+	if structured {
+		started := time.Now()
+		results, _ := checks.RunExerciseChecks(ctx, exercise, workDir)
+		complete := checks.RequiredPassed(results)
+		if complete {
+			if _, _, err := markCompleted(exercise, checks.Passed(results)); err != nil {
+				return err
+			}
+		}
+		checkReport := report.New(exercise, recordedVariant(exercise.Metadata.Name), results, time.Since(started))
+		if err := report.Write(cmd.OutOrStdout(), opts.output, checkReport); err != nil {
+			return err
+		}
+		if !complete {
+			return &ExitError{Code: ExitCodeChecksFailed, Err: fmt.Errorf("checks failed: %d/%d passed", checkReport.Total-checkReport.Failed, checkReport.Total)}
+		}
+		return nil
+	}
+
+	if opts.watch {
+		return watchChecks(cmd, ctx, exercise, workDir, opts)
+	}
+
+	// Show checking header
+	ColorInfo.Fprintf(cmd.OutOrStdout(), "🔍 Checking: %s\n", exercise.Metadata.Name)
+	fmt.Fprintln(cmd.OutOrStdout())
+
+	results, _ := checks.RunExerciseChecks(ctx, exercise, workDir)
+	for _, line := range formatCheckResults(results, opts.verbose) {
+		fmt.Fprintln(cmd.OutOrStdout(), line)
+	}
+
+	if checks.RequiredPassed(results) {
+		return completeExercise(cmd, exercise, results, opts)
+	}
+
+	passedCount := countPassed(results)
+	ColorWarning.Fprintf(cmd.OutOrStdout(), "⚠ Exercise not complete. %d/%d checks passed.\n", passedCount, len(results))
+	for _, line := range formatHintSuggestions(exercise, results, revealedHints(exercise.Metadata.Name)) {
+		fmt.Fprintln(cmd.OutOrStdout(), line)
+	}
+	if !opts.verbose {
+		ColorDim.Fprintln(cmd.OutOrStdout(), "Use --verbose flag for detailed error messages.")
+	}
+	return &ExitError{Code: ExitCodeChecksFailed, Err: fmt.Errorf("checks failed")}
+}
+
+// formatCheckResults renders the progress bar and one line per check,
+// each block followed by a blank line. A failed check shows its feedback
+// when it has some; the raw failure message is only shown when verbose.
+func formatCheckResults(results []checks.Result, verbose bool) []string {
+	lines := []string{ProgressBar(countPassed(results), len(results), 20), ""}
+	for _, result := range results {
+		checkLine, details := formatResult(result, verbose)
+		if result.Optional {
+			checkLine += ColorDim.Sprint(" (optional)")
+		}
+		if verbose && result.Attempts > 1 {
+			checkLine += ColorDim.Sprintf(" (%d attempts)", result.Attempts)
+		}
+		lines = append(lines, checkLine)
+		if details != "" {
+			lines = append(lines, "    "+details)
+		}
+		if verbose {
+			lines = append(lines, formatBranches(result.Branches, "    ")...)
+		}
+	}
+	return append(lines, "")
+}
+
+// formatResult renders a result's line and, when verbose and feedback
+// took the place of the raw message, a details line with that message.
+func formatResult(result checks.Result, verbose bool) (string, string) {
+	if result.Feedback != "" {
+		details := ""
+		if verbose && result.Message != "" {
+			details = ColorDim.Sprint("details: " + result.Message)
+		}
+		return FormatCheckResult(result.Name, result.Passed, result.Feedback), details
+	}
+	if verbose && result.Message != "" {
+		return FormatCheckResult(result.Name, result.Passed, result.Message), ""
+	}
+	return FormatCheckResult(result.Name, result.Passed, ""), ""
+}
+
+// formatBranches lists the nested results of a composite check, indented
+// under it.
+func formatBranches(branches []checks.Result, indent string) []string {
+	var lines []string
+	for _, branch := range branches {
+		// A composite's message only repeats its branches, shown below it.
+		if branch.Passed || len(branch.Branches) > 0 {
+			branch.Message = ""
+		}
+		line, details := formatResult(branch, true)
+		lines = append(lines, indent+line)
+		if details != "" {
+			lines = append(lines, indent+"    "+details)
+		}
+		lines = append(lines, formatBranches(branch.Branches, indent+"    ")...)
+	}
+	return lines
+}
+
+// formatHintSuggestions points at the hints that failed checks name, with
+// the command revealing each and what it costs.
+func formatHintSuggestions(exercise *scenario.Exercise, results []checks.Result, hintsUsed int) []string {
+	relevant := map[int][]string{}
+	var visit func(results []checks.Result)
+	visit = func(results []checks.Result) {
+		for _, result := range results {
+			if result.Passed {
+				continue
+			}
+			if result.Hint > 0 && result.Hint <= len(exercise.Spec.Hints) {
+				relevant[result.Hint] = append(relevant[result.Hint], fmt.Sprintf("%q", result.Name))
+			}
+			// Branches of a not fail by passing, their hints do not apply.
+			if result.Type != scenario.CheckNot {
+				visit(result.Branches)
+			}
+		}
+	}
+	visit(results)
+
+	indexes := make([]int, 0, len(relevant))
+	for index := range relevant {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+
+	var lines []string
+	for _, index := range indexes {
+		line := ColorInfo.Sprintf("%s Hint %d", IconHint, index) +
+			fmt.Sprintf(" may help with %s: gymctl hint --number %d", strings.Join(relevant[index], ", "), index)
+		if index <= hintsUsed {
+			line += ColorDim.Sprint(" (already revealed)")
+		} else if cost := hintCost(exercise.Spec.Hints, hintsUsed, index); cost > 0 {
+			line += ColorDim.Sprintf(" (-%d pts)", cost)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// hintCost is what revealing the hints after the first revealed ones up to
+// and including the 1-based index costs.
+func hintCost(hints []scenario.Hint, revealed, index int) int {
+	cost := 0
+	for i := revealed; i < index && i < len(hints); i++ {
+		cost += hints[i].Cost
+	}
+	return cost
+}
+
+// revealedHints returns how many of the exercise's hints were revealed.
+func revealedHints(exercise string) int {
+	path, err := resolveProgressFile()
+	if err != nil {
+		return 0
+	}
 	progressFile, err := progress.Load(path)
+	if err != nil {
+		return 0
+	}
+	return progressFile.Exercises[exercise].HintsUsed
+}
+
+func countPassed(results []checks.Result) int {
+	passed := 0
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+	return passed
+}
+
+// completeExercise records a solved exercise and runs the cleanup and
+// next exercise prompts.
+func completeExercise(cmd *cobra.Command, exercise *scenario.Exercise, results []checks.Result, opts *checkOptions) error {
+	breakdown, best, err := markCompleted(exercise, checks.Passed(results))
 	if err != nil {
 		return err
 	}
+	if breakdown.Missed > 0 {
+		ColorSuccess.Fprintln(cmd.OutOrStdout(), "🎉 Exercise complete with partial credit!")
+		ColorDim.Fprintf(cmd.OutOrStdout(), "Pass the optional checks and run 'gymctl check' again for the remaining %d pts.\n", breakdown.Missed)
+	} else {
+		ColorSuccess.Fprintln(cmd.OutOrStdout(), "🎉 Exercise complete! Well done!")
+	}
+	printScoreBreakdown(cmd, breakdown)
+	if best > breakdown.Total {
+		ColorDim.Fprintf(cmd.OutOrStdout(), "Your best score of %d pts is kept.\n", best)
+	}
+
+	// Run cleanup hook if not disabled
+	if !opts.noCleanup {
+		cleanupConfig := &CleanupConfig{
+			AutoClean:       false, // Prompt by default
+			SkipClean:       false,
+			CleanImages:     true,
+			CleanContainers: true,
+			CleanVolumes:    true,
+			Exercise:        exercise.Metadata.Name,
+		}
+		CleanupHook(cmd, exercise, cleanupConfig)
+	}
 
-	entry := progressFile.Exercises[exercise.Metadata.Name]
-	entry.Status = "completed"
-	entry.CompletedAt = time.Now().UTC().Format(time.RFC3339)
-	entry.Score = defaultPoints(exercise.Spec.Points)
-	progressFile.Exercises[exercise.Metadata.Name] = entry
+	if !opts.noNext {
+		return offerNextExercise(cmd, exercise.Metadata.Name)
+	}
+	return nil
+}
 
-	return progress.Save(path, progressFile)
+// markCompleted scores a completed attempt from the outcome of each check
+// and records it unless an earlier attempt scored higher. It returns the
+// attempt's breakdown and the best score recorded.
+func markCompleted(exercise *scenario.Exercise, passed []bool) (progress.ScoreBreakdown, int, error) {
+	path, err := resolveProgressFile()
+	if err != nil {
+		return progress.ScoreBreakdown{}, 0, err
+	}
+
+	var breakdown progress.ScoreBreakdown
+	best := 0
+	err = progress.Update(path, func(progressFile *progress.File) error {
+		now := time.Now().UTC()
+		entry := progressFile.Exercises[exercise.Metadata.Name]
+		entry.EndSession(now)
+		breakdown = scoring.Calculate(exercise, entry, entry.ActiveTime(now), passed)
+
+		entry.Complete(breakdown, now)
+		best = entry.Score
+		progressFile.Exercises[exercise.Metadata.Name] = entry
+		return nil
+	})
+	return breakdown, best, err
+}
+
+func printScoreBreakdown(cmd *cobra.Command, breakdown progress.ScoreBreakdown) {
+	ColorBold.Fprint(cmd.OutOrStdout(), "Score: ")
+	ColorSuccess.Fprintf(cmd.OutOrStdout(), "%d/%d pts", breakdown.Total, breakdown.Base)
+	if penalties := formatPenalties(breakdown); penalties != "" {
+		ColorDim.Fprintf(cmd.OutOrStdout(), " (%s)", penalties)
+	}
+	fmt.Fprintln(cmd.OutOrStdout())
+}
+
+// formatPenalties lists the non-zero deductions of a score breakdown.
+func formatPenalties(breakdown progress.ScoreBreakdown) string {
+	var parts []string
+	if breakdown.Missed > 0 {
+		parts = append(parts, fmt.Sprintf("-%d optional checks", breakdown.Missed))
+	}
+	if breakdown.HintPenalty > 0 {
+		parts = append(parts, fmt.Sprintf("-%d hints", breakdown.HintPenalty))
+	}
+	if breakdown.ResetPenalty > 0 {
+		parts = append(parts, fmt.Sprintf("-%d resets", breakdown.ResetPenalty))
+	}
+	if breakdown.TimePenalty > 0 {
+		parts = append(parts, fmt.Sprintf("-%d overtime", breakdown.TimePenalty))
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gymctl/internal/checks"
+	"gymctl/internal/scenario"
+	"gymctl/internal/watch"
+)
+
+// watchChecks re-runs the checks whenever the exercise changes, redrawing
+// the results in place, and completes the exercise as soon as its required
+// checks pass. Ctrl-C stops watching with the checks still failing.
+func watchChecks(cmd *cobra.Command, ctx context.Context, exercise *scenario.Exercise, workDir string, opts *checkOptions) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// Checks only read the work directory of Docker exercises, but students
+	// edit manifests in it for every kind.
+	watchDir := workDir
+	if watchDir == "" {
+		if resolved, err := resolveWorkDir(exercise.Metadata.Name); err == nil {
+			if _, err := os.Stat(resolved); err == nil {
+				watchDir = resolved
+			}
+		}
+	}
+	triggers := watch.Run(ctx, watch.DefaultQuiet, watch.ForExercise(exercise, watchDir)...)
+
+	out := cmd.OutOrStdout()
+	ColorInfo.Fprintf(out, "👀 Watching: %s\n", exercise.Metadata.Name)
+	ColorDim.Fprintln(out, "Checks re-run when your files, resources or containers change. Press Ctrl-C to stop.")
+	fmt.Fprintln(out)
+
+	block := &liveBlock{out: out, tty: isTerminal(out)}
+	var lines []string
+	reason := ""
+	for {
+		if block.tty {
+			block.Draw(append(lines, ColorDim.Sprint("⏳ Checking...")))
+		} else if reason != "" {
+			ColorDim.Fprintf(out, "Re-checking after: %s\n", reason)
+		}
+
+		results, _ := checks.RunExerciseChecks(ctx, exercise, workDir)
+		if ctx.Err() != nil {
+			break
+		}
+		lines = formatCheckResults(results, opts.verbose)
+		status := fmt.Sprintf("%d/%d checks passed at %s", countPassed(results), len(results), time.Now().Format("15:04:05"))
+		if reason != "" {
+			status += " after " + reason
+		}
+		lines = append(lines, ColorDim.Sprint(status))
+		if !checks.RequiredPassed(results) {
+			lines = append(lines, formatHintSuggestions(exercise, results, revealedHints(exercise.Metadata.Name))...)
+		}
+		block.Draw(lines)
+
+		if checks.RequiredPassed(results) {
+			stop()
+			fmt.Fprintln(out)
+			return completeExercise(cmd, exercise, results, opts)
+		}
+
+		// Building images and running containers during the checks shows
+		// up as changes too; only what changed since counts.
+		if !watch.Drain(triggers, watch.DefaultSettle) {
+			break
+		}
+		reasons, ok := <-triggers
+		if !ok {
+			break
+		}
+		reason = summarizeReasons(reasons)
+	}
+
+	fmt.Fprintln(out)
+	ColorDim.Fprintln(out, "Stopped watching.")
+	return &ExitError{Code: ExitCodeChecksFailed, Err: fmt.Errorf("checks failed")}
+}
+
+// summarizeReasons names the first few changes behind a re-run.
+func summarizeReasons(reasons []string) string {
+	const shown = 3
+	if len(reasons) <= shown {
+		return strings.Join(reasons, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(reasons[:shown], ", "), len(reasons)-shown)
+}
+
+// liveBlock draws a block of lines that each Draw replaces on a terminal.
+// Elsewhere, such as a pipe or CI log, every block is simply appended.
+type liveBlock struct {
+	out    io.Writer
+	tty    bool
+	height int
+}
+
+// Draw replaces the previous block with lines.
+func (b *liveBlock) Draw(lines []string) {
+	if b.tty && b.height > 0 {
+		// Move to the start of the previous block and clear to the end.
+		fmt.Fprintf(b.out, "\x1b[%dA\x1b[J", b.height)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(b.out, line)
+	}
+	b.height = len(lines)
+}
+
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -10,6 +10,7 @@ import (
 
 	"gymctl/internal/environment"
 	"gymctl/internal/scenario"
+	"gymctl/internal/state"
 )
 
 type cleanOptions struct {
@@ -32,39 +33,48 @@ func newCleanCmd() *cobra.Command {
 			}
 
 			if opts.all {
-				for _, entry := range entries {
+				for i := range entries {
+					entry := &entries[i]
 					exercise := entry.Exercise
-					if exercise.Spec.Environment.Type != "docker" || exercise.Spec.Environment.Docker == nil {
+					if !exercise.Spec.Environment.UsesDocker() || exercise.Spec.Environment.Docker == nil {
 						continue
 					}
 					workDir, err := resolveWorkDir(exercise.Metadata.Name)
 					if err != nil {
 						return err
 					}
-					manager := environment.DockerManager{WorkDir: workDir}
+					names, err := exerciseEnvironment(exercise)
+					if err != nil {
+						return err
+					}
+					manager := environment.DockerManager{WorkDir: workDir, Project: names.ComposeProject}
 					_ = manager.Teardown(ctx, entry.Dir, *exercise.Spec.Environment.Docker)
 				}
+
+				// Every cluster gymctl still tracks goes, whichever exercise owns it.
+				if _, gymState, err := loadGymState(); err == nil {
+					for _, cluster := range gymState.Clusters() {
+						manager := environment.KindManager{ClusterName: cluster}
+						_ = manager.Delete(ctx)
+					}
+					_ = updateGymState(func(gymState *state.File) error {
+						gymState.Environments = map[string]state.Environment{}
+						return nil
+					})
+				}
 			} else {
 				current, err := loadCurrentExercise()
 				if err == nil {
 					if entry, found := scenario.FindByName(entries, current); found {
-						exercise := entry.Exercise
-						if exercise.Spec.Environment.Type == "docker" && exercise.Spec.Environment.Docker != nil {
-							workDir, err := resolveWorkDir(exercise.Metadata.Name)
-							if err != nil {
-								return err
-							}
-							manager := environment.DockerManager{WorkDir: workDir}
-							_ = manager.Teardown(ctx, entry.Dir, *exercise.Spec.Environment.Docker)
+						workDir, err := resolveWorkDir(current)
+						if err != nil {
+							return err
 						}
+						_ = teardownEnvironment(ctx, entry, workDir)
 					}
 				}
 			}
 
-			manager := environment.KindManager{ClusterName: "jerry-gym"}
-			_, _ = manager.Exists(ctx)
-			_ = manager.Delete(ctx)
-
 			gymDir, err := resolveGymDir()
 			if err == nil {
 				_ = os.RemoveAll(filepath.Join(gymDir, "workdir"))
@@ -3,12 +3,14 @@ package cli
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/environment"
 	"gymctl/internal/progress"
+	"gymctl/internal/runner"
 	"gymctl/internal/scenario"
 )
 
@@ -25,7 +27,7 @@ type CleanupConfig struct {
 // CleanupHook runs cleanup after successful exercise completion
 func CleanupHook(cmd *cobra.Command, exercise *scenario.Exercise, config *CleanupConfig) error {
 	// Don't cleanup if skipped or not a Docker exercise
-	if config.SkipClean || exercise.Spec.Environment.Type != "docker" {
+	if config.SkipClean || !exercise.Spec.Environment.UsesDocker() {
 		return nil
 	}
 
@@ -138,16 +140,72 @@ func (a *DockerArtifacts) IsEmpty() bool {
 
 // detectDockerArtifacts finds Docker resources created by an exercise
 func detectDockerArtifacts(ctx context.Context, exercise *scenario.Exercise) (*DockerArtifacts, error) {
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		return detectDockerArtifactsAPI(ctx, client, exercise)
+	}
+	return detectDockerArtifactsCLI(ctx, exercise)
+}
+
+// detectDockerArtifactsAPI asks the Engine API, which reports exact sizes.
+func detectDockerArtifactsAPI(ctx context.Context, client *dockerapi.Client, exercise *scenario.Exercise) (*DockerArtifacts, error) {
 	artifacts := &DockerArtifacts{}
+	labelFilter := environment.ExerciseFilters(exercise.Metadata.Name)
+
+	images, err := client.ImageList(ctx, dockerapi.Filters{"label": labelFilter})
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		artifacts.Images = append(artifacts.Images, image.ID)
+		artifacts.ImageCount++
+		artifacts.ImageSize += image.Size
+	}
 
-	// Label used to tag exercise resources
-	label := fmt.Sprintf("gym.exercise=%s", exercise.Metadata.Name)
+	containers, err := client.ContainerList(ctx, true, dockerapi.Filters{"status": {"exited"}, "label": labelFilter})
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		artifacts.Containers = append(artifacts.Containers, container.ID)
+		artifacts.ContainerCount++
+	}
 
-	// Find images with the exercise label
-	cmd := exec.CommandContext(ctx, "docker", "images", "--filter", fmt.Sprintf("label=%s", label), "--format", "{{.ID}}:{{.Size}}")
-	output, err := cmd.Output()
+	volumes, err := client.VolumeList(ctx, dockerapi.Filters{"label": labelFilter})
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes) > 0 {
+		sizes, err := client.VolumeSizes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range volumes {
+			artifacts.Volumes = append(artifacts.Volumes, volume.Name)
+			artifacts.VolumeCount++
+			artifacts.VolumeSize += sizes[volume.Name]
+		}
+	}
+
+	return artifacts, nil
+}
+
+// detectDockerArtifactsCLI is the docker CLI fallback.
+func detectDockerArtifactsCLI(ctx context.Context, exercise *scenario.Exercise) (*DockerArtifacts, error) {
+	artifacts := &DockerArtifacts{}
+
+	// Filters matching the labels gymctl puts on exercise resources
+	var labelFilters []string
+	for _, label := range environment.ExerciseFilters(exercise.Metadata.Name) {
+		labelFilters = append(labelFilters, "--filter", "label="+label)
+	}
+	listArgs := func(args ...string) []string {
+		return append(args, labelFilters...)
+	}
+
+	// Find images with the exercise labels
+	output, err := runner.Output(ctx, "docker", listArgs("images", "--format", "{{.ID}}:{{.Size}}")...)
 	if err == nil && len(output) > 0 {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		lines := strings.Split(output, "\n")
 		for _, line := range lines {
 			if line == "" {
 				continue
@@ -162,32 +220,10 @@ func detectDockerArtifacts(ctx context.Context, exercise *scenario.Exercise) (*D
 		}
 	}
 
-	// Also check for images built in the work directory
-	_, err = resolveWorkDir(exercise.Metadata.Name)
-	if err == nil {
-		// Look for Dockerfiles in work directory
-		cmd = exec.CommandContext(ctx, "docker", "images", "--format", "{{.Repository}}:{{.Tag}}:{{.ID}}:{{.Size}}")
-		output, err = cmd.Output()
-		if err == nil {
-			lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-			for _, line := range lines {
-				if strings.Contains(line, exercise.Metadata.Name) {
-					parts := strings.Split(line, ":")
-					if len(parts) >= 4 && !contains(artifacts.Images, parts[2]) {
-						artifacts.Images = append(artifacts.Images, parts[2])
-						artifacts.ImageCount++
-						artifacts.ImageSize += parseDockerSize(parts[3])
-					}
-				}
-			}
-		}
-	}
-
 	// Find stopped containers
-	cmd = exec.CommandContext(ctx, "docker", "ps", "-a", "--filter", "status=exited", "--filter", fmt.Sprintf("label=%s", label), "--format", "{{.ID}}")
-	output, err = cmd.Output()
+	output, err = runner.Output(ctx, "docker", listArgs("ps", "-a", "--filter", "status=exited", "--format", "{{.ID}}")...)
 	if err == nil && len(output) > 0 {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		lines := strings.Split(output, "\n")
 		for _, line := range lines {
 			if line != "" {
 				artifacts.Containers = append(artifacts.Containers, line)
@@ -197,20 +233,18 @@ func detectDockerArtifacts(ctx context.Context, exercise *scenario.Exercise) (*D
 	}
 
 	// Find volumes
-	cmd = exec.CommandContext(ctx, "docker", "volume", "ls", "--filter", fmt.Sprintf("label=%s", label), "--format", "{{.Name}}")
-	output, err = cmd.Output()
+	output, err = runner.Output(ctx, "docker", listArgs("volume", "ls", "--format", "{{.Name}}")...)
 	if err == nil && len(output) > 0 {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		lines := strings.Split(output, "\n")
 		for _, line := range lines {
 			if line != "" {
 				artifacts.Volumes = append(artifacts.Volumes, line)
 				artifacts.VolumeCount++
 				// Get volume size
-				sizeCmd := exec.CommandContext(ctx, "docker", "volume", "inspect", line, "--format", "{{.UsageData.Size}}")
-				if sizeOutput, err := sizeCmd.Output(); err == nil {
+				if sizeOutput, err := runner.Output(ctx, "docker", "volume", "inspect", line, "--format", "{{.UsageData.Size}}"); err == nil {
 					// This returns size in bytes
 					var size int64
-					fmt.Sscanf(string(sizeOutput), "%d", &size)
+					fmt.Sscanf(sizeOutput, "%d", &size)
 					artifacts.VolumeSize += size
 				}
 			}
@@ -229,14 +263,20 @@ func cleanDockerImages(ctx context.Context, exercise *scenario.Exercise) (int64,
 
 	totalSize := artifacts.ImageSize
 
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		for _, imageID := range artifacts.Images {
+			_ = client.ImageRemove(ctx, imageID, true) // Ignore errors for individual images
+		}
+		reclaimed, _ := client.ImagesPrune(ctx)
+		return totalSize + reclaimed, nil
+	}
+
 	for _, imageID := range artifacts.Images {
-		cmd := exec.CommandContext(ctx, "docker", "rmi", "-f", imageID)
-		cmd.Run() // Ignore errors for individual images
+		_, _ = runner.Run(ctx, "docker", "rmi", "-f", imageID) // Ignore errors for individual images
 	}
 
 	// Also try to clean dangling images
-	cmd := exec.CommandContext(ctx, "docker", "image", "prune", "-f")
-	cmd.Run()
+	_, _ = runner.Run(ctx, "docker", "image", "prune", "-f")
 
 	return totalSize, nil
 }
@@ -248,9 +288,14 @@ func cleanDockerContainers(ctx context.Context, exercise *scenario.Exercise) err
 		return err
 	}
 
+	client, clientErr := dockerapi.ClientFor(ctx)
 	for _, containerID := range artifacts.Containers {
-		cmd := exec.CommandContext(ctx, "docker", "rm", "-f", containerID)
-		cmd.Run() // Ignore errors for individual containers
+		// Ignore errors for individual containers
+		if clientErr == nil {
+			_ = client.ContainerRemove(ctx, containerID)
+		} else {
+			_, _ = runner.Run(ctx, "docker", "rm", "-f", containerID)
+		}
 	}
 
 	return nil
@@ -265,15 +310,21 @@ func cleanDockerVolumes(ctx context.Context, exercise *scenario.Exercise) (int64
 
 	totalSize := artifacts.VolumeSize
 
+	client, clientErr := dockerapi.ClientFor(ctx)
 	for _, volumeName := range artifacts.Volumes {
-		cmd := exec.CommandContext(ctx, "docker", "volume", "rm", "-f", volumeName)
-		cmd.Run() // Ignore errors for individual volumes
+		// Ignore errors for individual volumes
+		if clientErr == nil {
+			_ = client.VolumeRemove(ctx, volumeName)
+		} else {
+			_, _ = runner.Run(ctx, "docker", "volume", "rm", "-f", volumeName)
+		}
 	}
 
 	return totalSize, nil
 }
 
-// parseDockerSize parses Docker's human-readable size format
+// parseDockerSize parses Docker's human-readable size format, as printed by
+// the CLI fallback
 func parseDockerSize(sizeStr string) int64 {
 	sizeStr = strings.TrimSpace(sizeStr)
 	if sizeStr == "" {
@@ -384,14 +435,13 @@ func systemCleanup(cmd *cobra.Command, ctx context.Context, force bool) error {
 	// Get current disk usage
 	spinner.Start("Analyzing Docker disk usage")
 
-	dfCmd := exec.CommandContext(ctx, "docker", "system", "df")
-	dfOutput, _ := dfCmd.Output()
+	dfOutput, _ := runner.Output(ctx, "docker", "system", "df")
 
 	spinner.Stop()
 
 	if len(dfOutput) > 0 {
 		ColorDim.Fprintln(cmd.OutOrStdout(), "Current Docker disk usage:")
-		fmt.Fprintln(cmd.OutOrStdout(), string(dfOutput))
+		fmt.Fprintln(cmd.OutOrStdout(), dfOutput)
 	}
 
 	if !force && !confirmAction(cmd, "Proceed with system-wide cleanup?") {
@@ -401,8 +451,7 @@ func systemCleanup(cmd *cobra.Command, ctx context.Context, force bool) error {
 	// Run Docker system prune
 	spinner.Start("Running Docker system cleanup")
 
-	pruneCmd := exec.CommandContext(ctx, "docker", "system", "prune", "-a", "-f", "--volumes")
-	pruneOutput, err := pruneCmd.Output()
+	pruneOutput, err := runner.Output(ctx, "docker", "system", "prune", "-a", "-f", "--volumes")
 
 	spinner.Stop()
 
@@ -413,7 +462,7 @@ func systemCleanup(cmd *cobra.Command, ctx context.Context, force bool) error {
 
 	ColorSuccess.Fprintln(cmd.OutOrStdout(), "✓ System cleanup complete")
 	if len(pruneOutput) > 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), string(pruneOutput))
+		fmt.Fprintln(cmd.OutOrStdout(), pruneOutput)
 	}
 
 	return nil
@@ -436,7 +485,7 @@ func interactiveCleanup(cmd *cobra.Command, ctx context.Context) error {
 	hasArtifacts := false
 	for _, entry := range entries {
 		exercise := entry.Exercise
-		if exercise.Spec.Environment.Type != "docker" {
+		if !exercise.Spec.Environment.UsesDocker() {
 			continue
 		}
 
@@ -38,6 +38,7 @@ var (
 	IconWarning = "⚠"
 	IconInfo    = "ℹ"
 	IconHint    = "💡"
+	IconLocked  = "🔒"
 )
 
 // DifficultyColor returns appropriate color for difficulty level
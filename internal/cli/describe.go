@@ -37,7 +37,7 @@ func newDescribeCmd() *cobra.Command {
 			if exercise.Spec.EstimatedTime != "" {
 				fmt.Fprintf(out, "Estimated time: %s\n", exercise.Spec.EstimatedTime)
 			}
-			fmt.Fprintf(out, "Points: %d\n", defaultPoints(exercise.Spec.Points))
+			fmt.Fprintf(out, "Points: %d\n", exercisePoints(exercise.Spec))
 			fmt.Fprintln(out, "")
 
 			if exercise.Spec.Description != "" {
@@ -65,13 +65,31 @@ func newDescribeCmd() *cobra.Command {
 
 			if len(exercise.Spec.Checks) > 0 {
 				fmt.Fprintln(out, "Checks:")
-				for _, check := range exercise.Spec.Checks {
+				points, _, _ := exercise.Spec.CheckPoints()
+				weighted := exercise.Spec.WeighsChecks() && points != nil
+				for i, check := range exercise.Spec.Checks {
 					label := check.Name
 					if label == "" {
 						label = check.Type
 					}
-					fmt.Fprintf(out, "- %s (%s)\n", label, check.Type)
+					line := fmt.Sprintf("- %s (%s", label, check.Type)
+					if weighted {
+						line += fmt.Sprintf(", %d pts", points[i])
+					}
+					if !check.IsRequired() {
+						line += ", optional"
+					}
+					fmt.Fprintln(out, line+")")
+				}
+				fmt.Fprintln(out, "")
+			}
+
+			if len(exercise.Spec.Variants) > 0 {
+				policy := exercise.Spec.VariantSelection
+				if policy == "" {
+					policy = scenario.VariantSelectionRandom
 				}
+				fmt.Fprintf(out, "Variants (%s): %s\n", policy, strings.Join(exercise.Spec.VariantNames(), ", "))
 				fmt.Fprintln(out, "")
 			}
 
@@ -4,14 +4,15 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"gymctl/internal/kube"
 	"gymctl/internal/progress"
+	"gymctl/internal/runner"
 	"gymctl/internal/scenario"
 )
 
@@ -147,12 +148,11 @@ func getSystemChecks() []diagnosticCheck {
 			Category: "Docker",
 			Required: true,
 			Check: func(ctx context.Context) (bool, string, string) {
-				cmd := exec.CommandContext(ctx, "docker", "version", "--format", "{{.Server.Version}}")
-				output, err := cmd.Output()
+				output, err := runner.Output(ctx, "docker", "version", "--format", "{{.Server.Version}}")
 				if err != nil {
 					return false, "Docker is not installed or not running", "Install Docker from https://docs.docker.com/get-docker/"
 				}
-				version := strings.TrimSpace(string(output))
+				version := output
 				return true, fmt.Sprintf("Version %s", version), ""
 			},
 		},
@@ -161,8 +161,7 @@ func getSystemChecks() []diagnosticCheck {
 			Category: "Docker",
 			Required: true,
 			Check: func(ctx context.Context) (bool, string, string) {
-				cmd := exec.CommandContext(ctx, "docker", "info")
-				if err := cmd.Run(); err != nil {
+				if _, err := runner.Run(ctx, "docker", "info"); err != nil {
 					if runtime.GOOS == "linux" {
 						return false, "Docker daemon is not running", "sudo systemctl start docker"
 					} else if runtime.GOOS == "darwin" {
@@ -178,8 +177,7 @@ func getSystemChecks() []diagnosticCheck {
 			Category: "Docker",
 			Required: true,
 			Check: func(ctx context.Context) (bool, string, string) {
-				cmd := exec.CommandContext(ctx, "docker", "ps")
-				if err := cmd.Run(); err != nil {
+				if _, err := runner.Run(ctx, "docker", "ps"); err != nil {
 					if strings.Contains(err.Error(), "permission denied") {
 						return false, "User doesn't have Docker permissions", "Add user to docker group: sudo usermod -aG docker $USER"
 					}
@@ -194,14 +192,12 @@ func getSystemChecks() []diagnosticCheck {
 			Required: false,
 			Check: func(ctx context.Context) (bool, string, string) {
 				// Try docker compose (v2)
-				cmd := exec.CommandContext(ctx, "docker", "compose", "version")
-				if err := cmd.Run(); err == nil {
+				if _, err := runner.Run(ctx, "docker", "compose", "version"); err == nil {
 					return true, "Docker Compose v2 installed", ""
 				}
 
 				// Try docker-compose (v1)
-				cmd = exec.CommandContext(ctx, "docker-compose", "--version")
-				if err := cmd.Run(); err == nil {
+				if _, err := runner.Run(ctx, "docker-compose", "--version"); err == nil {
 					return true, "Docker Compose v1 installed", ""
 				}
 
@@ -215,12 +211,11 @@ func getSystemChecks() []diagnosticCheck {
 			Category: "Kubernetes",
 			Required: false,
 			Check: func(ctx context.Context) (bool, string, string) {
-				cmd := exec.CommandContext(ctx, "kubectl", "version", "--client", "--short")
-				output, err := cmd.Output()
+				output, err := runner.Output(ctx, "kubectl", "version", "--client", "--short")
 				if err != nil {
 					return false, "kubectl is not installed", "Install from https://kubernetes.io/docs/tasks/tools/"
 				}
-				version := strings.TrimSpace(string(output))
+				version := output
 				return true, version, ""
 			},
 		},
@@ -229,12 +224,11 @@ func getSystemChecks() []diagnosticCheck {
 			Category: "Kubernetes",
 			Required: false,
 			Check: func(ctx context.Context) (bool, string, string) {
-				cmd := exec.CommandContext(ctx, "kind", "version")
-				output, err := cmd.Output()
+				output, err := runner.Output(ctx, "kind", "version")
 				if err != nil {
 					return false, "kind is not installed", "Install from https://kind.sigs.k8s.io/docs/user/quick-start/#installation"
 				}
-				version := strings.TrimSpace(string(output))
+				version := output
 				return true, version, ""
 			},
 		},
@@ -282,7 +276,7 @@ func getSystemChecks() []diagnosticCheck {
 				// Try to load it
 				_, err = progress.Load(progressPath)
 				if err != nil {
-					return false, fmt.Sprintf("Cannot load progress file: %v", err), "Try: gymctl reset --all"
+					return false, fmt.Sprintf("Cannot load progress file: %v", err), "Try: gymctl recover (restores the last known good copy)"
 				}
 
 				return true, fmt.Sprintf("Progress file: %s", progressPath), ""
@@ -298,8 +292,7 @@ func getSystemChecks() []diagnosticCheck {
 				ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 				defer cancel()
 
-				cmd := exec.CommandContext(ctx, "docker", "pull", "alpine:latest")
-				if err := cmd.Run(); err != nil {
+				if _, err := runner.Run(ctx, "docker", "pull", "alpine:latest"); err != nil {
 					return false, "Cannot pull images from Docker Hub", "Check internet connection and proxy settings"
 				}
 				return true, "Docker Hub is accessible", ""
@@ -328,8 +321,7 @@ func diagnoseExercise(cmd *cobra.Command, ctx context.Context, exerciseName stri
 	// Environment type
 	fmt.Fprintf(cmd.OutOrStdout(), "  Environment: %s\n", exercise.Spec.Environment.Type)
 
-	switch exercise.Spec.Environment.Type {
-	case "docker":
+	if exercise.Spec.Environment.UsesDocker() {
 		if exercise.Spec.Environment.Docker != nil {
 			docker := exercise.Spec.Environment.Docker
 
@@ -359,19 +351,25 @@ func diagnoseExercise(cmd *cobra.Command, ctx context.Context, exerciseName stri
 				composeFile := fmt.Sprintf("%s/%s", entry.Dir, docker.ComposeFile)
 				if _, err := os.Stat(composeFile); err == nil {
 					ColorSuccess.Fprintf(cmd.OutOrStdout(), "  %s Compose file exists: %s\n", IconSuccess, docker.ComposeFile)
+					if names, err := exerciseEnvironment(exercise); err == nil {
+						fmt.Fprintf(cmd.OutOrStdout(), "  Compose project: %s\n", names.ComposeProject)
+					}
 				} else {
 					ColorError.Fprintf(cmd.OutOrStdout(), "  %s Compose file not found: %s\n", IconFail, docker.ComposeFile)
 				}
 			}
 		}
+	}
 
-	case "kubernetes":
+	if exercise.Spec.Environment.UsesKubernetes() {
 		if exercise.Spec.Environment.Kubernetes != nil {
 			k8s := exercise.Spec.Environment.Kubernetes
 
-			// Check if cluster should exist
-			if k8s.CreateCluster != nil && *k8s.CreateCluster {
-				checkKindCluster(cmd, ctx, "jerry-gym", verbose)
+			// Check the cluster this exercise runs in
+			if names, err := exerciseEnvironment(exercise); err == nil && names.Cluster != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "  Cluster: %s\n", names.Cluster)
+				checkKindCluster(cmd, ctx, names.Cluster, verbose)
+				ctx = withExerciseCluster(ctx, names)
 			}
 
 			// Check namespace
@@ -403,14 +401,13 @@ func diagnoseExercise(cmd *cobra.Command, ctx context.Context, exerciseName stri
 }
 
 func checkContainer(cmd *cobra.Command, ctx context.Context, name string, verbose bool) {
-	execCmd := exec.CommandContext(ctx, "docker", "ps", "-a", "--filter", fmt.Sprintf("name=%s", name), "--format", "{{.Status}}")
-	output, err := execCmd.Output()
-	if err != nil || strings.TrimSpace(string(output)) == "" {
+	output, err := runner.Output(ctx, "docker", "ps", "-a", "--filter", fmt.Sprintf("name=%s", name), "--format", "{{.Status}}")
+	if err != nil || output == "" {
 		ColorWarning.Fprintf(cmd.OutOrStdout(), "    %s Container '%s' not found\n", IconWarning, name)
 		return
 	}
 
-	status := strings.TrimSpace(string(output))
+	status := output
 	if strings.HasPrefix(status, "Up") {
 		ColorSuccess.Fprintf(cmd.OutOrStdout(), "    %s Container '%s' is running\n", IconSuccess, name)
 	} else {
@@ -419,9 +416,8 @@ func checkContainer(cmd *cobra.Command, ctx context.Context, name string, verbos
 }
 
 func checkNetwork(cmd *cobra.Command, ctx context.Context, name string, verbose bool) {
-	execCmd := exec.CommandContext(ctx, "docker", "network", "ls", "--filter", fmt.Sprintf("name=%s", name), "--format", "{{.Name}}")
-	output, err := execCmd.Output()
-	if err != nil || strings.TrimSpace(string(output)) == "" {
+	output, err := runner.Output(ctx, "docker", "network", "ls", "--filter", fmt.Sprintf("name=%s", name), "--format", "{{.Name}}")
+	if err != nil || output == "" {
 		ColorWarning.Fprintf(cmd.OutOrStdout(), "    %s Network '%s' not found\n", IconWarning, name)
 		return
 	}
@@ -429,14 +425,13 @@ func checkNetwork(cmd *cobra.Command, ctx context.Context, name string, verbose
 }
 
 func checkKindCluster(cmd *cobra.Command, ctx context.Context, name string, verbose bool) {
-	execCmd := exec.CommandContext(ctx, "kind", "get", "clusters")
-	output, err := execCmd.Output()
+	output, err := runner.Output(ctx, "kind", "get", "clusters")
 	if err != nil {
 		ColorError.Fprintf(cmd.OutOrStdout(), "    %s Cannot check kind clusters\n", IconFail)
 		return
 	}
 
-	clusters := strings.Split(strings.TrimSpace(string(output)), "\n")
+	clusters := strings.Split(output, "\n")
 	for _, cluster := range clusters {
 		if cluster == name {
 			ColorSuccess.Fprintf(cmd.OutOrStdout(), "    %s Kind cluster '%s' exists\n", IconSuccess, name)
@@ -447,8 +442,7 @@ func checkKindCluster(cmd *cobra.Command, ctx context.Context, name string, verb
 }
 
 func checkNamespace(cmd *cobra.Command, ctx context.Context, name string, verbose bool) {
-	execCmd := exec.CommandContext(ctx, "kubectl", "get", "namespace", name)
-	if err := execCmd.Run(); err != nil {
+	if _, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, "get", "namespace", name)...); err != nil {
 		ColorWarning.Fprintf(cmd.OutOrStdout(), "    %s Namespace '%s' not found\n", IconWarning, name)
 		return
 	}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"gymctl/internal/config"
+	"gymctl/internal/environment"
+	"gymctl/internal/kube"
+	"gymctl/internal/scenario"
+	"gymctl/internal/state"
+)
+
+func resolveConfigFile() (string, error) {
+	gymDir, err := resolveGymDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gymDir, "config.yaml"), nil
+}
+
+func resolveStateFile() (string, error) {
+	gymDir, err := resolveGymDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gymDir, "state.yaml"), nil
+}
+
+func loadGymConfig() (*config.Config, error) {
+	path, err := resolveConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(path)
+}
+
+func loadGymState() (string, *state.File, error) {
+	path, err := resolveStateFile()
+	if err != nil {
+		return "", nil, err
+	}
+	file, err := state.Load(path)
+	return path, file, err
+}
+
+// ownsCluster reports whether the exercise runs in a kind cluster gymctl
+// creates for it.
+func ownsCluster(exercise *scenario.Exercise) bool {
+	env := exercise.Spec.Environment
+	return env.UsesKubernetes() && env.Kubernetes != nil &&
+		(env.Kubernetes.CreateCluster == nil || *env.Kubernetes.CreateCluster)
+}
+
+// recordedEnvironment returns the environment recorded when the exercise
+// was started.
+func recordedEnvironment(exercise string) (state.Environment, bool) {
+	_, file, err := loadGymState()
+	if err != nil {
+		return state.Environment{}, false
+	}
+	env, ok := file.Environments[exercise]
+	return env, ok
+}
+
+// exerciseEnvironment returns the cluster and compose project of an
+// exercise: the recorded ones while it is running, otherwise the names the
+// configuration gives it.
+func exerciseEnvironment(exercise *scenario.Exercise) (state.Environment, error) {
+	if recorded, ok := recordedEnvironment(exercise.Metadata.Name); ok {
+		return recorded, nil
+	}
+	return plannedEnvironment(exercise, false)
+}
+
+// plannedEnvironment derives the environment names from the configuration.
+func plannedEnvironment(exercise *scenario.Exercise, noCluster bool) (state.Environment, error) {
+	cfg, err := loadGymConfig()
+	if err != nil {
+		return state.Environment{}, err
+	}
+	return namedEnvironment(cfg, exercise, noCluster)
+}
+
+// harnessEnvironment derives the names gymctl test provisions under, from
+// the "<prefix>-test" prefix, so the harness never shares a cluster or
+// compose project with a student's run of the exercise.
+func harnessEnvironment(exercise *scenario.Exercise, noCluster bool) (state.Environment, error) {
+	cfg, err := loadGymConfig()
+	if err != nil {
+		return state.Environment{}, err
+	}
+	cfg.Prefix += "-test"
+	return namedEnvironment(cfg, exercise, noCluster)
+}
+
+func namedEnvironment(cfg *config.Config, exercise *scenario.Exercise, noCluster bool) (state.Environment, error) {
+	name := exercise.Metadata.Name
+	env := state.Environment{ComposeProject: cfg.ComposeProject(name)}
+	if ownsCluster(exercise) && !noCluster {
+		hash, err := environment.KindConfigHash(exercise.Spec.Environment.Kubernetes.KindConfig)
+		if err != nil {
+			return state.Environment{}, err
+		}
+		env.Cluster = cfg.ClusterName(name)
+		env.KubeContext = environment.KindContext(env.Cluster)
+		env.KindConfigHash = hash
+	}
+	return env, nil
+}
+
+// canReuseCluster reports whether the running cluster of env may be kept:
+// reuse is enabled and the cluster was recorded with the same kind
+// configuration. A cluster gymctl has no record of is never reused.
+func canReuseCluster(env state.Environment) bool {
+	cfg, err := loadGymConfig()
+	if err != nil || !cfg.ReusesClusters() {
+		return false
+	}
+	_, file, err := loadGymState()
+	if err != nil {
+		return false
+	}
+	hash, ok := file.ClusterConfigHash(env.Cluster)
+	return ok && hash == env.KindConfigHash
+}
+
+// environmentLabels returns the labels for resources provisioned for the
+// exercise in env.
+func environmentLabels(exercise *scenario.Exercise, env state.Environment) environment.Labels {
+	return environment.Labels{
+		Exercise: exercise.Metadata.Name,
+		Variant:  env.Variant,
+		Session:  env.Session,
+	}
+}
+
+// harnessLabels returns the labels for resources gymctl test provisions.
+// They name the exercise differently, so cleaning up after the harness
+// leaves a student's resources alone.
+func harnessLabels(exercise *scenario.Exercise, env state.Environment) environment.Labels {
+	labels := environmentLabels(exercise, env)
+	labels.Exercise = "test-" + labels.Exercise
+	return labels
+}
+
+// exerciseNamespace returns the namespace the exercise's setup goes to.
+func exerciseNamespace(spec *scenario.KubernetesSpec) string {
+	if spec.Namespace == "" {
+		return "default"
+	}
+	return spec.Namespace
+}
+
+// updateGymState changes the state file under its lock.
+func updateGymState(change func(*state.File) error) error {
+	path, err := resolveStateFile()
+	if err != nil {
+		return err
+	}
+	return state.Update(path, change)
+}
+
+func recordEnvironment(exercise string, env state.Environment) error {
+	return updateGymState(func(file *state.File) error {
+		env.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+		file.Environments[exercise] = env
+		return nil
+	})
+}
+
+// errNotRecorded stops a state update that has nothing to change.
+var errNotRecorded = errors.New("environment not recorded")
+
+// forgetEnvironment drops the exercise from the state and reports whether
+// another exercise still uses its cluster.
+func forgetEnvironment(exercise string) (bool, error) {
+	shared := false
+	err := updateGymState(func(file *state.File) error {
+		env, ok := file.Environments[exercise]
+		if !ok {
+			return errNotRecorded
+		}
+		delete(file.Environments, exercise)
+		shared = env.Cluster != "" && len(file.ClusterUsers(env.Cluster, exercise)) > 0
+		return nil
+	})
+	if errors.Is(err, errNotRecorded) {
+		return false, nil
+	}
+	return shared, err
+}
+
+// withExerciseCluster points Kubernetes calls at the exercise's own
+// cluster rather than whatever kubeconfig context is current.
+func withExerciseCluster(ctx context.Context, env state.Environment) context.Context {
+	if env.KubeContext == "" {
+		return ctx
+	}
+	return kube.WithContextName(ctx, env.KubeContext)
+}
+
+// teardownEnvironment removes the exercise's compose project, containers
+// and anything else labelled as its own and, unless another exercise still
+// uses it, its kind cluster. In a cluster that stays, the exercise's
+// labelled objects are deleted instead. It returns the first error and
+// keeps going.
+func teardownEnvironment(ctx context.Context, entry *scenario.CatalogEntry, workDir string) error {
+	exercise := entry.Exercise
+	env, err := exerciseEnvironment(exercise)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+
+	spec := exercise.Spec.Environment
+	if spec.UsesDocker() && spec.Docker != nil {
+		manager := environment.DockerManager{WorkDir: workDir, Project: env.ComposeProject}
+		if err := manager.Teardown(ctx, entry.Dir, *spec.Docker); err != nil {
+			firstErr = err
+		}
+		if err := environment.RemoveLabeled(ctx, exercise.Metadata.Name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	shared, err := forgetEnvironment(exercise.Metadata.Name)
+	if err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if env.Cluster != "" && !shared {
+		manager := environment.KindManager{ClusterName: env.Cluster}
+		if err := manager.Delete(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	} else if err := deleteLabeledObjects(ctx, entry, env); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// deleteLabeledObjects deletes the exercise's labelled Kubernetes objects
+// from a cluster that outlives it: a shared kind cluster or the user's own.
+func deleteLabeledObjects(ctx context.Context, entry *scenario.CatalogEntry, env state.Environment) error {
+	spec := entry.Exercise.Spec.Environment
+	if !spec.UsesKubernetes() || spec.Kubernetes == nil {
+		return nil
+	}
+	ctx = withExerciseCluster(ctx, env)
+	manifests := environment.ResolveManifestPaths(entry.Dir, spec.Kubernetes.SetupManifests)
+	return environment.DeleteLabeled(ctx, entry.Exercise.Metadata.Name, manifests)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -23,6 +24,45 @@ func (e *ErrorWithHint) Unwrap() error {
 	return e.Err
 }
 
+// Exit codes returned by gymctl. Scripts and CI pipelines gate on them, so
+// they must stay stable. Only the commands in gatedCommands tell the two
+// apart; every other command fails with 1.
+const (
+	ExitCodeChecksFailed = 1
+	ExitCodeError        = 2
+)
+
+// gatedCommands are the commands CI runs to verify solutions. Any failure
+// other than failed checks exits with ExitCodeError, so it cannot pass for
+// failed checks.
+var gatedCommands = map[string]bool{"check": true, "test": true}
+
+// exitCode returns the process exit code for err, returned by cmd.
+func exitCode(cmd *cobra.Command, err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	if cmd != nil && gatedCommands[cmd.Name()] {
+		return ExitCodeError
+	}
+	return 1
+}
+
+// ExitError carries the process exit code for an error.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
 // WrapErrorWithHint creates an error with a helpful hint
 func WrapErrorWithHint(err error, hint string, command ...string) error {
 	cmd := ""
@@ -185,9 +225,12 @@ func HandleCommandError(cmd *cobra.Command, err error) error {
 	return err
 }
 
-// RecoverFromPanic recovers from panic and provides helpful error message
-func RecoverFromPanic(cmd *cobra.Command) {
+// RecoverFromPanic recovers from panic and provides helpful error message.
+// The command then fails through err, which must be the named result of
+// the deferring function.
+func RecoverFromPanic(cmd *cobra.Command, err *error) {
 	if r := recover(); r != nil {
+		*err = fmt.Errorf("unexpected error: %v", r)
 		ColorError.Fprintf(cmd.ErrOrStderr(), "\n🚨 Unexpected error occurred: %v\n", r)
 		ColorInfo.Fprintln(cmd.ErrOrStderr(), "This might be a bug. Please report it with the following:")
 		ColorDim.Fprintln(cmd.ErrOrStderr(), "1. Run 'gymctl diagnose > diagnostic.log'")
@@ -5,6 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"gymctl/internal/scenario"
+	"gymctl/internal/scoring"
 )
 
 func resolveProgressFile() (string, error) {
@@ -82,9 +85,10 @@ func firstLine(text string) string {
 	return text
 }
 
-func defaultPoints(points int) int {
-	if points == 0 {
-		return 100
+// exercisePoints returns what the exercise is worth.
+func exercisePoints(spec scenario.ExerciseSpec) int {
+	if _, total, err := spec.CheckPoints(); err == nil {
+		return total
 	}
-	return points
+	return scoring.BasePoints(spec.Points)
 }
@@ -14,6 +14,7 @@ import (
 
 type hintOptions struct {
 	revealAll bool
+	number    int
 }
 
 func newHintCmd() *cobra.Command {
@@ -21,8 +22,17 @@ func newHintCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "hint [exercise-name]",
 		Short: "Show the next hint",
-		Args:  cobra.RangeArgs(0, 1),
+		Long: `Hint reveals the exercise's hints one at a time, in order.
+
+With --number N it shows hint N: for free when it was already revealed,
+otherwise by revealing the hints up to it, each costing what it declares.
+Failed checks name the hints that help with them.`,
+		Args: cobra.RangeArgs(0, 1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.revealAll && opts.number != 0 {
+				return fmt.Errorf("--reveal-all and --number cannot be used together")
+			}
+
 			name := ""
 			if len(args) == 1 {
 				name = args[0]
@@ -47,44 +57,79 @@ func newHintCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			progressFile, err := progress.Load(progressPath)
+			hints := entry.Exercise.Spec.Hints
+			if opts.number < 0 || opts.number > len(hints) {
+				return fmt.Errorf("exercise %s has %d hints, there is no hint %d", entry.Exercise.Metadata.Name, len(hints), opts.number)
+			}
+			// Every hint is loaded up front, so a broken hint file cannot
+			// leave hints shown but not counted.
+			contents := make([]string, len(hints))
+			for i, hint := range hints {
+				if contents[i], err = loadHintContent(entry.Dir, hint); err != nil {
+					return fmt.Errorf("hint %d: %w", i+1, err)
+				}
+			}
+
+			// Reveal and count under the progress lock, so two terminals
+			// asking at once get consecutive hints. They are printed once
+			// the count is saved.
+			var first, last int
+			revealing := false
+			err = progress.Update(progressPath, func(progressFile *progress.File) error {
+				status := progressFile.Exercises[entry.Exercise.Metadata.Name]
+				startIndex := status.HintsUsed
+				if opts.number != 0 && opts.number <= startIndex {
+					// Already paid for, so show it again for free.
+					first, last = opts.number-1, opts.number
+					return nil
+				}
+				if startIndex >= len(hints) {
+					return nil
+				}
+
+				endIndex := startIndex + 1
+				if opts.revealAll {
+					endIndex = len(hints)
+				} else if opts.number != 0 {
+					endIndex = opts.number
+				}
+				first, last, revealing = startIndex, endIndex, true
+
+				status.HintsUsed = endIndex
+				progressFile.Exercises[entry.Exercise.Metadata.Name] = status
+				return nil
+			})
 			if err != nil {
 				return err
 			}
 
-			status := progressFile.Exercises[entry.Exercise.Metadata.Name]
-			startIndex := status.HintsUsed
-			if startIndex >= len(entry.Exercise.Spec.Hints) {
+			if first == last {
 				ColorWarning.Fprintln(cmd.OutOrStdout(), "No more hints available.")
 				return nil
 			}
-
-			endIndex := startIndex + 1
-			if opts.revealAll {
-				endIndex = len(entry.Exercise.Spec.Hints)
+			for i := first; i < last; i++ {
+				printHint(cmd, i, hints[i], contents[i], revealing)
 			}
-
-			for i := startIndex; i < endIndex; i++ {
-				hint := entry.Exercise.Spec.Hints[i]
-				content, err := loadHintContent(entry.Dir, hint)
-				if err != nil {
-					return err
-				}
-				ColorInfo.Fprintf(cmd.OutOrStdout(), "%s Hint %d: ", IconHint, i+1)
-				fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(content))
-				fmt.Fprintln(cmd.OutOrStdout(), "")
-			}
-
-			status.HintsUsed = endIndex
-			progressFile.Exercises[entry.Exercise.Metadata.Name] = status
-			return progress.Save(progressPath, progressFile)
+			return nil
 		},
 	}
 
 	cmd.Flags().BoolVar(&opts.revealAll, "reveal-all", false, "Show all remaining hints")
+	cmd.Flags().IntVarP(&opts.number, "number", "n", 0, "Show hint N, revealing the hints before it")
 	return cmd
 }
 
+// printHint prints the hint at index i, with its cost when it is being
+// revealed.
+func printHint(cmd *cobra.Command, i int, hint scenario.Hint, content string, revealing bool) {
+	ColorInfo.Fprintf(cmd.OutOrStdout(), "%s Hint %d: ", IconHint, i+1)
+	if revealing && hint.Cost > 0 {
+		ColorDim.Fprintf(cmd.OutOrStdout(), "(-%d pts) ", hint.Cost)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(content))
+	fmt.Fprintln(cmd.OutOrStdout(), "")
+}
+
 func loadHintContent(baseDir string, hint scenario.Hint) (string, error) {
 	if hint.Content != "" {
 		return hint.Content, nil
@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -44,20 +43,7 @@ func newListCmd() *cobra.Command {
 				return nil
 			}
 
-			sort.Slice(filtered, func(i, j int) bool {
-				ai := filtered[i].Exercise
-				aj := filtered[j].Exercise
-				if ai.Metadata.Track != aj.Metadata.Track {
-					return ai.Metadata.Track < aj.Metadata.Track
-				}
-				if ai.Metadata.Week != aj.Metadata.Week {
-					return ai.Metadata.Week < aj.Metadata.Week
-				}
-				if ai.Metadata.Order != aj.Metadata.Order {
-					return ai.Metadata.Order < aj.Metadata.Order
-				}
-				return ai.Metadata.Name < aj.Metadata.Name
-			})
+			scenario.SortCatalog(filtered)
 
 			// Count completed exercises
 			completedCount := 0
@@ -84,6 +70,12 @@ func newListCmd() *cobra.Command {
 				statusIcon := FormatStatus(status.Status)
 
 				desc := firstLine(exercise.Spec.Description)
+				if status.Status != "completed" && status.Status != "in_progress" {
+					if unmet := unmetPrerequisites(exercise, progressFile); len(unmet) > 0 {
+						statusIcon = ColorDim.Sprint(IconLocked)
+						desc = fmt.Sprintf("requires %s", strings.Join(unmet, ", "))
+					}
+				}
 				estimated := exercise.Spec.EstimatedTime
 				if estimated == "" {
 					estimated = "-"
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"gymctl/internal/progress"
+	"gymctl/internal/scenario"
+)
+
+type nextOptions struct {
+	start bool
+}
+
+func newNextCmd() *cobra.Command {
+	opts := &nextOptions{}
+	cmd := &cobra.Command{
+		Use:   "next",
+		Short: "Show and start the next exercise",
+		Long: `Next resolves the exercise to work on after the current one. The current
+exercise's nextExercise is preferred; otherwise the catalog is followed in
+track, week and order sequence, skipping completed and locked exercises.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer RecoverFromPanic(cmd, &err)
+
+			current, _ := loadCurrentExercise()
+			next, err := resolveNextExercise(current)
+			if err != nil {
+				return err
+			}
+			if next == nil {
+				ColorSuccess.Fprintln(cmd.OutOrStdout(), "🎉 Nothing left to do - every available exercise is completed!")
+				return nil
+			}
+
+			printNextExercise(cmd, next.Exercise)
+			if !opts.start && !confirmAction(cmd, "Start it now?") {
+				ColorDim.Fprintf(cmd.OutOrStdout(), "Run 'gymctl start %s' when you are ready.\n", next.Exercise.Metadata.Name)
+				return nil
+			}
+			return switchExercise(cmd, current, next.Exercise.Metadata.Name)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.start, "start", false, "Start the next exercise without prompting")
+
+	return cmd
+}
+
+// resolveNextExercise loads the catalog and progress and returns the exercise
+// to continue with after current, or nil when everything is completed.
+func resolveNextExercise(current string) (*scenario.CatalogEntry, error) {
+	entries, err := scenario.LoadCatalog(tasksDir)
+	if err != nil {
+		return nil, err
+	}
+	path, err := resolveProgressFile()
+	if err != nil {
+		return nil, err
+	}
+	progressFile, err := progress.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	next, ok := scenario.NextExercise(entries, current, func(name string) bool {
+		return progressFile.Exercises[name].Status == "completed"
+	})
+	if !ok {
+		return nil, nil
+	}
+	return next, nil
+}
+
+func printNextExercise(cmd *cobra.Command, exercise *scenario.Exercise) {
+	ColorBold.Fprint(cmd.OutOrStdout(), "➡️  Next up: ")
+	ColorInfo.Fprintf(cmd.OutOrStdout(), "%s", exercise.Metadata.Name)
+	fmt.Fprintf(cmd.OutOrStdout(), " - %s\n", exercise.Metadata.Title)
+	if exercise.Spec.EstimatedTime != "" {
+		ColorDim.Fprintf(cmd.OutOrStdout(), "   %s, ~%s\n", exercise.Spec.Difficulty, exercise.Spec.EstimatedTime)
+	}
+}
+
+// switchExercise tears down the current exercise environment, if any, and
+// starts the named exercise.
+func switchExercise(cmd *cobra.Command, current, next string) error {
+	if current != "" && current != next {
+		if err := runStop(cmd, current); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout())
+	}
+	return runStart(cmd, next, &startOptions{})
+}
+
+// offerNextExercise suggests the exercise that follows a completed one and
+// starts it when the user accepts.
+func offerNextExercise(cmd *cobra.Command, current string) error {
+	next, err := resolveNextExercise(current)
+	if err != nil || next == nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	printNextExercise(cmd, next.Exercise)
+	if !confirmAction(cmd, "Stop this environment and start it now?") {
+		ColorDim.Fprintln(cmd.OutOrStdout(), "Run 'gymctl next' whenever you are ready.")
+		return nil
+	}
+	return switchExercise(cmd, current, next.Exercise.Metadata.Name)
+}
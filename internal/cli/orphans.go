@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gymctl/internal/config"
+	"gymctl/internal/environment"
+	"gymctl/internal/kube"
+	"gymctl/internal/progress"
+	"gymctl/internal/state"
+)
+
+// orphanFinder decides which gymctl resources no live exercise accounts
+// for. Live exercises are the current one and those in progress; anything
+// labelled for another exercise, or for an earlier session of a live one,
+// is left over from a stopped or crashed run.
+type orphanFinder struct {
+	live  map[string]bool
+	state *state.File
+	cfg   *config.Config
+}
+
+func newOrphanFinder() (*orphanFinder, error) {
+	cfg, err := loadGymConfig()
+	if err != nil {
+		return nil, err
+	}
+	_, stateFile, err := loadGymState()
+	if err != nil {
+		return nil, err
+	}
+	finder := &orphanFinder{live: map[string]bool{}, state: stateFile, cfg: cfg}
+
+	path, err := resolveProgressFile()
+	if err != nil {
+		return nil, err
+	}
+	progressFile, err := progress.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	for name, status := range progressFile.Exercises {
+		if status.Status == "in_progress" {
+			finder.live[name] = true
+		}
+	}
+	if current, err := loadCurrentExercise(); err == nil {
+		finder.live[current] = true
+	}
+	return finder, nil
+}
+
+// orphaned reports whether a labelled resource is left over. Volumes and
+// networks outlive a session when an exercise is started again without a
+// stop, so only their exercise counts.
+func (f *orphanFinder) orphaned(resource environment.Resource) bool {
+	exercise := resource.Exercise()
+	if !f.live[exercise] {
+		return true
+	}
+	if resource.Kind == environment.KindVolume || resource.Kind == environment.KindNetwork {
+		return false
+	}
+	recorded, ok := f.state.Environments[exercise]
+	session := resource.Session()
+	return ok && recorded.Session != "" && session != "" && session != recorded.Session
+}
+
+// liveClusters returns the kind clusters live exercises run in. A live
+// exercise without a record is assumed to use the cluster its name maps to.
+func (f *orphanFinder) liveClusters() map[string]bool {
+	clusters := map[string]bool{}
+	for exercise := range f.live {
+		if env, ok := f.state.Environments[exercise]; ok {
+			if env.Cluster != "" {
+				clusters[env.Cluster] = true
+			}
+			continue
+		}
+		clusters[f.cfg.ClusterName(exercise)] = true
+	}
+	return clusters
+}
+
+// ownsCluster reports whether gymctl created the kind cluster: it is
+// recorded in the state or carries the configured prefix.
+func (f *orphanFinder) ownsCluster(name string) bool {
+	for _, cluster := range f.state.Clusters() {
+		if cluster == name {
+			return true
+		}
+	}
+	prefix := config.Sanitize(f.cfg.Prefix)
+	return name == prefix || strings.HasPrefix(name, prefix+"-")
+}
+
+// find lists the orphaned resources. Sources that cannot be reached, such
+// as a stopped Docker daemon, are reported as warnings and skipped.
+func (f *orphanFinder) find(ctx context.Context) ([]environment.Resource, []error) {
+	var orphans []environment.Resource
+	var warnings []error
+
+	if resources, err := environment.DockerResources(ctx); err != nil {
+		warnings = append(warnings, fmt.Errorf("docker: %w", err))
+	} else {
+		for _, resource := range resources {
+			if f.orphaned(resource) {
+				orphans = append(orphans, resource)
+			}
+		}
+	}
+
+	liveClusters := f.liveClusters()
+	orphanContexts := map[string]bool{}
+	if clusters, err := (environment.KindManager{}).Clusters(ctx); err != nil {
+		warnings = append(warnings, fmt.Errorf("kind: %w", err))
+	} else {
+		for _, cluster := range clusters {
+			if !f.ownsCluster(cluster.Name) || liveClusters[cluster.Name] {
+				continue
+			}
+			if users := f.state.ClusterUsers(cluster.Name, ""); len(users) > 0 {
+				cluster.Labels = map[string]string{environment.LabelExercise: strings.Join(users, ",")}
+			}
+			orphanContexts[environment.KindContext(cluster.Name)] = true
+			orphans = append(orphans, cluster)
+		}
+	}
+
+	// Namespaces of other exercises can linger in the clusters that stay:
+	// shared kind clusters and the user's own current context.
+	contexts := map[string]bool{"": true}
+	for exercise := range f.live {
+		if env := f.state.Environments[exercise]; env.KubeContext != "" {
+			contexts[env.KubeContext] = true
+		}
+	}
+	for contextName := range contexts {
+		if orphanContexts[contextName] {
+			continue
+		}
+		scanCtx := ctx
+		if contextName != "" {
+			scanCtx = kube.WithContextName(ctx, contextName)
+		}
+		namespaces, err := environment.Namespaces(scanCtx)
+		if err != nil {
+			// The current context is often just not running.
+			if contextName != "" {
+				warnings = append(warnings, fmt.Errorf("namespaces in %s: %w", contextName, err))
+			}
+			continue
+		}
+		for _, namespace := range namespaces {
+			if f.orphaned(namespace) {
+				orphans = append(orphans, namespace)
+			}
+		}
+	}
+
+	environment.SortForRemoval(orphans)
+	return orphans, warnings
+}
+
+// forgetOrphanedClusters drops the state records of exercises that are not
+// live and whose cluster, if any, is no longer around.
+func (f *orphanFinder) forgetOrphanedClusters(removed map[string]bool) error {
+	return updateGymState(func(file *state.File) error {
+		for exercise, env := range file.Environments {
+			if !f.live[exercise] && (env.Cluster == "" || removed[env.Cluster]) {
+				delete(file.Environments, exercise)
+			}
+		}
+		return nil
+	})
+}
+
+// recoverOrphans finds leftover resources, lists them and removes them
+// after confirmation or when force is set.
+func recoverOrphans(cmd *cobra.Command, force bool) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := cmd.OutOrStdout()
+
+	finder, err := newOrphanFinder()
+	if err != nil {
+		return err
+	}
+	spinner := NewSpinnerManager()
+	spinner.Start("Checking for orphaned resources")
+	orphans, warnings := finder.find(ctx)
+	if len(orphans) == 0 {
+		spinner.Success("No orphaned resources found")
+	} else {
+		spinner.Stop()
+	}
+	for _, warning := range warnings {
+		ColorDim.Fprintf(out, "  Skipped %v\n", warning)
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	ColorWarning.Fprintf(out, "Found %d orphaned resource(s)\n", len(orphans))
+	printOrphans(cmd, orphans)
+	if !force && !confirmAction(cmd, "Remove orphaned resources?") {
+		ColorDim.Fprintln(out, "Leaving orphaned resources in place.")
+		return nil
+	}
+
+	removedClusters := map[string]bool{}
+	var reclaimed int64
+	failed := 0
+	for _, orphan := range orphans {
+		err := WithSpinner(fmt.Sprintf("Removing %s %s", orphan.Kind, orphan.Name), func() error {
+			return environment.RemoveResource(ctx, orphan)
+		})
+		if err != nil {
+			failed++
+			continue
+		}
+		reclaimed += orphan.Size
+		if orphan.Kind == environment.KindCluster {
+			removedClusters[orphan.Name] = true
+		}
+	}
+	if err := finder.forgetOrphanedClusters(removedClusters); err != nil {
+		ColorWarning.Fprintf(out, "Failed to update gym state: %v\n", err)
+	}
+
+	if failed > 0 {
+		ColorError.Fprintf(out, "Failed to remove %d of %d orphaned resources\n", failed, len(orphans))
+	} else {
+		ColorSuccess.Fprintf(out, "✓ Removed %d orphaned resource(s), reclaimed %s\n", len(orphans), humanizeBytes(reclaimed))
+	}
+	return nil
+}
+
+func printOrphans(cmd *cobra.Command, orphans []environment.Resource) {
+	out := cmd.OutOrStdout()
+	now := time.Now()
+	var total int64
+	fmt.Fprintf(out, "  %-10s %-40s %-30s %8s %10s\n", "KIND", "NAME", "EXERCISE", "AGE", "SIZE")
+	for _, orphan := range orphans {
+		name := orphan.Name
+		if orphan.KubeContext != "" {
+			name = orphan.KubeContext + "/" + name
+		}
+		exercise := orphan.Exercise()
+		if exercise == "" {
+			exercise = "-"
+		}
+		age, size := "-", "-"
+		if !orphan.Created.IsZero() {
+			age = humanizeAge(now.Sub(orphan.Created))
+		}
+		if orphan.Size > 0 {
+			size = humanizeBytes(orphan.Size)
+			total += orphan.Size
+		}
+		ColorDim.Fprintf(out, "  %-10s %-40s %-30s %8s %10s\n", orphan.Kind, name, exercise, age, size)
+	}
+	if total > 0 {
+		ColorBold.Fprintf(out, "  Total space to reclaim: %s\n", humanizeBytes(total))
+	}
+}
+
+// humanizeAge renders a duration in its largest whole unit, like `docker ps`.
+func humanizeAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
@@ -1,11 +1,8 @@
 package cli
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,7 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"gymctl/internal/environment"
+	"gymctl/internal/backup"
 	"gymctl/internal/progress"
 	"gymctl/internal/scenario"
 )
@@ -28,10 +25,16 @@ func newRecoverCmd() *cobra.Command {
 		Long: `Recover helps restore exercises from various failure states:
 - Corrupted Docker containers
 - Failed Kubernetes deployments
+- Containers, images, volumes, networks, kind clusters and namespaces
+  left behind by stopped or crashed exercises
 - Lost work directory
-- Corrupted progress file`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			defer RecoverFromPanic(cmd)
+- Corrupted progress file
+
+Recovering an exercise snapshots its work directory first. Pass --backup
+with a name from "gymctl backup list" or a path to restore a snapshot's
+files and progress entry.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer RecoverFromPanic(cmd, &err)
 
 			spinner := NewSpinnerManager()
 
@@ -42,6 +45,13 @@ func newRecoverCmd() *cobra.Command {
 			if len(args) > 0 {
 				return recoverExercise(cmd, args[0], backupPath, force)
 			}
+			if backupPath != "" {
+				name, err := backupExercise(backupPath)
+				if err != nil {
+					return err
+				}
+				return recoverExercise(cmd, name, backupPath, force)
+			}
 
 			// General recovery - check all systems
 			ColorBold.Fprintln(cmd.OutOrStdout(), "Checking system state...")
@@ -58,13 +68,28 @@ func newRecoverCmd() *cobra.Command {
 			if err != nil {
 				spinner.Fail("Progress file is corrupted")
 
-				// Attempt to recover
-				if force || confirmAction(cmd, "Create new progress file?") {
+				// Attempt to recover, from the last known good copy if any
+				restored := false
+				if backups := progress.Backups(progressPath); len(backups) > 0 {
+					prompt := fmt.Sprintf("Restore progress saved %s ago?", humanizeAge(time.Since(backups[0].ModTime)))
+					if force || confirmAction(cmd, prompt) {
+						good, err := progress.RestoreBackup(progressPath)
+						if err != nil {
+							return fmt.Errorf("failed to restore progress: %w", err)
+						}
+						if progressFile, err = progress.Load(progressPath); err != nil {
+							return err
+						}
+						restored = true
+						ColorSuccess.Fprintf(cmd.OutOrStdout(), "✓ Restored progress from %s\n", filepath.Base(good.Path))
+					}
+				}
+				if !restored && (force || confirmAction(cmd, "Create new progress file?")) {
 					progressFile = &progress.File{
 						Version:   1,
 						Exercises: make(map[string]progress.ExerciseStatus),
 					}
-					if err := progress.Save(progressPath, progressFile); err != nil {
+					if err := progress.Replace(progressPath, progressFile); err != nil {
 						return fmt.Errorf("failed to create new progress file: %w", err)
 					}
 					ColorSuccess.Fprintln(cmd.OutOrStdout(), "✓ Created new progress file")
@@ -73,28 +98,9 @@ func newRecoverCmd() *cobra.Command {
 				spinner.Success("Progress file is valid")
 			}
 
-			// 2. Check for orphaned containers
-			spinner.Start("Checking for orphaned containers")
-			orphaned, err := findOrphanedContainers(cmd.Context())
-			if err != nil {
-				spinner.Fail("Failed to check containers")
-			} else if len(orphaned) > 0 {
-				spinner.Stop()
-				ColorWarning.Fprintf(cmd.OutOrStdout(), "Found %d orphaned containers\n", len(orphaned))
-
-				for _, container := range orphaned {
-					ColorDim.Fprintf(cmd.OutOrStdout(), "  - %s\n", container)
-				}
-
-				if force || confirmAction(cmd, "Remove orphaned containers?") {
-					if err := cleanupOrphanedContainers(cmd.Context(), orphaned); err != nil {
-						ColorError.Fprintf(cmd.OutOrStdout(), "Failed to clean some containers: %v\n", err)
-					} else {
-						ColorSuccess.Fprintln(cmd.OutOrStdout(), "✓ Cleaned up orphaned containers")
-					}
-				}
-			} else {
-				spinner.Success("No orphaned containers found")
+			// 2. Check for orphaned resources
+			if err := recoverOrphans(cmd, force); err != nil {
+				ColorError.Fprintf(cmd.OutOrStdout(), "Failed to check for orphaned resources: %v\n", err)
 			}
 
 			// 3. Check work directories
@@ -127,16 +133,13 @@ func newRecoverCmd() *cobra.Command {
 			// 4. Check for backups
 			backupDir, err := resolveBackupDir()
 			if err == nil {
-				files, err := os.ReadDir(backupDir)
-				if err == nil && len(files) > 0 {
-					ColorInfo.Fprintf(cmd.OutOrStdout(), "\nFound %d backup(s) in %s\n", len(files), backupDir)
-					for _, file := range files {
-						if strings.HasSuffix(file.Name(), ".tar.gz") {
-							info, _ := file.Info()
-							ColorDim.Fprintf(cmd.OutOrStdout(), "  - %s (%s)\n",
-								file.Name(),
-								humanizeBytes(info.Size()))
-						}
+				backups, err := backup.List(backupDir, "")
+				if err == nil && len(backups) > 0 {
+					ColorInfo.Fprintf(cmd.OutOrStdout(), "\nFound %d backup(s) in %s\n", len(backups), backupDir)
+					for _, b := range backups {
+						ColorDim.Fprintf(cmd.OutOrStdout(), "  - %s (%s)\n",
+							b.Name(),
+							humanizeBytes(b.Size))
 					}
 				}
 			}
@@ -148,7 +151,7 @@ func newRecoverCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&backupPath, "backup", "b", "", "Path to backup file to restore")
+	cmd.Flags().StringVarP(&backupPath, "backup", "b", "", "Backup name or path to restore")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Force recovery without confirmation")
 
 	return cmd
@@ -165,14 +168,18 @@ func recoverExercise(cmd *cobra.Command, exerciseName, backupPath string, force
 		return fmt.Errorf("exercise not found: %s", exerciseName)
 	}
 
-	exercise := entry.Exercise
-
 	ColorBold.Fprintf(cmd.OutOrStdout(), "Recovering exercise: %s\n", exerciseName)
 	fmt.Fprintln(cmd.OutOrStdout())
 
 	// If backup path provided, restore from backup
 	if backupPath != "" {
-		return restoreFromBackup(cmd, exerciseName, backupPath)
+		return restoreBackup(cmd, exerciseName, backupPath)
+	}
+
+	// Teardown removes a Docker exercise's work directory, so snapshot it
+	// before anything else.
+	if err := snapshotBeforeWipe(cmd, exerciseName, backup.ReasonRecover); err != nil {
+		return err
 	}
 
 	// Otherwise, perform smart recovery
@@ -185,35 +192,24 @@ func recoverExercise(cmd *cobra.Command, exerciseName, backupPath string, force
 		ctx = context.Background()
 	}
 
-	switch exercise.Spec.Environment.Type {
-	case "docker":
-		if exercise.Spec.Environment.Docker != nil {
-			workDir, _ := resolveWorkDir(exerciseName)
-			manager := environment.DockerManager{WorkDir: workDir}
-			_ = manager.Teardown(ctx, entry.Dir, *exercise.Spec.Environment.Docker)
-		}
-	case "kubernetes":
-		if exercise.Spec.Environment.Kubernetes != nil {
-			k8s := exercise.Spec.Environment.Kubernetes
-			if k8s.CreateCluster != nil && *k8s.CreateCluster {
-				manager := environment.KindManager{ClusterName: "jerry-gym"}
-				_ = manager.Delete(ctx)
-			}
-		}
-	}
+	envWorkDir, _ := resolveWorkDir(exerciseName)
+	_ = teardownEnvironment(ctx, entry, envWorkDir)
 	spinner.Success("Environment cleaned")
 
 	// 2. Reset progress
 	spinner.Start("Resetting progress")
 	progressPath, _ := resolveProgressFile()
-	progressFile, _ := progress.Load(progressPath)
-
-	if progressFile.Exercises != nil {
+	err = progress.Update(progressPath, func(progressFile *progress.File) error {
 		status := progressFile.Exercises[exerciseName]
+		status.EndSession(time.Now())
 		status.Status = "not_started"
 		status.HintsUsed = 0
 		progressFile.Exercises[exerciseName] = status
-		progress.Save(progressPath, progressFile)
+		return nil
+	})
+	if err != nil {
+		spinner.Fail("Failed to reset progress")
+		return err
 	}
 	spinner.Success("Progress reset")
 
@@ -237,145 +233,6 @@ func recoverExercise(cmd *cobra.Command, exerciseName, backupPath string, force
 	return nil
 }
 
-func createBackup(exerciseName string) (string, error) {
-	backupDir, err := resolveBackupDir()
-	if err != nil {
-		return "", err
-	}
-
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return "", err
-	}
-
-	timestamp := time.Now().Format("20060102-150405")
-	backupFile := filepath.Join(backupDir, fmt.Sprintf("%s-%s.tar.gz", exerciseName, timestamp))
-
-	file, err := os.Create(backupFile)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	gw := gzip.NewWriter(file)
-	defer gw.Close()
-
-	tw := tar.NewWriter(gw)
-	defer tw.Close()
-
-	// Backup work directory
-	workDir, err := resolveWorkDir(exerciseName)
-	if err == nil {
-		filepath.Walk(workDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			header, err := tar.FileInfoHeader(info, "")
-			if err != nil {
-				return err
-			}
-
-			relPath, _ := filepath.Rel(workDir, path)
-			header.Name = filepath.Join("work", relPath)
-
-			if err := tw.WriteHeader(header); err != nil {
-				return err
-			}
-
-			if !info.IsDir() {
-				file, err := os.Open(path)
-				if err != nil {
-					return err
-				}
-				defer file.Close()
-				io.Copy(tw, file)
-			}
-
-			return nil
-		})
-	}
-
-	// Backup progress entry
-	progressPath, _ := resolveProgressFile()
-	progressFile, _ := progress.Load(progressPath)
-	if status, exists := progressFile.Exercises[exerciseName]; exists {
-		// Create a metadata file with progress info
-		metadata := fmt.Sprintf("exercise: %s\nstatus: %s\nstarted: %s\ncompleted: %s\nhints: %d\n",
-			exerciseName, status.Status, status.StartedAt, status.CompletedAt, status.HintsUsed)
-
-		header := &tar.Header{
-			Name: "metadata.txt",
-			Mode: 0644,
-			Size: int64(len(metadata)),
-		}
-		tw.WriteHeader(header)
-		tw.Write([]byte(metadata))
-	}
-
-	return backupFile, nil
-}
-
-func restoreFromBackup(cmd *cobra.Command, exerciseName, backupPath string) error {
-	file, err := os.Open(backupPath)
-	if err != nil {
-		return fmt.Errorf("cannot open backup file: %w", err)
-	}
-	defer file.Close()
-
-	gr, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("invalid backup file: %w", err)
-	}
-	defer gr.Close()
-
-	tr := tar.NewReader(gr)
-
-	workDir, _ := resolveWorkDir(exerciseName)
-
-	ColorInfo.Fprintf(cmd.OutOrStdout(), "Restoring from backup: %s\n", backupPath)
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		if strings.HasPrefix(header.Name, "work/") {
-			// Restore work directory files
-			targetPath := filepath.Join(workDir, strings.TrimPrefix(header.Name, "work/"))
-
-			if header.Typeflag == tar.TypeDir {
-				os.MkdirAll(targetPath, os.FileMode(header.Mode))
-			} else {
-				os.MkdirAll(filepath.Dir(targetPath), 0755)
-				file, err := os.Create(targetPath)
-				if err != nil {
-					return err
-				}
-				io.Copy(file, tr)
-				file.Close()
-			}
-		}
-	}
-
-	ColorSuccess.Fprintln(cmd.OutOrStdout(), "✓ Backup restored successfully")
-	return nil
-}
-
-func findOrphanedContainers(ctx context.Context) ([]string, error) {
-	// Implementation would check for containers with gymctl labels
-	// that don't match current exercises
-	return []string{}, nil
-}
-
-func cleanupOrphanedContainers(ctx context.Context, containers []string) error {
-	// Implementation would remove the specified containers
-	return nil
-}
-
 func checkWorkDirectories() ([]string, error) {
 	gymDir, err := resolveGymDir()
 	if err != nil {
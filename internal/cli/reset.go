@@ -10,14 +10,16 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"gymctl/internal/backup"
 	"gymctl/internal/environment"
 	"gymctl/internal/progress"
 	"gymctl/internal/scenario"
 )
 
 type resetOptions struct {
-	noCluster bool
-	keepWork  bool
+	noCluster       bool
+	recreateCluster bool
+	keepWork        bool
 }
 
 func newResetCmd() *cobra.Command {
@@ -46,76 +48,45 @@ func newResetCmd() *cobra.Command {
 			if !found {
 				return fmt.Errorf("exercise not found: %s", name)
 			}
-			exercise := entry.Exercise
+			exercise, err := withRecordedVariant(entry.Exercise)
+			if err != nil {
+				return err
+			}
 
 			ctx := cmd.Context()
 			if ctx == nil {
 				ctx = context.Background()
 			}
 
-			switch exercise.Spec.Environment.Type {
-			case "kubernetes":
-				if exercise.Spec.Environment.Kubernetes == nil {
-					return fmt.Errorf("missing kubernetes environment config")
-				}
-				k8s := exercise.Spec.Environment.Kubernetes
-				createCluster := true
-				if k8s.CreateCluster != nil {
-					createCluster = *k8s.CreateCluster
-				}
-				if opts.noCluster {
-					createCluster = false
-				}
-				namespace := k8s.Namespace
-				if namespace == "" {
-					namespace = "default"
-				}
-
-				if createCluster {
-					manager := environment.KindManager{ClusterName: "jerry-gym"}
-					_ = manager.Delete(ctx)
-					if err := manager.Create(ctx, k8s.KindConfig); err != nil {
-						return err
-					}
-				}
-
-				manifests := environment.ResolveManifestPaths(entry.Dir, k8s.SetupManifests)
-				if len(manifests) > 0 {
-					if err := environment.ApplyManifests(ctx, namespace, manifests); err != nil {
-						return err
-					}
-				}
-
-				for _, wait := range k8s.WaitFor {
-					if err := environment.WaitForCondition(ctx, namespace, wait.Resource, wait.Condition, wait.Timeout); err != nil {
-						return err
-					}
-				}
-			case "docker":
-				if exercise.Spec.Environment.Docker == nil {
-					return fmt.Errorf("missing docker environment config")
-				}
-				workDir, err := resolveWorkDir(exercise.Metadata.Name)
+			envWorkDir, err := resolveWorkDir(exercise.Metadata.Name)
+			if err != nil {
+				return err
+			}
+			if err := snapshotBeforeWipe(cmd, exercise.Metadata.Name, backup.ReasonReset); err != nil {
+				return err
+			}
+			if exercise.Spec.Environment.UsesDocker() && exercise.Spec.Environment.Docker != nil {
+				previous, err := exerciseEnvironment(exercise)
 				if err != nil {
 					return err
 				}
-				manager := environment.DockerManager{WorkDir: workDir}
+				manager := environment.DockerManager{WorkDir: envWorkDir, Project: previous.ComposeProject}
 				if err := manager.Teardown(ctx, entry.Dir, *exercise.Spec.Environment.Docker); err != nil {
 					return err
 				}
-				if err := manager.Setup(ctx, entry.Dir, *exercise.Spec.Environment.Docker); err != nil {
+				if err := environment.RemoveLabeled(ctx, exercise.Metadata.Name); err != nil {
 					return err
 				}
-			default:
-				return fmt.Errorf("unsupported environment type: %s", exercise.Spec.Environment.Type)
 			}
-
-			if err := markReset(exercise); err != nil {
-				return err
-			}
-			if err := writeCurrentExercise(exercise.Metadata.Name); err != nil {
+			env, err := provisionEnvironment(ctx, entry, exercise, envWorkDir, provisionOptions{
+				noCluster:       opts.noCluster,
+				recreateCluster: opts.recreateCluster,
+				variant:         recordedVariant(exercise.Metadata.Name),
+			})
+			if err != nil {
 				return err
 			}
+			ctx = withExerciseCluster(ctx, env)
 
 			// Handle work directory
 			workDir, err := resolveWorkDir(exercise.Metadata.Name)
@@ -144,6 +115,27 @@ func newResetCmd() *cobra.Command {
 				}
 			}
 
+			// The starting files are restored whether or not the rest of
+			// the work directory was kept.
+			if _, err := copyExerciseFiles(entry, exercise, workDir); err != nil {
+				return err
+			}
+			if len(exercise.Spec.Environment.CustomSetup) > 0 {
+				if err := os.MkdirAll(workDir, 0o755); err != nil {
+					return fmt.Errorf("create work directory: %w", err)
+				}
+				if err := runCustomSetup(ctx, entry.Dir, workDir, exercise.Spec.Environment.CustomSetup); err != nil {
+					return err
+				}
+			}
+
+			if err := markReset(exercise); err != nil {
+				return err
+			}
+			if err := writeCurrentExercise(exercise.Metadata.Name); err != nil {
+				return err
+			}
+
 			fmt.Fprintln(cmd.OutOrStdout(), "")
 			fmt.Fprintln(cmd.OutOrStdout(), "Exercise reset successfully.")
 			fmt.Fprintf(cmd.OutOrStdout(), "Work directory: %s\n", workDir)
@@ -155,6 +147,7 @@ func newResetCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&opts.noCluster, "no-cluster", false, "Skip kind cluster recreation")
+	cmd.Flags().BoolVar(&opts.recreateCluster, "recreate-cluster", false, "Recreate the kind cluster instead of wiping the exercise's resources")
 	cmd.Flags().BoolVar(&opts.keepWork, "keep-work", false, "Keep work directory contents")
 	return cmd
 }
@@ -164,14 +157,13 @@ func markReset(exercise *scenario.Exercise) error {
 	if err != nil {
 		return err
 	}
-	progressFile, err := progress.Load(path)
-	if err != nil {
-		return err
-	}
-	entry := progressFile.Exercises[exercise.Metadata.Name]
-	entry.Resets++
-	entry.Status = "in_progress"
-	entry.StartedAt = time.Now().UTC().Format(time.RFC3339)
-	progressFile.Exercises[exercise.Metadata.Name] = entry
-	return progress.Save(path, progressFile)
+	return progress.Update(path, func(progressFile *progress.File) error {
+		entry := progressFile.Exercises[exercise.Metadata.Name]
+		entry.Resets++
+		entry.Status = "in_progress"
+		entry.StartedAt = time.Now().UTC().Format(time.RFC3339)
+		entry.StartSession(time.Now())
+		progressFile.Exercises[exercise.Metadata.Name] = entry
+		return nil
+	})
 }
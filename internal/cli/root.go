@@ -5,12 +5,17 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/kube"
+	"gymctl/internal/runner"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "gymctl",
 	Short: "Gymctl orchestrates Jerry's chaos gym exercises",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		setupExecutor(cmd)
 		// Resolve tasks directory location
 		return setupTasksDirectory()
 	},
@@ -18,17 +23,58 @@ var rootCmd = &cobra.Command{
 
 var tasksDir string
 var progressFile string
+var debug bool
+var recordFile string
+var useKubectl bool
+var useDockerCLI bool
+
+// recorder captures external commands when --record is set.
+var recorder *runner.Recorder
+
+// setupExecutor routes the external commands of the invoked command through
+// the --record and --debug hooks.
+func setupExecutor(cmd *cobra.Command) {
+	var executor runner.Executor = runner.Exec{}
+	if recordFile != "" {
+		recorder = runner.NewRecorder(executor)
+		executor = recorder
+	}
+	if debug {
+		executor = runner.NewLogger(executor, os.Stderr)
+	}
+	ctx := runner.WithExecutor(cmd.Context(), executor)
+	// Recordings can only replay commands, so they always use the CLIs.
+	if useKubectl || recordFile != "" {
+		ctx = kube.WithKubectl(ctx)
+	}
+	if useDockerCLI || recordFile != "" {
+		ctx = dockerapi.WithCLI(ctx)
+	}
+	cmd.SetContext(ctx)
+}
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	cmd, err := rootCmd.ExecuteC()
+	if recorder != nil {
+		if saveErr := recorder.Fixture().Save(recordFile); saveErr != nil {
+			fmt.Fprintln(os.Stderr, saveErr)
+		}
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		// Flag and argument errors, which cobra reports before RunE, count
+		// as the command not being able to run.
+		os.Exit(exitCode(cmd, err))
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&tasksDir, "tasks-dir", "tasks", "Tasks directory")
 	rootCmd.PersistentFlags().StringVar(&progressFile, "progress-file", "", "Progress file path (default: ~/.gym/progress.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Log every external command gymctl runs")
+	rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "Record external commands and their output to a fixture file")
+	rootCmd.PersistentFlags().BoolVar(&useDockerCLI, "docker-cli", false, "Talk to Docker through the docker CLI instead of the Engine API")
+	rootCmd.PersistentFlags().BoolVar(&useKubectl, "kubectl", false, "Talk to Kubernetes through kubectl instead of the API directly")
 
 	rootCmd.AddCommand(
 		newValidateCmd(),
@@ -36,9 +82,12 @@ func init() {
 		newStartCmd(),
 		newStopCmd(),
 		newCheckCmd(),
+		newNextCmd(),
+		newTestCmd(),
 		newHintCmd(),
 		newResetCmd(),
 		newRecoverCmd(),
+		newBackupCmd(),
 		newStatusCmd(),
 		newCleanCmd(),
 		newCleanupCmd(),
@@ -14,10 +14,14 @@ import (
 	"gymctl/internal/environment"
 	"gymctl/internal/progress"
 	"gymctl/internal/scenario"
+	"gymctl/internal/state"
 )
 
 type startOptions struct {
-	noCluster bool
+	noCluster       bool
+	recreateCluster bool
+	variant         string
+	force           bool
 }
 
 func newStartCmd() *cobra.Command {
@@ -26,157 +30,274 @@ func newStartCmd() *cobra.Command {
 		Use:   "start <exercise-name>",
 		Short: "Start an exercise",
 		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			defer RecoverFromPanic(cmd)
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer RecoverFromPanic(cmd, &err)
+			return runStart(cmd, args[0], opts)
+		},
+	}
 
-			entries, err := scenario.LoadCatalog(tasksDir)
-			if err != nil {
-				return HandleCommandError(cmd, err)
-			}
-			entry, found := scenario.FindByName(entries, args[0])
-			if !found {
-				return HandleCommandError(cmd, WrapErrorWithHint(
-					fmt.Errorf("exercise not found: %s", args[0]),
-					"Check the exercise name is correct",
-					"gymctl list",
-				))
-			}
+	cmd.Flags().BoolVar(&opts.noCluster, "no-cluster", false, "Skip kind cluster creation")
+	cmd.Flags().BoolVar(&opts.recreateCluster, "recreate-cluster", false, "Recreate the kind cluster even if a running one can be reused")
+	cmd.Flags().StringVar(&opts.variant, "variant", "", "Start a specific exercise variant")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Start even if prerequisites are not completed")
 
-			exercise := entry.Exercise
-			ctx := cmd.Context()
-			if ctx == nil {
-				ctx = context.Background()
-			}
+	return cmd
+}
 
-			switch exercise.Spec.Environment.Type {
-			case "kubernetes":
-				if exercise.Spec.Environment.Kubernetes == nil {
-					return fmt.Errorf("missing kubernetes environment config")
-				}
+// runStart provisions the exercise environment and records the attempt.
+func runStart(cmd *cobra.Command, name string, opts *startOptions) error {
+	entries, err := scenario.LoadCatalog(tasksDir)
+	if err != nil {
+		return HandleCommandError(cmd, err)
+	}
+	entry, found := scenario.FindByName(entries, name)
+	if !found {
+		return HandleCommandError(cmd, WrapErrorWithHint(
+			fmt.Errorf("exercise not found: %s", name),
+			"Check the exercise name is correct",
+			"gymctl list",
+		))
+	}
 
-				k8s := exercise.Spec.Environment.Kubernetes
-				createCluster := true
-				if k8s.CreateCluster != nil {
-					createCluster = *k8s.CreateCluster
-				}
-				if opts.noCluster {
-					createCluster = false
-				}
-				namespace := k8s.Namespace
-				if namespace == "" {
-					namespace = "default"
-				}
+	if err := checkPrerequisites(cmd, entry.Exercise, opts.force); err != nil {
+		return HandleCommandError(cmd, err)
+	}
 
-				if createCluster {
-					manager := environment.KindManager{ClusterName: "jerry-gym"}
-					exists, err := manager.Exists(ctx)
-					if err != nil {
-						return err
-					}
-					if exists {
-						err = WithSpinner("Cleaning existing kind cluster", func() error {
-							return manager.Delete(ctx)
-						})
-						if err != nil {
-							return err
-						}
-					}
-					err = WithSpinner("Creating kind cluster (this may take a minute)", func() error {
-						return manager.Create(ctx, k8s.KindConfig)
-					})
-					if err != nil {
-						return err
-					}
-				}
+	variant, err := chooseVariant(entry.Exercise, opts.variant)
+	if err != nil {
+		return HandleCommandError(cmd, err)
+	}
+	exercise, err := entry.Exercise.WithVariant(variant)
+	if err != nil {
+		return HandleCommandError(cmd, err)
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
-				manifests := environment.ResolveManifestPaths(entry.Dir, k8s.SetupManifests)
-				if len(manifests) > 0 {
-					err = WithSpinner("Applying setup manifests", func() error {
-						return environment.ApplyManifests(ctx, namespace, manifests)
-					})
-					if err != nil {
-						return err
-					}
-				}
+	workDir, err := resolveWorkDir(exercise.Metadata.Name)
+	if err != nil {
+		return err
+	}
+	env, err := provisionEnvironment(ctx, entry, exercise, workDir, provisionOptions{
+		noCluster:       opts.noCluster,
+		recreateCluster: opts.recreateCluster,
+		variant:         variant,
+	})
+	if err != nil {
+		return err
+	}
+	ctx = withExerciseCluster(ctx, env)
 
-				for _, wait := range k8s.WaitFor {
-					err = WithSpinner(fmt.Sprintf("Waiting for %s", wait.Resource), func() error {
-						return environment.WaitForCondition(ctx, namespace, wait.Resource, wait.Condition, wait.Timeout)
-					})
-					if err != nil {
-						return err
-					}
-				}
-			case "docker":
-				if exercise.Spec.Environment.Docker == nil {
-					return fmt.Errorf("missing docker environment config")
-				}
-				workDir, err := resolveWorkDir(exercise.Metadata.Name)
-				if err != nil {
+	printExerciseIntro(cmd, exercise)
+	if variant != "" {
+		ColorInfo.Fprint(cmd.OutOrStdout(), "🎲 Variant: ")
+		fmt.Fprintln(cmd.OutOrStdout(), variant)
+		fmt.Fprintln(cmd.OutOrStdout(), "")
+	}
+
+	// Create and show work directory
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return fmt.Errorf("create work directory: %w", err)
+	}
+
+	copied, err := copyExerciseFiles(entry, exercise, workDir)
+	if err != nil {
+		return err
+	}
+	if copied {
+		fmt.Fprintln(cmd.OutOrStdout(), "Exercise files copied to work directory.")
+	}
+
+	if err := runCustomSetup(ctx, entry.Dir, workDir, exercise.Spec.Environment.CustomSetup); err != nil {
+		return err
+	}
+
+	// Only a fully set up exercise counts as started, so a failed setup
+	// can simply be retried.
+	if err := markStarted(exercise, variant); err != nil {
+		return err
+	}
+	if err := writeCurrentExercise(exercise.Metadata.Name); err != nil {
+		return err
+	}
+
+	// Print work directory info
+	fmt.Fprintln(cmd.OutOrStdout(), "")
+	fmt.Fprintf(cmd.OutOrStdout(), "Work directory: %s\n", workDir)
+	fmt.Fprintln(cmd.OutOrStdout(), "")
+	fmt.Fprintln(cmd.OutOrStdout(), "To navigate to your work directory, run:")
+	fmt.Fprintf(cmd.OutOrStdout(), "  cd %s\n", workDir)
+	fmt.Fprintln(cmd.OutOrStdout(), "")
+
+	return nil
+}
+
+// provisionOptions controls how provisionEnvironment treats the cluster.
+type provisionOptions struct {
+	// noCluster runs against the current kubeconfig context instead of a
+	// kind cluster of the exercise's own.
+	noCluster bool
+	// recreateCluster deletes a running cluster even when it could be
+	// reused.
+	recreateCluster bool
+	// variant is recorded in the labels of provisioned resources.
+	variant string
+}
+
+// provisionEnvironment brings up the exercise environment and records the
+// cluster and compose project it uses. Hybrid exercises get their cluster
+// first, then the Docker setup, whose built images are loaded into kind
+// before the manifests that use them are applied.
+func provisionEnvironment(ctx context.Context, entry *scenario.CatalogEntry, exercise *scenario.Exercise, workDir string, opts provisionOptions) (state.Environment, error) {
+	names, err := plannedEnvironment(exercise, opts.noCluster)
+	if err != nil {
+		return state.Environment{}, err
+	}
+	names.Variant = opts.variant
+	names.Session = environment.NewSessionID()
+	if err := setupEnvironment(ctx, entry, exercise, workDir, names, environmentLabels(exercise, names), opts.recreateCluster); err != nil {
+		return names, err
+	}
+	return names, recordEnvironment(exercise.Metadata.Name, names)
+}
+
+// setupEnvironment creates or reuses the kind cluster and applies the
+// exercise's Docker and Kubernetes setup, putting labels on what it
+// provisions. A running cluster is kept when reuse is enabled and it was
+// created from the same kind configuration; it becomes the current context
+// again and only the exercise's namespaces, manifest objects and the
+// objects its checks read are wiped.
+func setupEnvironment(ctx context.Context, entry *scenario.CatalogEntry, exercise *scenario.Exercise, workDir string, names state.Environment, labels environment.Labels, recreate bool) error {
+	env := exercise.Spec.Environment
+	if !env.UsesKubernetes() && !env.UsesDocker() {
+		return fmt.Errorf("unsupported environment type: %s", env.Type)
+	}
+	if env.UsesKubernetes() && env.Kubernetes == nil {
+		return fmt.Errorf("missing kubernetes environment config")
+	}
+	if env.UsesDocker() && env.Docker == nil {
+		return fmt.Errorf("missing docker environment config")
+	}
+
+	createCluster := names.Cluster != ""
+	manager := environment.KindManager{ClusterName: names.Cluster}
+	ctx = withExerciseCluster(ctx, names)
+
+	if createCluster {
+		exists, err := manager.Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists && !recreate && canReuseCluster(names) {
+			err = WithSpinner(fmt.Sprintf("Reusing kind cluster %s, wiping exercise resources", names.Cluster), func() error {
+				if err := manager.UseContext(ctx); err != nil {
 					return err
 				}
-				manager := environment.DockerManager{WorkDir: workDir}
-				err = WithSpinner("Setting up docker environment", func() error {
-					return manager.Setup(ctx, entry.Dir, *exercise.Spec.Environment.Docker)
+				namespace := exerciseNamespace(env.Kubernetes)
+				manifests := environment.ResolveManifestPaths(entry.Dir, env.Kubernetes.SetupManifests)
+				return environment.WipeManifests(ctx, namespace, manifests, environment.CheckedObjects(exercise.Spec.Checks, namespace))
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			if exists {
+				err = WithSpinner("Cleaning existing kind cluster", func() error {
+					return manager.Delete(ctx)
 				})
 				if err != nil {
 					return err
 				}
-			default:
-				return fmt.Errorf("unsupported environment type: %s", exercise.Spec.Environment.Type)
 			}
-
-			printExerciseIntro(cmd, exercise)
-
-			if err := markStarted(exercise); err != nil {
+			err = WithSpinner("Creating kind cluster (this may take a minute)", func() error {
+				return manager.Create(ctx, env.Kubernetes.KindConfig)
+			})
+			if err != nil {
 				return err
 			}
+		}
+	}
 
-			if err := writeCurrentExercise(exercise.Metadata.Name); err != nil {
-				return err
-			}
+	if env.UsesDocker() {
+		docker := environment.DockerManager{WorkDir: workDir, Project: names.ComposeProject, Labels: labels}
+		err := WithSpinner("Setting up docker environment", func() error {
+			return docker.Setup(ctx, entry.Dir, *env.Docker)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if !env.UsesKubernetes() {
+		return nil
+	}
+	k8s := env.Kubernetes
+	namespace := exerciseNamespace(k8s)
 
-			// Create and show work directory
-			workDir, err := resolveWorkDir(exercise.Metadata.Name)
+	if createCluster {
+		var images []string
+		if env.UsesDocker() {
+			images = environment.BuiltImages(*env.Docker)
+		}
+		for _, image := range append(images, k8s.LoadImages...) {
+			err := WithSpinner(fmt.Sprintf("Loading image %s into kind", image), func() error {
+				return manager.LoadImage(ctx, image)
+			})
 			if err != nil {
 				return err
 			}
-			if err := os.MkdirAll(workDir, 0o755); err != nil {
-				return fmt.Errorf("create work directory: %w", err)
-			}
-
-			// Copy files if Docker environment specifies copyFiles
-			if exercise.Spec.Environment.Docker != nil && len(exercise.Spec.Environment.Docker.CopyFiles) > 0 {
-				for _, copySpec := range exercise.Spec.Environment.Docker.CopyFiles {
-					srcPath := filepath.Join(entry.Dir, copySpec.From)
-					dstPath := filepath.Join(workDir, copySpec.To)
-					// Handle both files and directories
-					if strings.HasSuffix(copySpec.From, "/") {
-						// Source ends with /, treat as directory contents
-						srcPath = strings.TrimSuffix(srcPath, "/")
-					}
-					if err := copyPath(srcPath, dstPath); err != nil {
-						return fmt.Errorf("copy %s: %w", copySpec.From, err)
-					}
-				}
-				fmt.Fprintln(cmd.OutOrStdout(), "Exercise files copied to work directory.")
-			}
+		}
+	}
 
-			// Print work directory info
-			fmt.Fprintln(cmd.OutOrStdout(), "")
-			fmt.Fprintf(cmd.OutOrStdout(), "Work directory: %s\n", workDir)
-			fmt.Fprintln(cmd.OutOrStdout(), "")
-			fmt.Fprintln(cmd.OutOrStdout(), "To navigate to your work directory, run:")
-			fmt.Fprintf(cmd.OutOrStdout(), "  cd %s\n", workDir)
-			fmt.Fprintln(cmd.OutOrStdout(), "")
+	manifests := environment.ResolveManifestPaths(entry.Dir, k8s.SetupManifests)
+	if len(manifests) > 0 {
+		err := WithSpinner("Applying setup manifests", func() error {
+			return environment.ApplyManifests(ctx, namespace, manifests, labels.Map())
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-			return nil
-		},
+	for _, wait := range k8s.WaitFor {
+		err := WithSpinner(fmt.Sprintf("Waiting for %s", wait.Resource), func() error {
+			return environment.WaitForCondition(ctx, namespace, wait.Resource, wait.Condition, wait.Timeout)
+		})
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	cmd.Flags().BoolVar(&opts.noCluster, "no-cluster", false, "Skip kind cluster creation")
+// copyExerciseFiles puts the exercise's broken starting files into the work
+// directory and reports whether there were any.
+func copyExerciseFiles(entry *scenario.CatalogEntry, exercise *scenario.Exercise, workDir string) (bool, error) {
+	docker := exercise.Spec.Environment.Docker
+	if docker == nil || len(docker.CopyFiles) == 0 {
+		return false, nil
+	}
+	if err := environment.CopyFiles(entry.Dir, workDir, *docker); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	return cmd
+// runCustomSetup runs the exercise's customSetup steps in order, stopping at
+// the first failure.
+func runCustomSetup(ctx context.Context, exerciseDir, workDir string, steps []scenario.CustomSetupStep) error {
+	setup := environment.CustomSetup{ExerciseDir: exerciseDir, WorkDir: workDir}
+	for _, step := range steps {
+		err := WithSpinner(environment.DescribeStep(step), func() error {
+			return setup.Run(ctx, step)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func printExerciseIntro(cmd *cobra.Command, exercise *scenario.Exercise) {
@@ -210,27 +331,63 @@ func printExerciseIntro(cmd *cobra.Command, exercise *scenario.Exercise) {
 	fmt.Fprintln(out, "")
 }
 
-func markStarted(exercise *scenario.Exercise) error {
+// checkPrerequisites refuses to start an exercise whose prerequisites are not
+// completed, or only warns about them when force is set.
+func checkPrerequisites(cmd *cobra.Command, exercise *scenario.Exercise, force bool) error {
+	if len(exercise.Spec.Prerequisites) == 0 {
+		return nil
+	}
 	path, err := resolveProgressFile()
 	if err != nil {
 		return err
 	}
-
 	progressFile, err := progress.Load(path)
 	if err != nil {
 		return err
 	}
 
-	entry := progressFile.Exercises[exercise.Metadata.Name]
-	if entry.Status == "" || entry.Status == "not_started" {
-		entry.StartedAt = time.Now().UTC().Format(time.RFC3339)
-		entry.HintsUsed = 0
-		entry.Resets = 0
+	unmet := unmetPrerequisites(exercise, progressFile)
+	if len(unmet) == 0 {
+		return nil
+	}
+	if force {
+		ColorWarning.Fprintf(cmd.ErrOrStderr(), "%s Starting without completed prerequisites: %s\n", IconWarning, strings.Join(unmet, ", "))
+		return nil
 	}
-	entry.Status = "in_progress"
-	progressFile.Exercises[exercise.Metadata.Name] = entry
+	return WrapErrorWithHint(
+		fmt.Errorf("exercise %s is locked: complete %s first", exercise.Metadata.Name, strings.Join(unmet, ", ")),
+		"Finish the prerequisites or use --force to start anyway",
+		"gymctl start "+unmet[0],
+	)
+}
+
+func unmetPrerequisites(exercise *scenario.Exercise, progressFile *progress.File) []string {
+	return scenario.UnmetPrerequisites(exercise, func(name string) bool {
+		return progressFile.Exercises[name].Status == "completed"
+	})
+}
 
-	return progress.Save(path, progressFile)
+func markStarted(exercise *scenario.Exercise, variant string) error {
+	path, err := resolveProgressFile()
+	if err != nil {
+		return err
+	}
+
+	return progress.Update(path, func(progressFile *progress.File) error {
+		now := time.Now().UTC()
+		entry := progressFile.Exercises[exercise.Metadata.Name]
+		if entry.Status == "" || entry.Status == "not_started" {
+			entry.StartedAt = now.Format(time.RFC3339)
+			entry.HintsUsed = 0
+			entry.Resets = 0
+			entry.Sessions = nil
+		}
+		entry.Status = "in_progress"
+		entry.Variant = variant
+		entry.StartSession(now)
+		progressFile.Exercises[exercise.Metadata.Name] = entry
+		return nil
+	})
 }
 
 func copyPath(srcPath, dstPath string) error {
@@ -1,9 +1,10 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -11,16 +12,25 @@ import (
 	"gymctl/internal/scenario"
 )
 
+type statusOptions struct {
+	output string
+}
+
 func newStatusCmd() *cobra.Command {
+	opts := &statusOptions{}
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show overall progress",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.output != "text" && opts.output != "json" {
+				return fmt.Errorf("unsupported output format: %s (use text or json)", opts.output)
+			}
+
 			entries, err := scenario.LoadCatalog(tasksDir)
 			if err != nil {
 				return err
 			}
-			if len(entries) == 0 {
+			if len(entries) == 0 && opts.output == "text" {
 				ColorWarning.Fprintln(cmd.OutOrStdout(), "No exercises found.")
 				return nil
 			}
@@ -34,26 +44,19 @@ func newStatusCmd() *cobra.Command {
 				return err
 			}
 
-			sort.Slice(entries, func(i, j int) bool {
-				ai := entries[i].Exercise
-				aj := entries[j].Exercise
-				if ai.Metadata.Track != aj.Metadata.Track {
-					return ai.Metadata.Track < aj.Metadata.Track
-				}
-				if ai.Metadata.Week != aj.Metadata.Week {
-					return ai.Metadata.Week < aj.Metadata.Week
-				}
-				if ai.Metadata.Order != aj.Metadata.Order {
-					return ai.Metadata.Order < aj.Metadata.Order
-				}
-				return ai.Metadata.Name < aj.Metadata.Name
-			})
+			scenario.SortCatalog(entries)
+
+			if opts.output == "json" {
+				return writeStatusJSON(cmd, entries, progressFile, time.Now())
+			}
 
 			// Calculate statistics
+			now := time.Now()
 			var totalPoints int
 			var earnedPoints int
 			var completedCount int
 			var inProgressCount int
+			var totalTime time.Duration
 			trackStats := make(map[string]struct {
 				total     int
 				completed int
@@ -65,8 +68,9 @@ func newStatusCmd() *cobra.Command {
 				stats := trackStats[track]
 				stats.total++
 
-				totalPoints += defaultPoints(exercise.Spec.Points)
+				totalPoints += exercisePoints(exercise.Spec)
 				status := progressFile.Exercises[exercise.Metadata.Name]
+				totalTime += status.ActiveTime(now)
 				if status.Status == "completed" {
 					completedCount++
 					stats.completed++
@@ -128,7 +132,9 @@ func newStatusCmd() *cobra.Command {
 
 				// Time display
 				timeStr := ColorDim.Sprint("-")
-				if status.TimeSpent != "" {
+				if len(status.Sessions) > 0 {
+					timeStr = ColorTime.Sprint(progress.FormatDuration(status.ActiveTime(now)))
+				} else if status.TimeSpent != "" {
 					timeStr = ColorTime.Sprint(status.TimeSpent)
 				}
 
@@ -138,6 +144,17 @@ func newStatusCmd() *cobra.Command {
 					pointsStr,
 					timeStr,
 				)
+				if status.Status == "completed" && status.ScoreBreakdown != nil {
+					breakdown := status.ScoreBreakdown
+					if penalties := formatPenalties(*breakdown); penalties != "" {
+						ColorDim.Fprintf(cmd.OutOrStdout(), "      └─ %d base, %s\n", breakdown.Base, penalties)
+					}
+					if breakdown.Missed > 0 || exercise.Spec.WeighsChecks() {
+						for _, line := range formatCheckScores(breakdown.Checks) {
+							fmt.Fprintln(cmd.OutOrStdout(), "         "+line)
+						}
+					}
+				}
 			}
 
 			// Print summary section
@@ -164,6 +181,10 @@ func newStatusCmd() *cobra.Command {
 			}
 			ColorDim.Fprintf(cmd.OutOrStdout(), " (%d%%)\n", percentage)
 
+			// Time summary
+			ColorBold.Fprint(cmd.OutOrStdout(), "Time:       ")
+			ColorTime.Fprintln(cmd.OutOrStdout(), progress.FormatDuration(totalTime))
+
 			// Status summary
 			ColorBold.Fprint(cmd.OutOrStdout(), "Status:     ")
 			if completedCount > 0 {
@@ -198,5 +219,149 @@ func newStatusCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "text", "Output format: text or json")
+
 	return cmd
 }
+
+type statusReport struct {
+	Exercises []exerciseStatusReport `json:"exercises"`
+	Totals    statusTotals           `json:"totals"`
+}
+
+type exerciseStatusReport struct {
+	Name             string                `json:"name"`
+	Title            string                `json:"title"`
+	Track            string                `json:"track"`
+	Status           string                `json:"status"`
+	Score            int                   `json:"score"`
+	MaxScore         int                   `json:"maxScore"`
+	Breakdown        *scoreBreakdownReport `json:"scoreBreakdown,omitempty"`
+	HintsUsed        int                   `json:"hintsUsed"`
+	Resets           int                   `json:"resets"`
+	StartedAt        string                `json:"startedAt,omitempty"`
+	CompletedAt      string                `json:"completedAt,omitempty"`
+	TimeSpentSeconds int64                 `json:"timeSpentSeconds"`
+	Sessions         []sessionReport       `json:"sessions,omitempty"`
+}
+
+type sessionReport struct {
+	StartedAt string `json:"startedAt"`
+	EndedAt   string `json:"endedAt,omitempty"`
+}
+
+type scoreBreakdownReport struct {
+	Base         int                `json:"base"`
+	Missed       int                `json:"missed"`
+	HintPenalty  int                `json:"hintPenalty"`
+	ResetPenalty int                `json:"resetPenalty"`
+	TimePenalty  int                `json:"timePenalty"`
+	Total        int                `json:"total"`
+	Checks       []checkScoreReport `json:"checks,omitempty"`
+}
+
+type checkScoreReport struct {
+	Name     string `json:"name"`
+	Points   int    `json:"points"`
+	Earned   int    `json:"earned"`
+	Passed   bool   `json:"passed"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type statusTotals struct {
+	Exercises        int   `json:"exercises"`
+	Completed        int   `json:"completed"`
+	InProgress       int   `json:"inProgress"`
+	EarnedPoints     int   `json:"earnedPoints"`
+	TotalPoints      int   `json:"totalPoints"`
+	TimeSpentSeconds int64 `json:"timeSpentSeconds"`
+}
+
+func writeStatusJSON(cmd *cobra.Command, entries []scenario.CatalogEntry, progressFile *progress.File, now time.Time) error {
+	report := statusReport{Exercises: []exerciseStatusReport{}}
+	var totalTime time.Duration
+	for _, entry := range entries {
+		exercise := entry.Exercise
+		status := progressFile.Exercises[exercise.Metadata.Name]
+		statusName := status.Status
+		if statusName == "" {
+			statusName = "not_started"
+		}
+		active := status.ActiveTime(now)
+		totalTime += active
+
+		item := exerciseStatusReport{
+			Name:             exercise.Metadata.Name,
+			Title:            exercise.Metadata.Title,
+			Track:            exercise.Metadata.Track,
+			Status:           statusName,
+			MaxScore:         exercisePoints(exercise.Spec),
+			HintsUsed:        status.HintsUsed,
+			Resets:           status.Resets,
+			StartedAt:        status.StartedAt,
+			CompletedAt:      status.CompletedAt,
+			TimeSpentSeconds: int64(active / time.Second),
+		}
+		for _, session := range status.Sessions {
+			item.Sessions = append(item.Sessions, sessionReport{StartedAt: session.StartedAt, EndedAt: session.EndedAt})
+		}
+		if status.Status == "completed" {
+			item.Score = status.Score
+			report.Totals.Completed++
+			report.Totals.EarnedPoints += status.Score
+		} else if status.Status == "in_progress" {
+			report.Totals.InProgress++
+		}
+		if b := status.ScoreBreakdown; b != nil {
+			item.Breakdown = &scoreBreakdownReport{
+				Base:         b.Base,
+				Missed:       b.Missed,
+				HintPenalty:  b.HintPenalty,
+				ResetPenalty: b.ResetPenalty,
+				TimePenalty:  b.TimePenalty,
+				Total:        b.Total,
+			}
+			for _, check := range b.Checks {
+				item.Breakdown.Checks = append(item.Breakdown.Checks, checkScoreReport{
+					Name:     check.Name,
+					Points:   check.Points,
+					Earned:   earnedPoints(check),
+					Passed:   check.Passed,
+					Optional: check.Optional,
+				})
+			}
+		}
+		report.Totals.TotalPoints += item.MaxScore
+		report.Exercises = append(report.Exercises, item)
+	}
+	report.Totals.Exercises = len(entries)
+	report.Totals.TimeSpentSeconds = int64(totalTime / time.Second)
+
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// formatCheckScores lists what each check earned of the points it is worth.
+func formatCheckScores(scores []progress.CheckScore) []string {
+	var lines []string
+	for _, score := range scores {
+		icon := ColorFail.Sprint(IconFail)
+		if score.Passed {
+			icon = ColorSuccess.Sprint(IconSuccess)
+		}
+		line := fmt.Sprintf("%3d/%-3d %s", earnedPoints(score), score.Points, score.Name)
+		if score.Optional {
+			line += " (optional)"
+		}
+		lines = append(lines, icon+" "+ColorDim.Sprint(line))
+	}
+	return lines
+}
+
+func earnedPoints(score progress.CheckScore) int {
+	if score.Passed {
+		return score.Points
+	}
+	return 0
+}
@@ -3,9 +3,11 @@ package cli
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"gymctl/internal/backup"
 	"gymctl/internal/environment"
 	"gymctl/internal/progress"
 	"gymctl/internal/scenario"
@@ -27,82 +29,104 @@ func newStopCmd() *cobra.Command {
 				}
 				name = current
 			}
+			return runStop(cmd, name)
+		},
+	}
 
-			entries, err := scenario.LoadCatalog(tasksDir)
-			if err != nil {
-				return err
-			}
-			entry, found := scenario.FindByName(entries, name)
-			if !found {
-				return fmt.Errorf("exercise not found: %s", name)
-			}
-			exercise := entry.Exercise
+	return cmd
+}
 
-			ctx := cmd.Context()
-			if ctx == nil {
-				ctx = context.Background()
-			}
+// runStop tears down the exercise environment and closes its session.
+func runStop(cmd *cobra.Command, name string) error {
+	entries, err := scenario.LoadCatalog(tasksDir)
+	if err != nil {
+		return err
+	}
+	entry, found := scenario.FindByName(entries, name)
+	if !found {
+		return fmt.Errorf("exercise not found: %s", name)
+	}
+	exercise := entry.Exercise
 
-			// Clean up resources based on environment type
-			switch exercise.Spec.Environment.Type {
-			case "kubernetes":
-				if exercise.Spec.Environment.Kubernetes == nil {
-					return fmt.Errorf("missing kubernetes environment config")
-				}
-				k8s := exercise.Spec.Environment.Kubernetes
-				createCluster := true
-				if k8s.CreateCluster != nil {
-					createCluster = *k8s.CreateCluster
-				}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
-				if createCluster {
-					manager := environment.KindManager{ClusterName: "jerry-gym"}
-					fmt.Fprintln(cmd.OutOrStdout(), "Stopping kind cluster...")
-					if err := manager.Delete(ctx); err != nil {
-						fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to delete cluster: %v\n", err)
-					}
-				}
+	// Clean up resources based on environment type
+	env := exercise.Spec.Environment
+	if !env.UsesKubernetes() && !env.UsesDocker() {
+		return fmt.Errorf("unsupported environment type: %s", env.Type)
+	}
+	if env.UsesKubernetes() && env.Kubernetes == nil {
+		return fmt.Errorf("missing kubernetes environment config")
+	}
+	if env.UsesDocker() && env.Docker == nil {
+		return fmt.Errorf("missing docker environment config")
+	}
 
-			case "docker":
-				if exercise.Spec.Environment.Docker == nil {
-					return fmt.Errorf("missing docker environment config")
-				}
-				workDir, err := resolveWorkDir(exercise.Metadata.Name)
-				if err != nil {
-					return err
-				}
-				manager := environment.DockerManager{WorkDir: workDir}
-				fmt.Fprintln(cmd.OutOrStdout(), "Stopping docker containers...")
-				if err := manager.Teardown(ctx, entry.Dir, *exercise.Spec.Environment.Docker); err != nil {
-					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to teardown docker: %v\n", err)
-				}
+	names, err := exerciseEnvironment(exercise)
+	if err != nil {
+		return err
+	}
 
-			default:
-				return fmt.Errorf("unsupported environment type: %s", exercise.Spec.Environment.Type)
-			}
+	if env.UsesDocker() {
+		workDir, err := resolveWorkDir(exercise.Metadata.Name)
+		if err != nil {
+			return err
+		}
+		// Teardown removes the work directory along with the containers.
+		if err := snapshotBeforeWipe(cmd, exercise.Metadata.Name, backup.ReasonStop); err != nil {
+			return err
+		}
+		manager := environment.DockerManager{WorkDir: workDir, Project: names.ComposeProject}
+		fmt.Fprintln(cmd.OutOrStdout(), "Stopping docker containers...")
+		if err := manager.Teardown(ctx, entry.Dir, *env.Docker); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to teardown docker: %v\n", err)
+		}
+		if err := environment.RemoveLabeled(ctx, exercise.Metadata.Name); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to remove labelled docker resources: %v\n", err)
+		}
+	}
 
-			// Mark exercise as stopped
-			if err := markStopped(exercise); err != nil {
-				return err
-			}
+	shared, err := forgetEnvironment(exercise.Metadata.Name)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to update gym state: %v\n", err)
+	}
+	if names.Cluster != "" && !shared {
+		manager := environment.KindManager{ClusterName: names.Cluster}
+		fmt.Fprintf(cmd.OutOrStdout(), "Stopping kind cluster %s...\n", names.Cluster)
+		if err := manager.Delete(ctx); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to delete cluster: %v\n", err)
+		}
+	} else if env.UsesKubernetes() {
+		if names.Cluster != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Keeping kind cluster %s, another exercise uses it.\n", names.Cluster)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "Deleting the exercise's kubernetes resources...")
+		if err := deleteLabeledObjects(ctx, entry, names); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to delete kubernetes resources: %v\n", err)
+		}
+	}
 
-			// Get work directory for display
-			workDir, _ := resolveWorkDir(exercise.Metadata.Name)
+	// Mark exercise as stopped
+	if err := markStopped(exercise); err != nil {
+		return err
+	}
 
-			fmt.Fprintln(cmd.OutOrStdout(), "")
-			fmt.Fprintf(cmd.OutOrStdout(), "Exercise '%s' stopped.\n", exercise.Metadata.Name)
-			fmt.Fprintln(cmd.OutOrStdout(), "Resources have been cleaned up.")
-			if workDir != "" {
-				fmt.Fprintln(cmd.OutOrStdout(), "")
-				fmt.Fprintf(cmd.OutOrStdout(), "Your work is preserved in: %s\n", workDir)
-				fmt.Fprintln(cmd.OutOrStdout(), "Use 'gymctl start' to resume or 'gymctl reset' to start fresh.")
-			}
+	// Get work directory for display
+	workDir, _ := resolveWorkDir(exercise.Metadata.Name)
 
-			return nil
-		},
+	fmt.Fprintln(cmd.OutOrStdout(), "")
+	fmt.Fprintf(cmd.OutOrStdout(), "Exercise '%s' stopped.\n", exercise.Metadata.Name)
+	fmt.Fprintln(cmd.OutOrStdout(), "Resources have been cleaned up.")
+	if workDir != "" {
+		fmt.Fprintln(cmd.OutOrStdout(), "")
+		fmt.Fprintf(cmd.OutOrStdout(), "Your work is preserved in: %s\n", workDir)
+		fmt.Fprintln(cmd.OutOrStdout(), "Use 'gymctl start' to resume or 'gymctl reset' to start fresh.")
 	}
 
-	return cmd
+	return nil
 }
 
 func markStopped(exercise *scenario.Exercise) error {
@@ -111,15 +135,14 @@ func markStopped(exercise *scenario.Exercise) error {
 		return err
 	}
 
-	progressFile, err := progress.Load(path)
-	if err != nil {
-		return err
-	}
-
-	entry := progressFile.Exercises[exercise.Metadata.Name]
-	entry.Status = "stopped"
-	// Keep the StartedAt time to track when it was started
-	progressFile.Exercises[exercise.Metadata.Name] = entry
-
-	return progress.Save(path, progressFile)
-}
\ No newline at end of file
+	return progress.Update(path, func(progressFile *progress.File) error {
+		entry := progressFile.Exercises[exercise.Metadata.Name]
+		if entry.Status != "completed" {
+			entry.Status = "stopped"
+		}
+		// Keep the StartedAt time to track when it was started
+		entry.EndSession(time.Now())
+		progressFile.Exercises[exercise.Metadata.Name] = entry
+		return nil
+	})
+}
@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gymctl/internal/checks"
+	"gymctl/internal/environment"
+	"gymctl/internal/scenario"
+	"gymctl/internal/state"
+)
+
+type testOptions struct {
+	track     string
+	noCluster bool
+	keep      bool
+	verbose   bool
+	settle    time.Duration
+}
+
+// errNoSolution marks exercises that ship without a solution/ directory.
+var errNoSolution = errors.New("no solution/ directory")
+
+type exerciseTestResult struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
+func newTestCmd() *cobra.Command {
+	opts := &testOptions{}
+	cmd := &cobra.Command{
+		Use:   "test [exercise-name...]",
+		Short: "Verify exercises against their shipped solutions",
+		Long: `Test is a harness for exercise authors. For each exercise it provisions the
+environment in a scratch work directory, asserts that the checks fail on the
+broken setup, applies the files in solution/ (copied into the work directory,
+and applied with kubectl for Kubernetes exercises), asserts that every check
+passes and tears the environment down again.
+
+Clusters and compose projects are named with a "<prefix>-test" prefix, so
+they never collide with a started exercise. Without arguments the whole
+catalog is tested. Progress and the state of started exercises are not
+touched.`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer RecoverFromPanic(cmd, &err)
+			cmd.SilenceUsage = true
+
+			entries, err := scenario.LoadCatalog(tasksDir)
+			if err != nil {
+				return err
+			}
+			selected, err := selectTestEntries(entries, args, opts.track)
+			if err != nil {
+				return err
+			}
+			if len(selected) == 0 {
+				ColorWarning.Fprintln(cmd.OutOrStdout(), "No exercises found.")
+				return nil
+			}
+
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			var results []exerciseTestResult
+			for i := range selected {
+				entry := &selected[i]
+				ColorBold.Fprintf(cmd.OutOrStdout(), "\n▶ %s\n", entry.Exercise.Metadata.Name)
+				started := time.Now()
+				err := testExercise(ctx, cmd, entry, opts)
+				results = append(results, exerciseTestResult{
+					name:     entry.Exercise.Metadata.Name,
+					err:      err,
+					duration: time.Since(started),
+				})
+			}
+
+			return printTestSummary(cmd, results)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.track, "track", "", "Only test exercises in this track")
+	cmd.Flags().BoolVar(&opts.noCluster, "no-cluster", false, "Use the current cluster instead of creating kind clusters")
+	cmd.Flags().BoolVar(&opts.keep, "keep", false, "Leave the environment running after each exercise")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "Show check details for failures")
+	cmd.Flags().DurationVar(&opts.settle, "settle", time.Minute, "How long to wait for checks to pass after applying the solution")
+
+	return cmd
+}
+
+func selectTestEntries(entries []scenario.CatalogEntry, names []string, track string) ([]scenario.CatalogEntry, error) {
+	var selected []scenario.CatalogEntry
+	if len(names) > 0 {
+		for _, name := range names {
+			entry, found := scenario.FindByName(entries, name)
+			if !found {
+				return nil, fmt.Errorf("exercise not found: %s", name)
+			}
+			selected = append(selected, *entry)
+		}
+	} else {
+		for _, entry := range entries {
+			if track == "" || entry.Exercise.Metadata.Track == track {
+				selected = append(selected, entry)
+			}
+		}
+	}
+	scenario.SortCatalog(selected)
+	return selected, nil
+}
+
+// testExercise runs the broken-then-solved cycle for one exercise.
+func testExercise(ctx context.Context, cmd *cobra.Command, entry *scenario.CatalogEntry, opts *testOptions) (err error) {
+	exercise := entry.Exercise
+	solutionDir := filepath.Join(entry.Dir, "solution")
+	if info, statErr := os.Stat(solutionDir); statErr != nil || !info.IsDir() {
+		ColorDim.Fprintf(cmd.OutOrStdout(), "  skipped: %v\n", errNoSolution)
+		return errNoSolution
+	}
+
+	env, err := harnessEnvironment(exercise, opts.noCluster)
+	if err != nil {
+		return err
+	}
+	env.Session = environment.NewSessionID()
+	workDir, err := os.MkdirTemp("", "gymctl-test-"+exercise.Metadata.Name+"-")
+	if err != nil {
+		return fmt.Errorf("create work directory: %w", err)
+	}
+	if !opts.keep {
+		defer teardownTestEnvironment(ctx, entry, env, workDir)
+	} else {
+		defer ColorDim.Fprintf(cmd.OutOrStdout(), "  environment kept, work directory: %s\n", workDir)
+	}
+
+	// The harness environment is not recorded in the state file: it
+	// belongs to no started exercise and is torn down right after.
+	if err := setupEnvironment(ctx, entry, exercise, workDir, env, harnessLabels(exercise, env), true); err != nil {
+		return fmt.Errorf("provision: %w", err)
+	}
+	ctx = withExerciseCluster(ctx, env)
+	if _, err := copyExerciseFiles(entry, exercise, workDir); err != nil {
+		return err
+	}
+	if err := runCustomSetup(ctx, entry.Dir, workDir, exercise.Spec.Environment.CustomSetup); err != nil {
+		return fmt.Errorf("custom setup: %w", err)
+	}
+
+	var results []checks.Result
+	err = WithSpinner("Checks fail on the broken setup", func() error {
+		var allPassed bool
+		results, allPassed = checks.RunExerciseChecks(ctx, exercise, workDir)
+		if allPassed {
+			return fmt.Errorf("all %d checks pass before the solution is applied", len(results))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = WithSpinner("Applying solution", func() error {
+		return applySolution(ctx, exercise, solutionDir, workDir)
+	})
+	if err != nil {
+		return fmt.Errorf("apply solution: %w", err)
+	}
+
+	err = WithSpinner("Checks pass with the solution", func() error {
+		results = settleChecks(ctx, exercise, workDir, opts.settle)
+		if failed := failedChecks(results); len(failed) > 0 {
+			return fmt.Errorf("%d/%d checks fail with the solution applied: %s", len(failed), len(results), strings.Join(failed, ", "))
+		}
+		return nil
+	})
+	if err != nil && opts.verbose {
+		for _, result := range results {
+			if !result.Passed {
+				fmt.Fprintln(cmd.OutOrStdout(), "  "+FormatCheckResult(result.Name, false, result.Message))
+			}
+		}
+	}
+	return err
+}
+
+// applySolution copies the solution files over the work directory and, for
+// exercises with a cluster, applies the solution manifests.
+func applySolution(ctx context.Context, exercise *scenario.Exercise, solutionDir, workDir string) error {
+	env := exercise.Spec.Environment
+	if env.UsesDocker() {
+		if err := copyDir(solutionDir, workDir); err != nil {
+			return err
+		}
+	}
+	if !env.UsesKubernetes() || env.Kubernetes == nil {
+		return nil
+	}
+
+	var manifests []string
+	err := filepath.WalkDir(solutionDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(path); !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			manifests = append(manifests, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(manifests)
+
+	// The solution stands in for the learner's work, so it is not labelled
+	// as provisioned by gymctl.
+	return environment.ApplyManifests(ctx, exerciseNamespace(env.Kubernetes), manifests, nil)
+}
+
+// settleChecks reruns the checks until they all pass or the settle window
+// closes, giving rollouts triggered by the solution time to finish.
+func settleChecks(ctx context.Context, exercise *scenario.Exercise, workDir string, settle time.Duration) []checks.Result {
+	deadline := time.Now().Add(settle)
+	for {
+		results, allPassed := checks.RunExerciseChecks(ctx, exercise, workDir)
+		if allPassed || time.Now().Add(5*time.Second).After(deadline) {
+			return results
+		}
+		select {
+		case <-ctx.Done():
+			return results
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func failedChecks(results []checks.Result) []string {
+	var failed []string
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Name)
+		}
+	}
+	return failed
+}
+
+// teardownTestEnvironment removes what the harness provisioned in env:
+// its compose project and labelled containers, and its kind cluster or,
+// with --no-cluster, its labelled objects.
+func teardownTestEnvironment(ctx context.Context, entry *scenario.CatalogEntry, env state.Environment, workDir string) {
+	exercise := entry.Exercise
+	label := harnessLabels(exercise, env).Exercise
+	spec := exercise.Spec.Environment
+	if spec.UsesDocker() && spec.Docker != nil {
+		manager := environment.DockerManager{WorkDir: workDir, Project: env.ComposeProject}
+		_ = manager.Teardown(ctx, entry.Dir, *spec.Docker)
+		_ = environment.RemoveLabeled(ctx, label)
+	}
+	if env.Cluster != "" {
+		manager := environment.KindManager{ClusterName: env.Cluster}
+		_ = manager.Delete(ctx)
+	} else if spec.UsesKubernetes() && spec.Kubernetes != nil {
+		manifests := environment.ResolveManifestPaths(entry.Dir, spec.Kubernetes.SetupManifests)
+		_ = environment.DeleteLabeled(ctx, label, manifests)
+	}
+	_ = os.RemoveAll(workDir)
+}
+
+func printTestSummary(cmd *cobra.Command, results []exerciseTestResult) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out)
+	ColorHeader.Fprintln(out, "Summary")
+	ColorDim.Fprintln(out, strings.Repeat("─", 60))
+
+	passed, failed, skipped := 0, 0, 0
+	for _, result := range results {
+		duration := ColorDim.Sprintf("(%s)", result.duration.Round(time.Second))
+		switch {
+		case result.err == nil:
+			passed++
+			fmt.Fprintf(out, "%s %-40s %s\n", ColorSuccess.Sprint(IconSuccess), result.name, duration)
+		case errors.Is(result.err, errNoSolution):
+			skipped++
+			fmt.Fprintf(out, "%s %-40s %s\n", ColorDim.Sprint(IconPending), result.name, ColorDim.Sprint(result.err))
+		default:
+			failed++
+			fmt.Fprintf(out, "%s %-40s %s\n", ColorError.Sprint(IconFail), result.name, duration)
+			ColorDim.Fprintf(out, "    %v\n", result.err)
+		}
+	}
+
+	ColorDim.Fprintln(out, strings.Repeat("─", 60))
+	fmt.Fprintf(out, "%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	if failed > 0 {
+		return &ExitError{Code: ExitCodeChecksFailed, Err: fmt.Errorf("%d of %d exercises failed", failed, len(results))}
+	}
+	return nil
+}
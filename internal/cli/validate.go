@@ -10,15 +10,30 @@ import (
 
 func newValidateCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "validate <task.yaml>",
-		Short: "Validate an exercise definition",
-		Args:  cobra.ExactArgs(1),
+		Use:   "validate [task.yaml]",
+		Short: "Validate an exercise definition or the whole catalog",
+		Long: `Validate checks exercise definitions against the schema and verifies that
+prerequisites refer to known exercises without forming cycles.
+
+Without arguments every exercise in the tasks directory is validated.`,
+		Args: cobra.RangeArgs(0, 1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return validateCatalog(cmd)
+			}
+
 			exercise, err := scenario.LoadExerciseFile(args[0])
 			if err != nil {
 				return err
 			}
 
+			entries, err := scenario.LoadCatalog(tasksDir)
+			if err != nil {
+				ColorWarning.Fprintf(cmd.ErrOrStderr(), "%s Skipping prerequisite validation: %v\n", IconWarning, err)
+			} else if err := validateExercisePrerequisites(cmd, exercise, args[0], entries); err != nil {
+				return err
+			}
+
 			fmt.Fprintf(cmd.OutOrStdout(), "OK: %s (%s)\n", exercise.Metadata.Name, exercise.Metadata.Title)
 			return nil
 		},
@@ -26,3 +41,47 @@ func newValidateCmd() *cobra.Command {
 
 	return cmd
 }
+
+// validateExercisePrerequisites checks a single exercise against the catalog,
+// reporting only the issues it takes part in.
+func validateExercisePrerequisites(cmd *cobra.Command, exercise *scenario.Exercise, path string, entries []scenario.CatalogEntry) error {
+	graph := []scenario.CatalogEntry{{Exercise: exercise, Path: path}}
+	for _, entry := range entries {
+		if entry.Exercise.Metadata.Name != exercise.Metadata.Name {
+			graph = append(graph, entry)
+		}
+	}
+
+	var issues []scenario.PrerequisiteIssue
+	for _, issue := range scenario.ValidatePrerequisites(graph) {
+		if issue.Involves(exercise.Metadata.Name) {
+			issues = append(issues, issue)
+		}
+	}
+	return reportPrerequisiteIssues(cmd, issues)
+}
+
+func validateCatalog(cmd *cobra.Command) error {
+	entries, err := scenario.LoadCatalog(tasksDir)
+	if err != nil {
+		return err
+	}
+	if err := reportPrerequisiteIssues(cmd, scenario.ValidatePrerequisites(entries)); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		fmt.Fprintf(cmd.OutOrStdout(), "OK: %s (%s)\n", entry.Exercise.Metadata.Name, entry.Exercise.Metadata.Title)
+	}
+	return nil
+}
+
+func reportPrerequisiteIssues(cmd *cobra.Command, issues []scenario.PrerequisiteIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	for _, issue := range issues {
+		ColorError.Fprintf(cmd.OutOrStdout(), "%s %s\n", IconFail, issue.Error())
+	}
+	return fmt.Errorf("validation failed: %d prerequisite issue(s)", len(issues))
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"os"
+	"os/user"
+
+	"gymctl/internal/progress"
+	"gymctl/internal/scenario"
+)
+
+// chooseVariant decides which variant a start uses. Resuming an attempt that
+// is still in progress keeps its recorded variant; anything else goes through
+// the exercise's selection policy unless a variant is pinned.
+func chooseVariant(exercise *scenario.Exercise, pinned string) (string, error) {
+	path, err := resolveProgressFile()
+	if err != nil {
+		return "", err
+	}
+	progressFile, err := progress.Load(path)
+	if err != nil {
+		return "", err
+	}
+
+	status := progressFile.Exercises[exercise.Metadata.Name]
+	if pinned == "" && status.Variant != "" && (status.Status == "in_progress" || status.Status == "stopped") {
+		if _, ok := exercise.Spec.FindVariant(status.Variant); ok {
+			return status.Variant, nil
+		}
+	}
+
+	variant, err := scenario.SelectVariant(exercise.Spec, pinned, status.Variant, currentUser())
+	if err != nil || variant == nil {
+		return "", err
+	}
+	return variant.Name, nil
+}
+
+// withRecordedVariant applies the variant recorded in progress for the
+// exercise so that check and reset see the same setup as start did.
+func withRecordedVariant(exercise *scenario.Exercise) (*scenario.Exercise, error) {
+	variant := recordedVariant(exercise.Metadata.Name)
+	if _, ok := exercise.Spec.FindVariant(variant); !ok {
+		return exercise, nil
+	}
+	return exercise.WithVariant(variant)
+}
+
+// recordedVariant returns the variant recorded in progress for the exercise,
+// or "" when there is none or progress cannot be read.
+func recordedVariant(name string) string {
+	path, err := resolveProgressFile()
+	if err != nil {
+		return ""
+	}
+	progressFile, err := progress.Load(path)
+	if err != nil {
+		return ""
+	}
+	return progressFile.Exercises[name].Variant
+}
+
+func currentUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
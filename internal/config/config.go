@@ -0,0 +1,167 @@
+// Package config reads the user's gymctl settings from ~/.gym/config.yaml.
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultPrefix names clusters and compose projects when none is set.
+const DefaultPrefix = "jerry-gym"
+
+const (
+	// ScopeExercise gives every exercise its own cluster and project.
+	ScopeExercise = "exercise"
+	// ScopeShared reuses one cluster and project named after the prefix.
+	ScopeShared = "shared"
+)
+
+// DefaultBackupKeep is how many snapshots of each exercise are kept when
+// the config does not say.
+const DefaultBackupKeep = 5
+
+// maxNameLength keeps kind node container names ("<name>-control-plane")
+// and their hostnames within the 63 character DNS label limit.
+const maxNameLength = 40
+
+// Config holds user settings. Every field is optional.
+type Config struct {
+	// Prefix starts every cluster and compose project name. Setting it per
+	// user keeps people sharing a Docker daemon apart.
+	Prefix string `json:"prefix,omitempty"`
+	// ClusterScope is "exercise" (the default) or "shared".
+	ClusterScope string `json:"clusterScope,omitempty"`
+	// ReuseClusters keeps a running kind cluster across start and reset
+	// when its configuration still fits, wiping only the exercise's
+	// resources. It defaults to true.
+	ReuseClusters *bool `json:"reuseClusters,omitempty"`
+	// Backups is the retention policy for work directory snapshots.
+	Backups BackupRetention `json:"backups,omitempty"`
+}
+
+// BackupRetention limits how many snapshots are kept per exercise.
+type BackupRetention struct {
+	// Keep is the number of snapshots kept per exercise; 0 means the
+	// default and a negative value keeps all of them.
+	Keep int `json:"keep,omitempty"`
+	// MaxAge drops older snapshots, as a Go duration or a number of days
+	// such as "30d". The newest snapshot is kept regardless.
+	MaxAge string `json:"maxAge,omitempty"`
+}
+
+// KeepCount returns how many snapshots to keep per exercise, 0 for all.
+func (r BackupRetention) KeepCount() int {
+	switch {
+	case r.Keep == 0:
+		return DefaultBackupKeep
+	case r.Keep < 0:
+		return 0
+	}
+	return r.Keep
+}
+
+// MaxAgeDuration returns the parsed MaxAge, 0 when unset.
+func (r BackupRetention) MaxAgeDuration() (time.Duration, error) {
+	if r.MaxAge == "" {
+		return 0, nil
+	}
+	return ParseAge(r.MaxAge)
+}
+
+// ParseAge parses a Go duration, also accepting whole days such as "7d".
+func ParseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+	return d, nil
+}
+
+// Load reads the config file, returning defaults when it does not exist.
+// GYMCTL_PREFIX overrides the prefix from the file.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+	if prefix := os.Getenv("GYMCTL_PREFIX"); prefix != "" {
+		cfg.Prefix = prefix
+	}
+
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if cfg.ClusterScope == "" {
+		cfg.ClusterScope = ScopeExercise
+	}
+	if cfg.ClusterScope != ScopeExercise && cfg.ClusterScope != ScopeShared {
+		return nil, fmt.Errorf("config %s: clusterScope must be %q or %q, got %q", path, ScopeExercise, ScopeShared, cfg.ClusterScope)
+	}
+	if _, err := cfg.Backups.MaxAgeDuration(); err != nil {
+		return nil, fmt.Errorf("config %s: backups.maxAge: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ReusesClusters reports whether warm clusters should be reused.
+func (c *Config) ReusesClusters() bool {
+	return c.ReuseClusters == nil || *c.ReuseClusters
+}
+
+// ClusterName returns the kind cluster name for an exercise.
+func (c *Config) ClusterName(exercise string) string {
+	return c.name(exercise)
+}
+
+// ComposeProject returns the compose project name for an exercise.
+func (c *Config) ComposeProject(exercise string) string {
+	return c.name(exercise)
+}
+
+func (c *Config) name(exercise string) string {
+	if c.ClusterScope == ScopeShared || exercise == "" {
+		return Sanitize(c.Prefix)
+	}
+	return Sanitize(c.Prefix + "-" + exercise)
+}
+
+// Sanitize turns value into a name kind and compose accept: lower case
+// letters, digits and dashes, at most 40 characters. Long names are
+// shortened with a hash so they stay unique.
+func Sanitize(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if len(name) <= maxNameLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	return strings.TrimRight(name[:maxNameLength-len(suffix)-1], "-") + "-" + suffix
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("GYMCTL_PREFIX", "")
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.ClusterName("01-jerry-forgot-resources"); got != "jerry-gym-01-jerry-forgot-resources" {
+		t.Errorf("ClusterName = %q", got)
+	}
+	if got := cfg.ComposeProject("05-jerry-lost-connection"); got != "jerry-gym-05-jerry-lost-connection" {
+		t.Errorf("ComposeProject = %q", got)
+	}
+	if !cfg.ReusesClusters() {
+		t.Error("cluster reuse should default to on")
+	}
+}
+
+func TestLoadFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("prefix: Alice\nclusterScope: shared\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GYMCTL_PREFIX", "")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.ClusterName("01-anything"); got != "alice" {
+		t.Errorf("shared ClusterName = %q, want alice", got)
+	}
+
+	t.Setenv("GYMCTL_PREFIX", "bob")
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.ClusterName("01-anything"); got != "bob" {
+		t.Errorf("ClusterName with GYMCTL_PREFIX = %q, want bob", got)
+	}
+
+	if err := os.WriteFile(path, []byte("clusterScope: galaxy\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for an unknown clusterScope")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"jerry-gym":         "jerry-gym",
+		"Jerry_Gym 02.Pods": "jerry-gym-02-pods",
+		"--edge--":          "edge",
+	}
+	for input, want := range tests {
+		if got := Sanitize(input); got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	long := Sanitize("jerry-gym-" + strings.Repeat("very-long-exercise-name-", 4))
+	if len(long) > maxNameLength {
+		t.Errorf("Sanitize kept %d characters: %q", len(long), long)
+	}
+	other := Sanitize("jerry-gym-" + strings.Repeat("very-long-exercise-name-", 5))
+	if long == other {
+		t.Errorf("long names collide: %q", long)
+	}
+}
+
+func TestBackupRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("GYMCTL_PREFIX", "")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Backups.KeepCount(); got != DefaultBackupKeep {
+		t.Errorf("default KeepCount = %d, want %d", got, DefaultBackupKeep)
+	}
+
+	if err := os.WriteFile(path, []byte("backups:\n  keep: -1\n  maxAge: 14d\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Backups.KeepCount(); got != 0 {
+		t.Errorf("KeepCount = %d, want 0 (keep all)", got)
+	}
+	if age, err := cfg.Backups.MaxAgeDuration(); err != nil || age != 14*24*time.Hour {
+		t.Errorf("MaxAgeDuration = %v, %v", age, err)
+	}
+
+	if err := os.WriteFile(path, []byte("backups:\n  maxAge: fortnight\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for an invalid backups.maxAge")
+	}
+}
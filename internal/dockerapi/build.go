@@ -0,0 +1,169 @@
+package dockerapi
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildOptions configures ImageBuild.
+type BuildOptions struct {
+	Tag    string
+	Labels map[string]string
+	// Output receives the build log when set.
+	Output io.Writer
+}
+
+// ImageBuild builds the Dockerfile in contextDir, sending the directory as
+// the build context minus the entries excluded by .dockerignore.
+func (c *Client) ImageBuild(ctx context.Context, contextDir string, opts BuildOptions) error {
+	ignore, err := readDockerignore(contextDir)
+	if err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeContext(writer, contextDir, ignore))
+	}()
+	defer reader.Close()
+
+	query := url.Values{}
+	if opts.Tag != "" {
+		query.Set("t", opts.Tag)
+	}
+	if len(opts.Labels) > 0 {
+		data, _ := json.Marshal(opts.Labels)
+		query.Set("labels", string(data))
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/build", query, reader, "application/x-tar")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readProgress(resp.Body, opts.Output)
+}
+
+func writeContext(w io.Writer, dir string, ignore []ignorePattern) error {
+	archive := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if excluded(ignore, rel) && rel != "Dockerfile" {
+			if info.IsDir() && !hasNegation(ignore) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+type ignorePattern struct {
+	pattern string
+	negate  bool
+}
+
+func readDockerignore(dir string) ([]ignorePattern, error) {
+	file, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = strings.TrimSpace(line[1:])
+		}
+		p.pattern = strings.Trim(filepath.ToSlash(filepath.Clean(line)), "/")
+		patterns = append(patterns, p)
+	}
+	return patterns, scanner.Err()
+}
+
+// excluded applies .dockerignore rules: the last matching pattern wins and
+// a pattern matching a directory excludes everything below it.
+func excluded(patterns []ignorePattern, rel string) bool {
+	result := false
+	for _, p := range patterns {
+		if matchesIgnore(p.pattern, rel) {
+			result = !p.negate
+		}
+	}
+	return result
+}
+
+func matchesIgnore(pattern, rel string) bool {
+	for path := rel; path != "." && path != ""; path = filepath.ToSlash(filepath.Dir(path)) {
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+		if strings.HasPrefix(pattern, "**/") {
+			if matched, _ := filepath.Match(strings.TrimPrefix(pattern, "**/"), filepath.Base(path)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasNegation(patterns []ignorePattern) bool {
+	for _, p := range patterns {
+		if p.negate {
+			return true
+		}
+	}
+	return false
+}
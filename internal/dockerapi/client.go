@@ -0,0 +1,358 @@
+// Package dockerapi is a small Docker Engine API client. It talks to the
+// daemon over its unix socket (or DOCKER_HOST, or the current docker
+// context) so callers get structured inspect data and exact byte sizes
+// instead of parsing docker CLI output.
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The Engine API version is negotiated on the first ping: the daemon's own
+// version, capped at MaxAPIVersion, the newest one this client was written
+// against. Recent daemons refuse versions below 1.44, so no version can be
+// fixed up front. FallbackAPIVersion, served by Docker 20.10 and later, is
+// used when the daemon does not say.
+const (
+	MaxAPIVersion      = "1.47"
+	FallbackAPIVersion = "1.41"
+)
+
+// DefaultHost is used when neither DOCKER_HOST nor a docker context is set.
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// ErrUnsupportedHost is returned for DOCKER_HOST schemes the client cannot
+// dial, such as ssh://. Callers fall back to the docker CLI.
+var ErrUnsupportedHost = errors.New("unsupported DOCKER_HOST")
+
+// Client talks to one Docker daemon.
+type Client struct {
+	// Host is the daemon address as in DOCKER_HOST.
+	Host string
+	HTTP *http.Client
+	base string
+
+	mu      sync.Mutex
+	version string
+}
+
+// NewClient returns a client for a DOCKER_HOST style address:
+// unix:///path/to/socket or tcp://host:port.
+func NewClient(host string) (*Client, error) {
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrUnsupportedHost, host, err)
+	}
+	client := &Client{Host: host}
+	switch parsed.Scheme {
+	case "unix":
+		socket := parsed.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		client.HTTP = &http.Client{Transport: transport}
+		client.base = "http://docker"
+	case "tcp", "http":
+		client.HTTP = &http.Client{}
+		client.base = "http://" + parsed.Host
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedHost, host)
+	}
+	return client, nil
+}
+
+// FromEnv returns a client for $DOCKER_HOST, or the endpoint of the current
+// docker context, or the default socket. TLS-protected daemons
+// (DOCKER_TLS_VERIFY) are left to the CLI.
+func FromEnv() (*Client, error) {
+	host, err := resolveHost()
+	if err != nil {
+		return nil, err
+	}
+	if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+		return nil, fmt.Errorf("%w %q: TLS is not supported", ErrUnsupportedHost, host)
+	}
+	return NewClient(host)
+}
+
+// Error is a failed API call, carrying the daemon's message.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// IsNotFound reports whether err is a 404 from the daemon, e.g. "No such
+// image" or "No such container".
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsConflict reports whether err is a 409, e.g. a container name in use.
+func IsConflict(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// DaemonError reports that the daemon could not be reached at all.
+type DaemonError struct {
+	Host string
+	Err  error
+}
+
+func (e *DaemonError) Error() string {
+	return fmt.Sprintf("cannot connect to the Docker daemon at %s: %v", e.Host, e.Err)
+}
+
+func (e *DaemonError) Unwrap() error {
+	return e.Err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	version, err := c.apiVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, method, "/v"+version+path, query, body, contentType)
+}
+
+// send makes one request to the daemon and turns error responses into
+// *Error.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &DaemonError{Host: c.Host, Err: err}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var message struct {
+		Message string `json:"message"`
+	}
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	if json.Unmarshal(data, &message) == nil && message.Message != "" {
+		apiErr.Message = message.Message
+	}
+	return nil, apiErr
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, into interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+func (c *Client) postJSON(ctx context.Context, path string, query url.Values, payload, into interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	resp, err := c.do(ctx, http.MethodPost, path, query, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if into == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
+
+func (c *Client) delete(ctx context.Context, path string, query url.Values) error {
+	resp, err := c.do(ctx, http.MethodDelete, path, query, nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Ping checks that the daemon answers and settles the API version the
+// client uses from then on.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.send(ctx, http.MethodGet, "/_ping", nil, nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	c.mu.Lock()
+	c.version = negotiateVersion(resp.Header.Get("API-Version"))
+	c.mu.Unlock()
+	return nil
+}
+
+// apiVersion returns the negotiated API version, pinging the daemon first
+// if that has not happened yet.
+func (c *Client) apiVersion(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	version := c.version
+	c.mu.Unlock()
+	if version != "" {
+		return version, nil
+	}
+	if err := c.Ping(ctx); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version, nil
+}
+
+// negotiateVersion picks the API version to use with a daemon that serves
+// server: the lower of it and MaxAPIVersion.
+func negotiateVersion(server string) string {
+	if _, _, ok := parseVersion(server); !ok {
+		return FallbackAPIVersion
+	}
+	if compareVersions(server, MaxAPIVersion) < 0 {
+		return server
+	}
+	return MaxAPIVersion
+}
+
+// compareVersions orders two "major.minor" API versions.
+func compareVersions(a, b string) int {
+	aMajor, aMinor, _ := parseVersion(a)
+	bMajor, bMinor, _ := parseVersion(b)
+	if aMajor != bMajor {
+		return aMajor - bMajor
+	}
+	return aMinor - bMinor
+}
+
+func parseVersion(version string) (int, int, bool) {
+	majorText, minorText, ok := strings.Cut(version, ".")
+	if !ok {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(majorText)
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(minorText)
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// Filters narrows list calls, e.g. {"label": {"gym.exercise=foo"}}.
+type Filters map[string][]string
+
+func (f Filters) query(values url.Values) url.Values {
+	if values == nil {
+		values = url.Values{}
+	}
+	if len(f) > 0 {
+		data, _ := json.Marshal(f)
+		values.Set("filters", string(data))
+	}
+	return values
+}
+
+type clientKey struct{}
+type cliKey struct{}
+
+// WithClient returns a context whose Docker calls use client.
+func WithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// WithCLI returns a context whose Docker calls go through the docker CLI.
+func WithCLI(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cliKey{}, true)
+}
+
+// ErrCLI is returned by ClientFor when the caller asked for the CLI.
+var ErrCLI = errors.New("docker engine API client disabled")
+
+// pings remembers, per daemon address, how the daemon answered the first
+// ping, so building a client per call does not ping every time.
+var pings sync.Map
+
+type pingResult struct {
+	version string
+	err     error
+}
+
+// ClientFor returns the client carried by ctx, or one for the environment
+// once its daemon has answered a ping. Callers fall back to the docker CLI
+// on any error.
+func ClientFor(ctx context.Context) (*Client, error) {
+	if client, ok := ctx.Value(clientKey{}).(*Client); ok && client != nil {
+		return client, nil
+	}
+	if cli, _ := ctx.Value(cliKey{}).(bool); cli {
+		return nil, ErrCLI
+	}
+	client, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := pings.Load(client.Host); ok {
+		result := cached.(pingResult)
+		if result.err != nil {
+			return nil, result.err
+		}
+		client.version = result.version
+		return client, nil
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	err = client.Ping(pingCtx)
+	if ctx.Err() == nil {
+		pings.Store(client.Host, pingResult{version: client.version, err: err})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// pingTimeout bounds the ping ClientFor sends to a daemon it has not
+// talked to yet.
+const pingTimeout = 3 * time.Second
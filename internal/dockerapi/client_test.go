@@ -0,0 +1,327 @@
+package dockerapi_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/dockerapi/dockertest"
+)
+
+func TestNewClientHosts(t *testing.T) {
+	for _, host := range []string{"unix:///var/run/docker.sock", "tcp://127.0.0.1:2375"} {
+		if _, err := dockerapi.NewClient(host); err != nil {
+			t.Errorf("NewClient(%q): %v", host, err)
+		}
+	}
+	if _, err := dockerapi.NewClient("ssh://jerry@build-box"); !errors.Is(err, dockerapi.ErrUnsupportedHost) {
+		t.Errorf("expected ErrUnsupportedHost for ssh, got %v", err)
+	}
+}
+
+func TestFromEnvHonoursDockerHost(t *testing.T) {
+	server := dockertest.NewServer(t)
+	t.Setenv("DOCKER_HOST", server.Host)
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+
+	client, err := dockerapi.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
+
+func TestFromEnvUsesCurrentDockerContext(t *testing.T) {
+	server := dockertest.NewServer(t)
+	dir := t.TempDir()
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONTEXT", "")
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	// Contexts live under the SHA-256 of their name.
+	meta := filepath.Join(dir, "contexts", "meta", "f24fd3749c1368328e2b149bec149cb6795619f244c5b584e844961215dadd16")
+	writeFile(t, filepath.Join(dir, "config.json"), `{"currentContext": "colima"}`)
+	writeFile(t, filepath.Join(meta, "meta.json"), `{"Name": "colima", "Endpoints": {"docker": {"Host": "`+server.Host+`"}}}`)
+
+	client, err := dockerapi.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Host != server.Host {
+		t.Errorf("Host = %q, want the colima context endpoint %q", client.Host, server.Host)
+	}
+
+	t.Setenv("DOCKER_CONTEXT", "default")
+	if client, err := dockerapi.FromEnv(); err != nil || client.Host != dockerapi.DefaultHost {
+		t.Errorf("default context: %v, %v", client, err)
+	}
+	t.Setenv("DOCKER_CONTEXT", "missing")
+	if _, err := dockerapi.FromEnv(); err == nil {
+		t.Error("expected an error for an unknown context")
+	}
+}
+
+func TestClientForPingsTheDaemon(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix://"+filepath.Join(t.TempDir(), "missing.sock"))
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+	if client, err := dockerapi.ClientFor(context.Background()); err == nil {
+		t.Errorf("ClientFor returned %v for a daemon that is not running", client)
+	}
+
+	server := dockertest.NewServer(t)
+	t.Setenv("DOCKER_HOST", server.Host)
+	if _, err := dockerapi.ClientFor(context.Background()); err != nil {
+		t.Errorf("ClientFor: %v", err)
+	}
+}
+
+func TestClientNegotiatesAPIVersion(t *testing.T) {
+	tests := []struct {
+		name, server, min string
+	}{
+		// Docker 29 and later refuse anything below 1.44.
+		{"newer daemon", "1.52", "1.44"},
+		{"older daemon", "1.43", "1.24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := dockertest.NewServer(t)
+			server.APIVersion, server.MinAPIVersion = tt.server, tt.min
+			server.AddImage("alpine:3.19", dockerapi.Image{Size: 1})
+
+			if _, err := server.Client().ImageInspect(context.Background(), "alpine:3.19"); err != nil {
+				t.Errorf("ImageInspect: %v", err)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDaemonUnavailable(t *testing.T) {
+	client, err := dockerapi.NewClient("unix://" + filepath.Join(t.TempDir(), "missing.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Ping(context.Background())
+	var daemonErr *dockerapi.DaemonError
+	if !errors.As(err, &daemonErr) {
+		t.Errorf("expected a DaemonError, got %v", err)
+	}
+}
+
+func TestImageInspectAndErrors(t *testing.T) {
+	server := dockertest.NewServer(t)
+	image := dockerapi.Image{Size: 73_400_320}
+	image.RootFS.Layers = []string{"sha256:a", "sha256:b"}
+	image.Config.Labels = map[string]string{"maintainer": "jerry"}
+	server.AddImage("jerry/app:1.0", image)
+	client := server.Client()
+	ctx := context.Background()
+
+	got, err := client.ImageInspect(ctx, "jerry/app:1.0")
+	if err != nil {
+		t.Fatalf("ImageInspect: %v", err)
+	}
+	if got.Size != 73_400_320 || len(got.RootFS.Layers) != 2 || got.Config.Labels["maintainer"] != "jerry" {
+		t.Errorf("unexpected image: %+v", got)
+	}
+
+	_, err = client.ImageInspect(ctx, "jerry/missing")
+	if !dockerapi.IsNotFound(err) || !strings.Contains(err.Error(), "No such image") {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestContainerRunPullsAndPublishesPorts(t *testing.T) {
+	server := dockertest.NewServer(t)
+	client := server.Client()
+	ctx := context.Background()
+
+	_, err := client.ContainerRun(ctx, dockerapi.ContainerSpec{
+		Name:  "web",
+		Image: "nginx:1.25",
+		Ports: []string{"8080:80", "127.0.0.1:8443:443"},
+	})
+	if err != nil {
+		t.Fatalf("ContainerRun: %v", err)
+	}
+	if len(server.Pulls) != 1 || server.Pulls[0] != "nginx:1.25" {
+		t.Errorf("pulls = %v, want [nginx:1.25]", server.Pulls)
+	}
+
+	container, err := client.ContainerInspect(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.State.Status != "running" {
+		t.Errorf("state = %q, want running", container.State.Status)
+	}
+	want := "443/tcp -> 127.0.0.1:8443\n80/tcp -> :8080"
+	if got := container.PortSummary(); got != want {
+		t.Errorf("ports = %q, want %q", got, want)
+	}
+
+	_, err = client.ContainerRun(ctx, dockerapi.ContainerSpec{Name: "web", Image: "nginx:1.25"})
+	if !dockerapi.IsConflict(err) {
+		t.Errorf("expected a conflict for a reused name, got %v", err)
+	}
+
+	if err := client.ContainerRemove(ctx, "web"); err != nil {
+		t.Errorf("ContainerRemove: %v", err)
+	}
+	if _, err := client.ContainerInspect(ctx, "web"); !dockerapi.IsNotFound(err) {
+		t.Errorf("expected the container to be gone, got %v", err)
+	}
+}
+
+func TestParsePortSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		port string
+		want dockerapi.PortBinding
+	}{
+		{"80", "80/tcp", dockerapi.PortBinding{}},
+		{"8080:80", "80/tcp", dockerapi.PortBinding{HostPort: "8080"}},
+		{"0.0.0.0:5353:53/udp", "53/udp", dockerapi.PortBinding{HostIP: "0.0.0.0", HostPort: "5353"}},
+	}
+	for _, tt := range tests {
+		port, binding, err := dockerapi.ParsePortSpec(tt.spec)
+		if err != nil || port != tt.port || binding != tt.want {
+			t.Errorf("ParsePortSpec(%q) = %q, %+v, %v", tt.spec, port, binding, err)
+		}
+	}
+	if _, _, err := dockerapi.ParsePortSpec("http:80"); err == nil {
+		t.Error("expected an error for a named port")
+	}
+}
+
+func TestContainerLogsDemultiplexes(t *testing.T) {
+	server := dockertest.NewServer(t)
+	server.AddContainer("api", dockerapi.Container{}, "starting\nlistening on :3000\n")
+	client := server.Client()
+
+	logs, err := client.ContainerLogs(context.Background(), "api", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs != "starting\nlistening on :3000\n" {
+		t.Errorf("logs = %q", logs)
+	}
+}
+
+func TestImageBuildSendsContext(t *testing.T) {
+	server := dockertest.NewServer(t)
+	client := server.Client()
+	dir := t.TempDir()
+	files := map[string]string{
+		"Dockerfile":          "FROM alpine\n",
+		".dockerignore":       "node_modules\n*.log\n!keep.log\n",
+		"app.js":              "console.log('hi')\n",
+		"debug.log":           "noise\n",
+		"keep.log":            "kept\n",
+		"node_modules/x/y.js": "dependency\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := dockerapi.BuildOptions{Tag: "api:latest", Labels: map[string]string{"gym.exercise": "demo"}}
+	if err := client.ImageBuild(context.Background(), dir, opts); err != nil {
+		t.Fatalf("ImageBuild: %v", err)
+	}
+	build := server.Builds[0]
+	got := strings.Join(build.Files, ",")
+	if got != ".dockerignore,Dockerfile,app.js,keep.log" {
+		t.Errorf("context files = %s", got)
+	}
+	if build.Labels["gym.exercise"] != "demo" {
+		t.Errorf("labels = %v", build.Labels)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "Dockerfile")); err != nil {
+		t.Fatal(err)
+	}
+	err := client.ImageBuild(context.Background(), dir, dockerapi.BuildOptions{Tag: "api:latest"})
+	if err == nil || !strings.Contains(err.Error(), "Dockerfile") {
+		t.Errorf("expected the build error to surface, got %v", err)
+	}
+}
+
+func TestVolumeSizes(t *testing.T) {
+	server := dockertest.NewServer(t)
+	server.AddVolume(dockerapi.Volume{Name: "pgdata", Labels: map[string]string{"gym.exercise": "demo"}}, 12_345)
+	server.AddVolume(dockerapi.Volume{Name: "other"}, 1)
+	client := server.Client()
+	ctx := context.Background()
+
+	volumes, err := client.VolumeList(ctx, dockerapi.Filters{"label": {"gym.exercise=demo"}})
+	if err != nil || len(volumes) != 1 || volumes[0].Name != "pgdata" {
+		t.Fatalf("VolumeList = %+v, %v", volumes, err)
+	}
+	sizes, err := client.VolumeSizes(ctx)
+	if err != nil || sizes["pgdata"] != 12_345 {
+		t.Errorf("VolumeSizes = %v, %v", sizes, err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	server := dockertest.NewServer(t)
+	client := server.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan dockerapi.Event, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Events(ctx, dockerapi.Filters{"type": {"container"}}, func(event dockerapi.Event) {
+			events <- event
+		})
+	}()
+
+	// The stream opens asynchronously; keep emitting until it is up.
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-events:
+			if event.Type != "container" || event.Action != "die" || event.Actor.ID != "abc" {
+				t.Errorf("event = %+v", event)
+			}
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("Events: %v", err)
+			}
+			return
+		case <-ticker.C:
+			server.Emit(dockerapi.Event{Type: "image", Action: "tag"})
+			die := dockerapi.Event{Type: "container", Action: "die"}
+			die.Actor.ID = "abc"
+			server.Emit(die)
+		case <-ctx.Done():
+			t.Fatal("no event received")
+		}
+	}
+}
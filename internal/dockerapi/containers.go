@@ -0,0 +1,239 @@
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PortBinding maps a container port to the host.
+type PortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// Container is the subset of `docker inspect` gymctl reads.
+type Container struct {
+	ID      string `json:"Id"`
+	Name    string `json:"Name"`
+	Created string `json:"Created"`
+	State   struct {
+		Status   string `json:"Status"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+		Tty    bool              `json:"Tty"`
+	} `json:"Config"`
+	NetworkSettings struct {
+		Ports map[string][]PortBinding `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+// HealthStatus returns the health check status, or "" without one.
+func (c *Container) HealthStatus() string {
+	if c.State.Health == nil {
+		return ""
+	}
+	return c.State.Health.Status
+}
+
+// PortSummary formats the published ports like `docker port`, one
+// "80/tcp -> 0.0.0.0:8080" line per binding.
+func (c *Container) PortSummary() string {
+	ports := make([]string, 0, len(c.NetworkSettings.Ports))
+	for port := range c.NetworkSettings.Ports {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	var lines []string
+	for _, port := range ports {
+		for _, binding := range c.NetworkSettings.Ports[port] {
+			host := binding.HostIP
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			lines = append(lines, fmt.Sprintf("%s -> %s:%s", port, host, binding.HostPort))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ContainerSummary is one entry of the container list.
+type ContainerSummary struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Created int64             `json:"Created"`
+	Labels  map[string]string `json:"Labels"`
+}
+
+// ContainerSpec describes a container to create, like the flags of
+// `docker run`.
+type ContainerSpec struct {
+	Name   string
+	Image  string
+	Labels map[string]string
+	// Ports are `docker run -p` specs such as "8080:80".
+	Ports []string
+}
+
+// ContainerInspect returns details about a container by name or ID.
+func (c *Client) ContainerInspect(ctx context.Context, name string) (*Container, error) {
+	var container Container
+	if err := c.getJSON(ctx, "/containers/"+url.PathEscape(name)+"/json", nil, &container); err != nil {
+		return nil, err
+	}
+	return &container, nil
+}
+
+// ContainerList lists containers, including stopped ones when all is set.
+func (c *Client) ContainerList(ctx context.Context, all bool, filters Filters) ([]ContainerSummary, error) {
+	query := url.Values{}
+	if all {
+		query.Set("all", "1")
+	}
+	var containers []ContainerSummary
+	if err := c.getJSON(ctx, "/containers/json", filters.query(query), &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ContainerRun creates and starts a container, pulling the image first
+// when the daemon does not have it, and returns the container ID.
+func (c *Client) ContainerRun(ctx context.Context, spec ContainerSpec) (string, error) {
+	exposed := map[string]struct{}{}
+	bindings := map[string][]PortBinding{}
+	for _, port := range spec.Ports {
+		containerPort, binding, err := ParsePortSpec(port)
+		if err != nil {
+			return "", err
+		}
+		exposed[containerPort] = struct{}{}
+		bindings[containerPort] = append(bindings[containerPort], binding)
+	}
+	payload := map[string]interface{}{
+		"Image":        spec.Image,
+		"Labels":       spec.Labels,
+		"ExposedPorts": exposed,
+		"HostConfig":   map[string]interface{}{"PortBindings": bindings},
+	}
+
+	query := url.Values{}
+	if spec.Name != "" {
+		query.Set("name", spec.Name)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := c.postJSON(ctx, "/containers/create", query, payload, &created)
+	if IsNotFound(err) {
+		if pullErr := c.ImagePull(ctx, spec.Image); pullErr != nil {
+			return "", pullErr
+		}
+		err = c.postJSON(ctx, "/containers/create", query, payload, &created)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := c.postJSON(ctx, "/containers/"+created.ID+"/start", nil, nil, nil); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// ParsePortSpec parses a `docker run -p` spec: "80", "8080:80",
+// "127.0.0.1:8080:80", optionally with a "/udp" suffix. It returns the
+// container port key, e.g. "80/tcp", and the host binding.
+func ParsePortSpec(spec string) (string, PortBinding, error) {
+	protocol := "tcp"
+	if base, proto, ok := strings.Cut(spec, "/"); ok {
+		spec, protocol = base, proto
+	}
+	var binding PortBinding
+	var containerPort string
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+		containerPort = parts[0]
+	case 2:
+		binding.HostPort, containerPort = parts[0], parts[1]
+	case 3:
+		binding.HostIP, binding.HostPort, containerPort = parts[0], parts[1], parts[2]
+	default:
+		return "", binding, fmt.Errorf("invalid port spec %q", spec)
+	}
+	if _, err := strconv.Atoi(containerPort); err != nil {
+		return "", binding, fmt.Errorf("invalid port spec %q", spec)
+	}
+	if binding.HostPort != "" {
+		if _, err := strconv.Atoi(binding.HostPort); err != nil {
+			return "", binding, fmt.Errorf("invalid port spec %q", spec)
+		}
+	}
+	return containerPort + "/" + protocol, binding, nil
+}
+
+// ContainerRemove force-removes a container and its anonymous volumes.
+func (c *Client) ContainerRemove(ctx context.Context, name string) error {
+	return c.delete(ctx, "/containers/"+url.PathEscape(name), url.Values{"force": {"1"}, "v": {"1"}})
+}
+
+// ContainerLogs returns stdout and stderr of a container, interleaved as
+// `docker logs` prints them. A non-zero since limits the logs to recent
+// entries.
+func (c *Client) ContainerLogs(ctx context.Context, name string, since time.Duration) (string, error) {
+	container, err := c.ContainerInspect(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if since > 0 {
+		query.Set("since", strconv.FormatInt(time.Now().Add(-since).Unix(), 10))
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(name)+"/logs", query, nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if container.Config.Tty {
+		data, err := io.ReadAll(resp.Body)
+		return string(data), err
+	}
+	return demux(resp.Body)
+}
+
+// demux decodes the multiplexed stream the daemon sends for containers
+// without a TTY: an 8 byte header (stream, 0, 0, 0, big endian length)
+// before each chunk.
+func demux(r io.Reader) (string, error) {
+	var out bytes.Buffer
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return out.String(), nil
+			}
+			return out.String(), err
+		}
+		size := binary.BigEndian.Uint32(header[4:])
+		if _, err := io.CopyN(&out, r, int64(size)); err != nil {
+			return out.String(), err
+		}
+	}
+}
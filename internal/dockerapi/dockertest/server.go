@@ -0,0 +1,636 @@
+// Package dockertest provides an in-process fake Docker Engine listening on
+// a unix socket, for tests of code built on dockerapi.
+package dockertest
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gymctl/internal/dockerapi"
+)
+
+// Build records one image build.
+type Build struct {
+	Tag    string
+	Labels map[string]string
+	// Files lists the paths in the build context.
+	Files []string
+}
+
+type container struct {
+	info dockerapi.Container
+	logs string
+}
+
+type volume struct {
+	info dockerapi.Volume
+	size int64
+}
+
+// Server is a fake Docker Engine.
+type Server struct {
+	// Host is the DOCKER_HOST address of the server.
+	Host string
+	// APIVersion and MinAPIVersion bound the API versions the server
+	// accepts, like a daemon's. Set them before the first request.
+	APIVersion    string
+	MinAPIVersion string
+
+	mu         sync.Mutex
+	images     map[string]*dockerapi.Image
+	containers map[string]*container
+	volumes    map[string]*volume
+	networks   map[string]*dockerapi.Network
+	nextID     int
+
+	eventsMu    sync.Mutex
+	subscribers map[chan dockerapi.Event]bool
+
+	// Builds, Pulls and Removed record what clients asked for.
+	Builds  []Build
+	Pulls   []string
+	Removed []string
+}
+
+// NewServer starts a fake engine that is stopped when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	// Socket paths are limited to about 100 bytes, too short for the
+	// nested directories t.TempDir creates.
+	dir, err := os.MkdirTemp("", "dockertest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		Host:          "unix://" + socket,
+		APIVersion:    dockerapi.MaxAPIVersion,
+		MinAPIVersion: "1.24",
+		images:        map[string]*dockerapi.Image{},
+		containers:    map[string]*container{},
+		volumes:       map[string]*volume{},
+		networks:      map[string]*dockerapi.Network{},
+
+		subscribers: map[chan dockerapi.Event]bool{},
+	}
+	server := &http.Server{Handler: http.HandlerFunc(s.serve)}
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+	return s
+}
+
+// Client returns a client connected to the server.
+func (s *Server) Client() *dockerapi.Client {
+	client, err := dockerapi.NewClient(s.Host)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// AddImage stores an image under ref, filling in its ID and tags.
+func (s *Server) AddImage(ref string, image dockerapi.Image) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addImageLocked(ref, image)
+}
+
+func (s *Server) addImageLocked(ref string, image dockerapi.Image) {
+	if image.ID == "" {
+		s.nextID++
+		image.ID = fmt.Sprintf("sha256:%064d", s.nextID)
+	}
+	if len(image.RepoTags) == 0 {
+		image.RepoTags = []string{normalizeRef(ref)}
+	}
+	s.images[normalizeRef(ref)] = &image
+}
+
+// AddContainer stores a container under name with the given logs.
+func (s *Server) AddContainer(name string, info dockerapi.Container, logs string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info.ID == "" {
+		s.nextID++
+		info.ID = fmt.Sprintf("%064d", s.nextID)
+	}
+	info.Name = "/" + name
+	s.containers[name] = &container{info: info, logs: logs}
+}
+
+// AddVolume stores a volume with the given disk usage.
+func (s *Server) AddVolume(info dockerapi.Volume, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.volumes[info.Name] = &volume{info: info, size: size}
+}
+
+// AddNetwork stores a network, filling in its ID.
+func (s *Server) AddNetwork(info dockerapi.Network) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info.ID == "" {
+		s.nextID++
+		info.ID = fmt.Sprintf("%064d", s.nextID)
+	}
+	s.networks[info.Name] = &info
+}
+
+// Container returns the stored container called name.
+func (s *Server) Container(name string) (dockerapi.Container, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.containers[name]
+	if !ok {
+		return dockerapi.Container{}, false
+	}
+	return c.info, true
+}
+
+func normalizeRef(ref string) string {
+	if strings.HasPrefix(ref, "sha256:") {
+		return ref
+	}
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon <= slash {
+		return ref + ":latest"
+	}
+	return ref
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if version, rest, ok := splitVersion(path); ok {
+		switch {
+		case versionLess(s.APIVersion, version):
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("client version %s is too new. Maximum supported API version is %s", version, s.APIVersion))
+			return
+		case versionLess(version, s.MinAPIVersion):
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("client version %s is too old. Minimum supported API version is %s, please upgrade your client to a newer version", version, s.MinAPIVersion))
+			return
+		}
+		path = rest
+	}
+	if path == "/_ping" {
+		w.Header().Set("API-Version", s.APIVersion)
+	}
+	if path == "/events" {
+		// Streams outlive the request lock.
+		s.serveEvents(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case path == "/_ping":
+		io.WriteString(w, "OK")
+	case path == "/images/json" && r.Method == http.MethodGet:
+		s.listImages(w, r)
+	case path == "/images/prune":
+		writeJSON(w, map[string]int64{"SpaceReclaimed": 0})
+	case path == "/images/create":
+		s.pull(w, r)
+	case path == "/build":
+		s.build(w, r)
+	case strings.HasPrefix(path, "/images/"):
+		s.image(w, r, strings.TrimPrefix(path, "/images/"))
+	case path == "/containers/json":
+		s.listContainers(w, r)
+	case path == "/containers/create":
+		s.createContainer(w, r)
+	case strings.HasPrefix(path, "/containers/"):
+		s.container(w, r, strings.TrimPrefix(path, "/containers/"))
+	case path == "/volumes":
+		s.listVolumes(w, r, false)
+	case path == "/system/df":
+		s.listVolumes(w, r, true)
+	case strings.HasPrefix(path, "/volumes/") && r.Method == http.MethodDelete:
+		name := strings.TrimPrefix(path, "/volumes/")
+		delete(s.volumes, name)
+		s.Removed = append(s.Removed, "volume:"+name)
+		w.WriteHeader(http.StatusNoContent)
+	case path == "/networks":
+		s.listNetworks(w, r)
+	case strings.HasPrefix(path, "/networks/") && r.Method == http.MethodDelete:
+		s.removeNetwork(w, strings.TrimPrefix(path, "/networks/"))
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (s *Server) findImage(name string) (string, *dockerapi.Image) {
+	if image, ok := s.images[normalizeRef(name)]; ok {
+		return normalizeRef(name), image
+	}
+	for ref, image := range s.images {
+		if image.ID == name || strings.TrimPrefix(image.ID, "sha256:") == name {
+			return ref, image
+		}
+	}
+	return "", nil
+}
+
+func (s *Server) image(w http.ResponseWriter, r *http.Request, rest string) {
+	name := strings.TrimSuffix(rest, "/json")
+	ref, image := s.findImage(name)
+	if image == nil {
+		writeError(w, http.StatusNotFound, "No such image: "+name)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, image)
+	case http.MethodDelete:
+		delete(s.images, ref)
+		s.Removed = append(s.Removed, "image:"+ref)
+		writeJSON(w, []interface{}{})
+	}
+}
+
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	filters := parseFilters(r)
+	refs := make([]string, 0, len(s.images))
+	for ref := range s.images {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	summaries := []dockerapi.ImageSummary{}
+	for _, ref := range refs {
+		image := s.images[ref]
+		if !matchLabels(image.Config.Labels, filters["label"]) {
+			continue
+		}
+		summary := dockerapi.ImageSummary{
+			ID:       image.ID,
+			RepoTags: image.RepoTags,
+			Size:     image.Size,
+			Labels:   image.Config.Labels,
+		}
+		if created, err := time.Parse(time.RFC3339Nano, image.Created); err == nil {
+			summary.Created = created.Unix()
+		}
+		summaries = append(summaries, summary)
+	}
+	writeJSON(w, summaries)
+}
+
+func (s *Server) pull(w http.ResponseWriter, r *http.Request) {
+	ref := r.URL.Query().Get("fromImage") + ":" + r.URL.Query().Get("tag")
+	s.Pulls = append(s.Pulls, ref)
+	s.addImageLocked(ref, dockerapi.Image{Size: 1 << 20})
+	writeJSON(w, map[string]string{"status": "Downloaded newer image for " + ref})
+}
+
+func (s *Server) build(w http.ResponseWriter, r *http.Request) {
+	build := Build{Tag: r.URL.Query().Get("t")}
+	if labels := r.URL.Query().Get("labels"); labels != "" {
+		_ = json.Unmarshal([]byte(labels), &build.Labels)
+	}
+	archive := tar.NewReader(r.Body)
+	hasDockerfile := false
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		build.Files = append(build.Files, header.Name)
+		hasDockerfile = hasDockerfile || header.Name == "Dockerfile"
+	}
+	s.Builds = append(s.Builds, build)
+	if !hasDockerfile {
+		writeJSON(w, map[string]interface{}{
+			"error":       "Cannot locate specified Dockerfile: Dockerfile",
+			"errorDetail": map[string]string{"message": "Cannot locate specified Dockerfile: Dockerfile"},
+		})
+		return
+	}
+	image := dockerapi.Image{Size: 5 << 20}
+	image.Config.Labels = build.Labels
+	s.addImageLocked(build.Tag, image)
+	writeJSON(w, map[string]string{"stream": "Successfully tagged " + build.Tag + "\n"})
+}
+
+func (s *Server) findContainer(name string) (string, *container) {
+	if c, ok := s.containers[name]; ok {
+		return name, c
+	}
+	for key, c := range s.containers {
+		if strings.HasPrefix(c.info.ID, name) {
+			return key, c
+		}
+	}
+	return "", nil
+}
+
+func (s *Server) container(w http.ResponseWriter, r *http.Request, rest string) {
+	name, action, _ := strings.Cut(rest, "/")
+	key, c := s.findContainer(name)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+name)
+		return
+	}
+	switch {
+	case action == "json":
+		writeJSON(w, c.info)
+	case action == "start":
+		c.info.State.Status = "running"
+		s.emit("container", "start", c.info.ID, c.info.Config.Labels)
+		w.WriteHeader(http.StatusNoContent)
+	case action == "logs":
+		w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+		if c.info.Config.Tty {
+			io.WriteString(w, c.logs)
+			return
+		}
+		for _, line := range strings.SplitAfter(c.logs, "\n") {
+			if line == "" {
+				continue
+			}
+			header := make([]byte, 8)
+			header[0] = 1
+			binary.BigEndian.PutUint32(header[4:], uint32(len(line)))
+			w.Write(header)
+			io.WriteString(w, line)
+		}
+	case action == "" && r.Method == http.MethodDelete:
+		delete(s.containers, key)
+		s.Removed = append(s.Removed, "container:"+key)
+		s.emit("container", "destroy", c.info.ID, c.info.Config.Labels)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (s *Server) createContainer(w http.ResponseWriter, r *http.Request) {
+	var spec struct {
+		Image      string
+		Labels     map[string]string
+		HostConfig struct {
+			PortBindings map[string][]dockerapi.PortBinding
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, image := s.findImage(spec.Image); image == nil {
+		writeError(w, http.StatusNotFound, "No such image: "+spec.Image)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if _, exists := s.containers[name]; exists {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Conflict. The container name %q is already in use", "/"+name))
+		return
+	}
+	s.nextID++
+	c := &container{}
+	c.info.ID = fmt.Sprintf("%064d", s.nextID)
+	c.info.Name = "/" + name
+	c.info.State.Status = "created"
+	c.info.Config.Image = spec.Image
+	c.info.Config.Labels = spec.Labels
+	c.info.NetworkSettings.Ports = spec.HostConfig.PortBindings
+	if name == "" {
+		name = c.info.ID[:12]
+	}
+	s.containers[name] = c
+	s.emit("container", "create", c.info.ID, c.info.Config.Labels)
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, map[string]string{"Id": c.info.ID})
+}
+
+func (s *Server) listContainers(w http.ResponseWriter, r *http.Request) {
+	filters := parseFilters(r)
+	all := r.URL.Query().Get("all") != ""
+	names := make([]string, 0, len(s.containers))
+	for name := range s.containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	summaries := []dockerapi.ContainerSummary{}
+	for _, name := range names {
+		c := s.containers[name]
+		state := c.info.State.Status
+		if !all && state != "running" {
+			continue
+		}
+		if statuses := filters["status"]; len(statuses) > 0 && !containsString(statuses, state) {
+			continue
+		}
+		if !matchLabels(c.info.Config.Labels, filters["label"]) {
+			continue
+		}
+		summaries = append(summaries, dockerapi.ContainerSummary{
+			ID:     c.info.ID,
+			Names:  []string{"/" + name},
+			Image:  c.info.Config.Image,
+			State:  state,
+			Labels: c.info.Config.Labels,
+		})
+	}
+	writeJSON(w, summaries)
+}
+
+func (s *Server) listVolumes(w http.ResponseWriter, r *http.Request, usage bool) {
+	filters := parseFilters(r)
+	names := make([]string, 0, len(s.volumes))
+	for name := range s.volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	volumes := []dockerapi.Volume{}
+	for _, name := range names {
+		v := s.volumes[name]
+		if !matchLabels(v.info.Labels, filters["label"]) {
+			continue
+		}
+		info := v.info
+		if usage {
+			info.UsageData = &struct {
+				Size     int64 `json:"Size"`
+				RefCount int64 `json:"RefCount"`
+			}{Size: v.size}
+		}
+		volumes = append(volumes, info)
+	}
+	writeJSON(w, map[string]interface{}{"Volumes": volumes})
+}
+
+func (s *Server) listNetworks(w http.ResponseWriter, r *http.Request) {
+	filters := parseFilters(r)
+	names := make([]string, 0, len(s.networks))
+	for name := range s.networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	networks := []dockerapi.Network{}
+	for _, name := range names {
+		if matchLabels(s.networks[name].Labels, filters["label"]) {
+			networks = append(networks, *s.networks[name])
+		}
+	}
+	writeJSON(w, networks)
+}
+
+func (s *Server) removeNetwork(w http.ResponseWriter, ref string) {
+	for name, network := range s.networks {
+		if name == ref || network.ID == ref {
+			delete(s.networks, name)
+			s.Removed = append(s.Removed, "network:"+name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "network "+ref+" not found")
+}
+
+func parseFilters(r *http.Request) map[string][]string {
+	filters := map[string][]string{}
+	if raw := r.URL.Query().Get("filters"); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &filters)
+	}
+	return filters
+}
+
+func matchLabels(labels map[string]string, wanted []string) bool {
+	for _, want := range wanted {
+		key, value, hasValue := strings.Cut(want, "=")
+		actual, ok := labels[key]
+		if !ok || (hasValue && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// splitVersion splits "/v1.47/images/json" into "1.47" and "/images/json".
+func splitVersion(path string) (string, string, bool) {
+	if !strings.HasPrefix(path, "/v") {
+		return "", path, false
+	}
+	version, rest, _ := strings.Cut(path[2:], "/")
+	if _, ok := versionParts(version); !ok {
+		return "", path, false
+	}
+	return version, "/" + rest, true
+}
+
+func versionLess(a, b string) bool {
+	aParts, _ := versionParts(a)
+	bParts, _ := versionParts(b)
+	if aParts[0] != bParts[0] {
+		return aParts[0] < bParts[0]
+	}
+	return aParts[1] < bParts[1]
+}
+
+func versionParts(version string) ([2]int, bool) {
+	var parts [2]int
+	_, err := fmt.Sscanf(version, "%d.%d", &parts[0], &parts[1])
+	return parts, err == nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// Emit sends an event to the clients streaming /events, as if the daemon
+// had reported it.
+func (s *Server) Emit(event dockerapi.Event) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+func (s *Server) emit(eventType, action, id string, labels map[string]string) {
+	event := dockerapi.Event{Type: eventType, Action: action, Time: time.Now().Unix()}
+	event.Actor.ID = id
+	event.Actor.Attributes = labels
+	s.Emit(event)
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	filters := parseFilters(r)
+	events := make(chan dockerapi.Event, 16)
+	s.eventsMu.Lock()
+	s.subscribers[events] = true
+	s.eventsMu.Unlock()
+	defer func() {
+		s.eventsMu.Lock()
+		delete(s.subscribers, events)
+		s.eventsMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			if types := filters["type"]; len(types) > 0 && !containsString(types, event.Type) {
+				continue
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
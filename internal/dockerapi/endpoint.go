@@ -0,0 +1,94 @@
+package dockerapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// configDir returns the docker CLI configuration directory: $DOCKER_CONFIG
+// or ~/.docker.
+func configDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// currentContext returns the docker context the CLI would use:
+// $DOCKER_CONTEXT or currentContext from config.json. Empty means the
+// default context.
+func currentContext(dir string) (string, error) {
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read docker config: %w", err)
+	}
+	var config struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("parse docker config: %w", err)
+	}
+	return config.CurrentContext, nil
+}
+
+// contextHost returns the daemon address of a docker context, as set up by
+// Docker Desktop, colima or rootless Docker. Contexts are stored under the
+// SHA-256 of their name.
+func contextHost(dir, name string) (string, error) {
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+	data, err := os.ReadFile(filepath.Join(dir, "contexts", "meta", id, "meta.json"))
+	if err != nil {
+		return "", fmt.Errorf("docker context %q: %w", name, err)
+	}
+	var meta struct {
+		Endpoints struct {
+			Docker struct {
+				Host string `json:"Host"`
+			} `json:"docker"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return "", fmt.Errorf("parse docker context %q: %w", name, err)
+	}
+	host := meta.Endpoints.Docker.Host
+	if host == "" {
+		return "", fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "contexts", "tls", id, "docker")); err == nil {
+		return "", fmt.Errorf("%w %q: TLS is not supported", ErrUnsupportedHost, host)
+	}
+	return host, nil
+}
+
+// resolveHost returns the daemon address the docker CLI would talk to:
+// $DOCKER_HOST, the current docker context's endpoint, or DefaultHost.
+func resolveHost() (string, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host, nil
+	}
+	dir := configDir()
+	name, err := currentContext(dir)
+	if err != nil {
+		return "", err
+	}
+	if name == "" || name == "default" {
+		return DefaultHost, nil
+	}
+	return contextHost(dir, name)
+}
@@ -0,0 +1,42 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Event is one entry of the daemon's event stream, such as a container
+// starting ("container", "start") or an image being tagged.
+type Event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	Time int64 `json:"time"`
+}
+
+// Events reports daemon events matching filters, e.g. {"type":
+// {"container"}}, until ctx ends or the daemon closes the stream. Only
+// events after the call are reported.
+func (c *Client) Events(ctx context.Context, filters Filters, onEvent func(Event)) error {
+	resp, err := c.do(ctx, http.MethodGet, "/events", filters.query(nil), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event Event
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		onEvent(event)
+	}
+}
@@ -0,0 +1,128 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Image is the subset of `docker image inspect` gymctl reads.
+type Image struct {
+	ID       string   `json:"Id"`
+	RepoTags []string `json:"RepoTags"`
+	Created  string   `json:"Created"`
+	Size     int64    `json:"Size"`
+	Config   struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	ContainerConfig struct {
+		Image string `json:"Image"`
+	} `json:"ContainerConfig"`
+	RootFS struct {
+		Layers []string `json:"Layers"`
+	} `json:"RootFS"`
+}
+
+// ImageSummary is one entry of the image list.
+type ImageSummary struct {
+	ID       string            `json:"Id"`
+	RepoTags []string          `json:"RepoTags"`
+	Created  int64             `json:"Created"`
+	Size     int64             `json:"Size"`
+	Labels   map[string]string `json:"Labels"`
+}
+
+// ImageInspect returns details about an image by name or ID.
+func (c *Client) ImageInspect(ctx context.Context, name string) (*Image, error) {
+	var image Image
+	if err := c.getJSON(ctx, "/images/"+url.PathEscape(name)+"/json", nil, &image); err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ImageList lists images matching filters.
+func (c *Client) ImageList(ctx context.Context, filters Filters) ([]ImageSummary, error) {
+	var images []ImageSummary
+	if err := c.getJSON(ctx, "/images/json", filters.query(nil), &images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// ImageRemove deletes an image.
+func (c *Client) ImageRemove(ctx context.Context, name string, force bool) error {
+	query := url.Values{}
+	if force {
+		query.Set("force", "1")
+	}
+	return c.delete(ctx, "/images/"+url.PathEscape(name), query)
+}
+
+// ImagesPrune removes dangling images and returns the bytes reclaimed.
+func (c *Client) ImagesPrune(ctx context.Context) (int64, error) {
+	var report struct {
+		SpaceReclaimed int64 `json:"SpaceReclaimed"`
+	}
+	err := c.postJSON(ctx, "/images/prune", Filters{"dangling": {"true"}}.query(nil), nil, &report)
+	return report.SpaceReclaimed, err
+}
+
+// ImagePull pulls an image, defaulting to the latest tag.
+func (c *Client) ImagePull(ctx context.Context, ref string) error {
+	name, tag := splitImageRef(ref)
+	query := url.Values{"fromImage": {name}, "tag": {tag}}
+	resp, err := c.do(ctx, http.MethodPost, "/images/create", query, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readProgress(resp.Body, nil)
+}
+
+func splitImageRef(ref string) (string, string) {
+	if at := strings.Index(ref, "@"); at >= 0 {
+		return ref[:at], ref[at+1:]
+	}
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		return ref[:colon], ref[colon+1:]
+	}
+	return ref, "latest"
+}
+
+// readProgress consumes a JSON message stream as sent by build and pull,
+// copying build output to out and returning the first reported error.
+func readProgress(body io.Reader, out io.Writer) error {
+	decoder := json.NewDecoder(body)
+	for {
+		var message struct {
+			Stream      string `json:"stream"`
+			Error       string `json:"error"`
+			ErrorDetail struct {
+				Message string `json:"message"`
+			} `json:"errorDetail"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if message.ErrorDetail.Message != "" {
+			return errors.New(strings.TrimSpace(message.ErrorDetail.Message))
+		}
+		if message.Error != "" {
+			return errors.New(strings.TrimSpace(message.Error))
+		}
+		if out != nil && message.Stream != "" {
+			fmt.Fprint(out, message.Stream)
+		}
+	}
+}
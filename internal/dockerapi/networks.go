@@ -0,0 +1,29 @@
+package dockerapi
+
+import (
+	"context"
+	"net/url"
+)
+
+// Network is one entry of the network list.
+type Network struct {
+	ID      string            `json:"Id"`
+	Name    string            `json:"Name"`
+	Driver  string            `json:"Driver"`
+	Created string            `json:"Created"`
+	Labels  map[string]string `json:"Labels"`
+}
+
+// NetworkList lists networks matching filters.
+func (c *Client) NetworkList(ctx context.Context, filters Filters) ([]Network, error) {
+	var networks []Network
+	if err := c.getJSON(ctx, "/networks", filters.query(nil), &networks); err != nil {
+		return nil, err
+	}
+	return networks, nil
+}
+
+// NetworkRemove removes a network by name or ID.
+func (c *Client) NetworkRemove(ctx context.Context, name string) error {
+	return c.delete(ctx, "/networks/"+url.PathEscape(name), nil)
+}
@@ -0,0 +1,52 @@
+package dockerapi
+
+import (
+	"context"
+	"net/url"
+)
+
+// Volume is one entry of the volume list.
+type Volume struct {
+	Name      string            `json:"Name"`
+	Driver    string            `json:"Driver"`
+	CreatedAt string            `json:"CreatedAt"`
+	Labels    map[string]string `json:"Labels"`
+	UsageData *struct {
+		Size     int64 `json:"Size"`
+		RefCount int64 `json:"RefCount"`
+	} `json:"UsageData"`
+}
+
+// VolumeList lists volumes matching filters.
+func (c *Client) VolumeList(ctx context.Context, filters Filters) ([]Volume, error) {
+	var list struct {
+		Volumes []Volume `json:"Volumes"`
+	}
+	if err := c.getJSON(ctx, "/volumes", filters.query(nil), &list); err != nil {
+		return nil, err
+	}
+	return list.Volumes, nil
+}
+
+// VolumeSizes returns the disk usage of every volume in bytes. Only the
+// disk usage endpoint computes sizes; volume listings leave them empty.
+func (c *Client) VolumeSizes(ctx context.Context) (map[string]int64, error) {
+	var usage struct {
+		Volumes []Volume `json:"Volumes"`
+	}
+	if err := c.getJSON(ctx, "/system/df", url.Values{"type": {"volume"}}, &usage); err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64, len(usage.Volumes))
+	for _, volume := range usage.Volumes {
+		if volume.UsageData != nil && volume.UsageData.Size > 0 {
+			sizes[volume.Name] = volume.UsageData.Size
+		}
+	}
+	return sizes, nil
+}
+
+// VolumeRemove force-removes a volume.
+func (c *Client) VolumeRemove(ctx context.Context, name string) error {
+	return c.delete(ctx, "/volumes/"+url.PathEscape(name), url.Values{"force": {"1"}})
+}
@@ -0,0 +1,185 @@
+package dockerfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, content string) *Dockerfile {
+	t.Helper()
+	d, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return d
+}
+
+func TestParseStages(t *testing.T) {
+	d := parse(t, `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+ARG BASE="alpine:3.19"
+
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION}-alpine AS Build
+WORKDIR /src
+COPY go.mod go.sum ./
+RUN --mount=type=cache,target=/go/pkg/mod \
+    # download first for a better cache
+    go mod download
+COPY . .
+RUN go build -o /app
+
+FROM build AS test
+RUN go test ./...
+
+FROM ${BASE}
+COPY --from=build --chown=app:app /app /app
+USER app
+HEALTHCHECK --interval=30s CMD ["wget", "-q", "http://localhost:8080/health"]
+EXPOSE 8080 9090/udp
+ENTRYPOINT ["/app"]
+`)
+
+	if d.Directives["syntax"] != "docker/dockerfile:1" {
+		t.Errorf("directives = %v", d.Directives)
+	}
+	if len(d.Stages) != 3 {
+		t.Fatalf("got %d stages, want 3", len(d.Stages))
+	}
+
+	build := d.Stages[0]
+	if build.Name != "build" || build.Base != "golang:1.22-alpine" || build.From.Flags["platform"] != "$BUILDPLATFORM" {
+		t.Errorf("build stage = %+v", build)
+	}
+	run := build.Find("RUN")[0]
+	if run.Value != "go mod download" {
+		t.Errorf("continued RUN = %q", run.Value)
+	}
+	if run.Flags["mount"] != "type=cache,target=/go/pkg/mod" || run.Line != 8 {
+		t.Errorf("RUN flags = %v, line %d", run.Flags, run.Line)
+	}
+
+	if test := d.Stages[1]; test.BaseStage != 0 || d.BaseImage(&test).Name != "golang" {
+		t.Errorf("test stage = %+v", test)
+	}
+
+	final, ok := d.Stage("")
+	if !ok || final.Index != 2 || final.Base != "alpine:3.19" {
+		t.Fatalf("final stage = %+v", final)
+	}
+	copyFrom := final.Find("COPY")[0]
+	if copyFrom.Flags["from"] != "build" || copyFrom.Flags["chown"] != "app:app" || !reflect.DeepEqual(copyFrom.Args, []string{"/app", "/app"}) {
+		t.Errorf("COPY = %+v", copyFrom)
+	}
+	healthcheck := final.Find("healthcheck")[0]
+	if healthcheck.Flags["interval"] != "30s" || healthcheck.Args[0] != "CMD" {
+		t.Errorf("HEALTHCHECK = %+v", healthcheck)
+	}
+	entrypoint := final.Find("ENTRYPOINT")[0]
+	if !entrypoint.JSON || !reflect.DeepEqual(entrypoint.Args, []string{"/app"}) {
+		t.Errorf("ENTRYPOINT = %+v", entrypoint)
+	}
+
+	for ref, want := range map[string]int{"build": 0, "TEST": 1, "2": 2, "final": 2, "last": 2} {
+		if stage, ok := d.Stage(ref); !ok || stage.Index != want {
+			t.Errorf("Stage(%q) = %v, %t, want index %d", ref, stage, ok, want)
+		}
+	}
+	if _, ok := d.Stage("release"); ok {
+		t.Error("Stage(release) found a stage")
+	}
+}
+
+func TestLastFollowsBaseStages(t *testing.T) {
+	d := parse(t, `FROM alpine:3.19 AS base
+USER app
+HEALTHCHECK CMD true
+
+FROM base
+RUN echo hi
+`)
+	final, _ := d.Stage("")
+	if user, ok := d.Last(final, "USER"); !ok || user.Value != "app" {
+		t.Errorf("USER = %+v, %t", user, ok)
+	}
+	if _, ok := d.Last(&d.Stages[0], "RUN"); ok {
+		t.Error("found a RUN in the base stage")
+	}
+	if image := d.BaseImage(final); image.String() != "alpine:3.19" {
+		t.Errorf("BaseImage = %s", image)
+	}
+}
+
+func TestParseHeredocs(t *testing.T) {
+	d := parse(t, `FROM alpine
+RUN <<EOF
+apk add curl
+echo done
+EOF
+COPY <<-"CONF" /etc/app.conf
+	port=8080
+	CONF
+USER nobody
+`)
+	stage := d.Stages[0]
+	if len(stage.Instructions) != 3 {
+		t.Fatalf("got %d instructions, want 3: %+v", len(stage.Instructions), stage.Instructions)
+	}
+	run := stage.Instructions[0]
+	if len(run.Heredocs) != 1 || run.Heredocs[0].Content != "apk add curl\necho done\n" {
+		t.Errorf("RUN heredocs = %+v", run.Heredocs)
+	}
+	conf := stage.Instructions[1]
+	if len(conf.Heredocs) != 1 || conf.Heredocs[0].Name != "CONF" || conf.Heredocs[0].Content != "port=8080\n" {
+		t.Errorf("COPY heredocs = %+v", conf.Heredocs)
+	}
+	if stage.Instructions[2].Command != "USER" || stage.Instructions[2].Line != 9 {
+		t.Errorf("instruction after heredocs = %+v", stage.Instructions[2])
+	}
+}
+
+func TestParseEscapeDirective(t *testing.T) {
+	d := parse(t, "# escape=`\nFROM mcr.microsoft.com/windows/servercore:ltsc2022\nRUN dir `\n    C:\\\nUSER ContainerUser\n")
+	stage := d.Stages[0]
+	if len(stage.Instructions) != 2 || stage.Instructions[0].Value != "dir     C:\\" {
+		t.Errorf("instructions = %+v", stage.Instructions)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no FROM":              "# just a comment\n",
+		"instruction first":    "RUN echo hi\nFROM alpine\n",
+		"bad FROM":             "FROM alpine AS\n",
+		"unterminated heredoc": "FROM alpine\nRUN <<EOF\necho hi\n",
+		"invalid escape":       "# escape=x\nFROM alpine\n",
+		"duplicate directive":  "# syntax=a\n# syntax=b\nFROM alpine\n",
+	}
+	for name, content := range tests {
+		if _, err := Parse(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		ref    string
+		want   ImageRef
+		pinned bool
+	}{
+		{"alpine", ImageRef{Name: "alpine"}, false},
+		{"alpine:latest", ImageRef{Name: "alpine", Tag: "latest"}, false},
+		{"alpine:3.19", ImageRef{Name: "alpine", Tag: "3.19"}, true},
+		{"localhost:5000/team/app", ImageRef{Name: "localhost:5000/team/app"}, false},
+		{"localhost:5000/team/app:v2", ImageRef{Name: "localhost:5000/team/app", Tag: "v2"}, true},
+		{"alpine@sha256:abc", ImageRef{Name: "alpine", Digest: "sha256:abc"}, true},
+		{"scratch", ImageRef{Name: "scratch"}, true},
+	}
+	for _, tt := range tests {
+		got := ParseImageRef(tt.ref)
+		if got != tt.want || got.Pinned() != tt.pinned || got.String() != tt.ref {
+			t.Errorf("ParseImageRef(%q) = %+v (pinned %t, %s), want %+v (pinned %t)", tt.ref, got, got.Pinned(), got, tt.want, tt.pinned)
+		}
+	}
+}
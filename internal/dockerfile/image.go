@@ -0,0 +1,42 @@
+package dockerfile
+
+import "strings"
+
+// ImageRef is an image reference split into its parts.
+type ImageRef struct {
+	// Name is the repository, including any registry.
+	Name   string
+	Tag    string
+	Digest string
+}
+
+// ParseImageRef splits a reference such as
+// "registry:5000/app:1.2@sha256:..." into name, tag and digest.
+func ParseImageRef(ref string) ImageRef {
+	var image ImageRef
+	ref, image.Digest, _ = strings.Cut(ref, "@")
+	// A colon after the last slash separates the tag; one before it
+	// belongs to a registry port.
+	if colon := strings.LastIndexByte(ref, ':'); colon > strings.LastIndexByte(ref, '/') {
+		ref, image.Tag = ref[:colon], ref[colon+1:]
+	}
+	image.Name = ref
+	return image
+}
+
+// Pinned reports whether the reference names a fixed image: by digest or by
+// a tag other than latest. scratch is always pinned.
+func (r ImageRef) Pinned() bool {
+	return r.Digest != "" || (r.Tag != "" && r.Tag != "latest") || r.Name == "scratch"
+}
+
+func (r ImageRef) String() string {
+	s := r.Name
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
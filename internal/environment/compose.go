@@ -0,0 +1,85 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// composeFile is the part of a compose file the label override needs.
+type composeFile struct {
+	Services map[string]struct {
+		Build interface{} `json:"build"`
+	} `json:"services"`
+	Volumes  map[string]composeResource `json:"volumes"`
+	Networks map[string]composeResource `json:"networks"`
+}
+
+type composeResource struct {
+	External interface{} `json:"external"`
+}
+
+// writeComposeOverride writes a temporary compose file that adds labels to
+// every service, the images it builds and the volumes and networks the
+// project creates, including the default network. Compose merges it over
+// the exercise's own file, which stays untouched. The caller removes it.
+func writeComposeOverride(composePath string, labels map[string]string) (string, error) {
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return "", fmt.Errorf("read compose file: %w", err)
+	}
+	var base composeFile
+	if err := yaml.Unmarshal(data, &base); err != nil {
+		return "", fmt.Errorf("parse compose file %s: %w", composePath, err)
+	}
+
+	override := map[string]interface{}{}
+	services := map[string]interface{}{}
+	for name, spec := range base.Services {
+		service := map[string]interface{}{"labels": labels}
+		if spec.Build != nil {
+			service["build"] = map[string]interface{}{"labels": labels}
+		}
+		services[name] = service
+	}
+	if len(services) > 0 {
+		override["services"] = services
+	}
+	if volumes := labelResources(base.Volumes, labels); len(volumes) > 0 {
+		override["volumes"] = volumes
+	}
+	networks := labelResources(base.Networks, labels)
+	if _, declared := base.Networks["default"]; !declared {
+		networks["default"] = map[string]interface{}{"labels": labels}
+	}
+	override["networks"] = networks
+
+	out, err := yaml.Marshal(override)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp("", "gymctl-compose-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("create compose override: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(out); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("write compose override: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// labelResources labels the volumes or networks compose creates. External
+// ones already exist and cannot take labels.
+func labelResources(resources map[string]composeResource, labels map[string]string) map[string]interface{} {
+	labelled := map[string]interface{}{}
+	for name, resource := range resources {
+		if resource.External != nil && resource.External != false {
+			continue
+		}
+		labelled[name] = map[string]interface{}{"labels": labels}
+	}
+	return labelled
+}
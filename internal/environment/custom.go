@@ -0,0 +1,115 @@
+package environment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gymctl/internal/kube"
+	"gymctl/internal/runner"
+	"gymctl/internal/scenario"
+)
+
+const (
+	CustomStepScript  = "script"
+	CustomStepWaitFor = "waitFor"
+
+	defaultCustomStepTimeout = 120 * time.Second
+	waitForPollInterval      = 2 * time.Second
+)
+
+// CustomSetup runs the customSetup steps of an exercise once its environment
+// is provisioned. Scripts run through bash in the work directory with
+// GYM_EXERCISE_DIR and GYM_WORK_DIR set, so they can reach both the exercise
+// sources and the files copied for the user, and with kubectl pinned to the
+// exercise's cluster.
+type CustomSetup struct {
+	ExerciseDir string
+	WorkDir     string
+}
+
+// DescribeStep returns the label shown while a step runs.
+func DescribeStep(step scenario.CustomSetupStep) string {
+	switch step.Type {
+	case CustomStepWaitFor:
+		return fmt.Sprintf("Waiting for %s", step.Condition)
+	default:
+		return "Running setup script"
+	}
+}
+
+// Run executes a single step, bounded by its timeout.
+func (c CustomSetup) Run(ctx context.Context, step scenario.CustomSetupStep) error {
+	timeout, err := stepTimeout(step)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch step.Type {
+	case CustomStepScript:
+		if step.Script == "" {
+			return fmt.Errorf("customSetup script step is missing script")
+		}
+		_, err := c.shell(ctx, step.Script)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("setup script timed out after %s", timeout)
+		}
+		return err
+	case CustomStepWaitFor:
+		if step.Condition == "" {
+			return fmt.Errorf("customSetup waitFor step is missing condition")
+		}
+		return c.waitFor(ctx, step.Condition, timeout)
+	default:
+		return fmt.Errorf("unsupported customSetup step type: %s", step.Type)
+	}
+}
+
+// waitFor polls the condition command until it exits successfully or the
+// context expires.
+func (c CustomSetup) waitFor(ctx context.Context, condition string, timeout time.Duration) error {
+	ticker := time.NewTicker(waitForPollInterval)
+	defer ticker.Stop()
+
+	for {
+		output, err := c.shell(ctx, condition)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if output != "" {
+				return fmt.Errorf("condition %q not met after %s: %s", condition, timeout, output)
+			}
+			return fmt.Errorf("condition %q not met after %s", condition, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c CustomSetup) shell(ctx context.Context, script string) (string, error) {
+	kubeEnv, cleanup, err := kube.ScriptEnv(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	env := append([]string{
+		"GYM_EXERCISE_DIR=" + c.ExerciseDir,
+		"GYM_WORK_DIR=" + c.WorkDir,
+	}, kubeEnv...)
+	return runner.RunWithEnv(ctx, c.WorkDir, env, "bash", "-c", script)
+}
+
+func stepTimeout(step scenario.CustomSetupStep) (time.Duration, error) {
+	if step.Timeout == "" {
+		return defaultCustomStepTimeout, nil
+	}
+	timeout, err := time.ParseDuration(step.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid customSetup timeout %q", step.Timeout)
+	}
+	return timeout, nil
+}
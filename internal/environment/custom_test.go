@@ -0,0 +1,72 @@
+package environment
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gymctl/internal/scenario"
+)
+
+func TestCustomSetupScript(t *testing.T) {
+	workDir := t.TempDir()
+	setup := CustomSetup{ExerciseDir: "/exercise", WorkDir: workDir}
+
+	err := setup.Run(context.Background(), scenario.CustomSetupStep{
+		Type:   CustomStepScript,
+		Script: `echo "$GYM_EXERCISE_DIR" > marker && pwd >> marker`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workDir, "marker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "/exercise\n") || !strings.Contains(string(data), workDir) {
+		t.Errorf("unexpected script output: %q", data)
+	}
+}
+
+func TestCustomSetupScriptTimeout(t *testing.T) {
+	setup := CustomSetup{WorkDir: t.TempDir()}
+	err := setup.Run(context.Background(), scenario.CustomSetupStep{Type: CustomStepScript, Script: "sleep 5", Timeout: "100ms"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestCustomSetupWaitFor(t *testing.T) {
+	workDir := t.TempDir()
+	setup := CustomSetup{WorkDir: workDir}
+
+	if err := os.WriteFile(filepath.Join(workDir, "ready"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := setup.Run(context.Background(), scenario.CustomSetupStep{Type: CustomStepWaitFor, Condition: "test -f ready"}); err != nil {
+		t.Errorf("expected condition to be met: %v", err)
+	}
+
+	err := setup.Run(context.Background(), scenario.CustomSetupStep{Type: CustomStepWaitFor, Condition: "test -f missing", Timeout: "100ms"})
+	if err == nil || !strings.Contains(err.Error(), "not met") {
+		t.Errorf("expected unmet condition error, got %v", err)
+	}
+}
+
+func TestCustomSetupRejectsInvalidSteps(t *testing.T) {
+	setup := CustomSetup{WorkDir: t.TempDir()}
+	steps := []scenario.CustomSetupStep{
+		{Type: "chaos"},
+		{Type: CustomStepScript},
+		{Type: CustomStepWaitFor},
+		{Type: CustomStepScript, Script: "true", Timeout: "soon"},
+	}
+	for _, step := range steps {
+		if err := setup.Run(context.Background(), step); err == nil {
+			t.Errorf("expected error for step %+v", step)
+		}
+	}
+}
@@ -7,12 +7,28 @@ import (
 	"os"
 	"path/filepath"
 
+	"gymctl/internal/dockerapi"
 	"gymctl/internal/runner"
 	"gymctl/internal/scenario"
 )
 
+// DefaultProject is the compose project used when none is configured.
+const DefaultProject = "jerry-gym"
+
 type DockerManager struct {
 	WorkDir string
+	// Project is the compose project name, DefaultProject when empty.
+	Project string
+	// Labels are put on every image, container, volume and network Setup
+	// creates.
+	Labels Labels
+}
+
+func (d DockerManager) project() string {
+	if d.Project != "" {
+		return d.Project
+	}
+	return DefaultProject
 }
 
 func (d DockerManager) Setup(ctx context.Context, entryDir string, spec scenario.DockerSpec) error {
@@ -20,40 +36,35 @@ func (d DockerManager) Setup(ctx context.Context, entryDir string, spec scenario
 		return fmt.Errorf("create workdir: %w", err)
 	}
 
-	for _, item := range spec.CopyFiles {
-		source := resolvePath(entryDir, item.From)
-		destination := filepath.Join(d.WorkDir, item.To)
-		if err := copyPath(source, destination); err != nil {
-			return err
-		}
-	}
-
 	if spec.ComposeFile != "" {
 		composePath := resolvePath(entryDir, spec.ComposeFile)
 		composeDir := filepath.Dir(composePath)
-		_, err := runner.RunInDir(ctx, composeDir, "docker", "compose", "-p", "jerry-gym", "-f", composePath, "up", "-d")
+		args := []string{"compose", "-p", d.project(), "-f", composePath}
+		if labels := d.Labels.Map(); labels != nil {
+			override, err := writeComposeOverride(composePath, labels)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(override)
+			args = append(args, "-f", override)
+		}
+		_, err := runner.RunInDir(ctx, composeDir, "docker", append(args, "up", "-d")...)
 		return err
 	}
 
 	if len(spec.Containers) > 0 {
 		for _, container := range spec.Containers {
-			image := container.Image
+			image := containerImage(container)
 			if container.Build != "" {
-				image = fmt.Sprintf("%s:latest", container.Name)
 				buildPath := resolvePath(entryDir, container.Build)
-				if _, err := runner.RunInDir(ctx, buildPath, "docker", "build", "-t", image, "."); err != nil {
+				if err := buildImage(ctx, buildPath, image, d.Labels.Map()); err != nil {
 					return err
 				}
 			}
 			if image == "" {
 				return fmt.Errorf("container %s missing image or build", container.Name)
 			}
-			args := []string{"run", "-d", "--name", container.Name}
-			for _, port := range container.Ports {
-				args = append(args, "-p", port)
-			}
-			args = append(args, image)
-			if _, err := runner.Run(ctx, "docker", args...); err != nil {
+			if err := runContainer(ctx, container.Name, image, container.Ports, d.Labels.Map()); err != nil {
 				return err
 			}
 		}
@@ -63,11 +74,24 @@ func (d DockerManager) Setup(ctx context.Context, entryDir string, spec scenario
 	return nil
 }
 
+// CopyFiles copies the exercise files listed in spec.CopyFiles from the
+// exercise directory into workDir, overwriting files already there.
+func CopyFiles(entryDir, workDir string, spec scenario.DockerSpec) error {
+	for _, item := range spec.CopyFiles {
+		source := resolvePath(entryDir, item.From)
+		destination := filepath.Join(workDir, item.To)
+		if err := copyPath(source, destination); err != nil {
+			return fmt.Errorf("copy %s: %w", item.From, err)
+		}
+	}
+	return nil
+}
+
 func (d DockerManager) Teardown(ctx context.Context, entryDir string, spec scenario.DockerSpec) error {
 	if spec.ComposeFile != "" {
 		composePath := resolvePath(entryDir, spec.ComposeFile)
 		composeDir := filepath.Dir(composePath)
-		_, err := runner.RunInDir(ctx, composeDir, "docker", "compose", "-p", "jerry-gym", "-f", composePath, "down", "-v")
+		_, err := runner.RunInDir(ctx, composeDir, "docker", "compose", "-p", d.project(), "-f", composePath, "down", "-v")
 		if err != nil {
 			return err
 		}
@@ -75,7 +99,7 @@ func (d DockerManager) Teardown(ctx context.Context, entryDir string, spec scena
 
 	if len(spec.Containers) > 0 {
 		for _, container := range spec.Containers {
-			_, _ = runner.Run(ctx, "docker", "rm", "-f", container.Name)
+			_ = removeContainer(ctx, container.Name)
 		}
 	}
 
@@ -86,6 +110,138 @@ func (d DockerManager) Teardown(ctx context.Context, entryDir string, spec scena
 	return nil
 }
 
+// Compose has no Engine API counterpart, so compose projects always go
+// through the docker CLI. Builds and containers use the API when it is
+// reachable and the CLI otherwise.
+
+func buildImage(ctx context.Context, contextDir, tag string, labels map[string]string) error {
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		if err := client.ImageBuild(ctx, contextDir, dockerapi.BuildOptions{Tag: tag, Labels: labels}); err != nil {
+			return fmt.Errorf("build %s: %w", tag, err)
+		}
+		return nil
+	}
+	args := append([]string{"build", "-t", tag}, labelArgs(labels)...)
+	_, err := runner.RunInDir(ctx, contextDir, "docker", append(args, ".")...)
+	return err
+}
+
+func runContainer(ctx context.Context, name, image string, ports []string, labels map[string]string) error {
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		_, err := client.ContainerRun(ctx, dockerapi.ContainerSpec{Name: name, Image: image, Ports: ports, Labels: labels})
+		if err != nil {
+			return fmt.Errorf("run %s: %w", name, err)
+		}
+		return nil
+	}
+	args := []string{"run", "-d", "--name", name}
+	for _, port := range ports {
+		args = append(args, "-p", port)
+	}
+	args = append(args, labelArgs(labels)...)
+	args = append(args, image)
+	_, err := runner.Run(ctx, "docker", args...)
+	return err
+}
+
+func removeContainer(ctx context.Context, name string) error {
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		if err := client.ContainerRemove(ctx, name); err != nil && !dockerapi.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	_, err := runner.Run(ctx, "docker", "rm", "-f", name)
+	return err
+}
+
+// RemoveLabeled removes the containers, networks and volumes labelled as
+// belonging to exercise, whichever session created them. Images stay, for
+// `gymctl cleanup` to reclaim, so the next start can reuse their layers.
+func RemoveLabeled(ctx context.Context, exercise string) error {
+	filters := ExerciseFilters(exercise)
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		return removeLabeledAPI(ctx, client, filters)
+	}
+
+	var labelFlags []string
+	for _, filter := range filters {
+		labelFlags = append(labelFlags, "--filter", "label="+filter)
+	}
+	listings := []struct {
+		list   []string
+		remove []string
+	}{
+		{[]string{"ps", "-aq"}, []string{"rm", "-f"}},
+		{[]string{"network", "ls", "-q"}, []string{"network", "rm"}},
+		{[]string{"volume", "ls", "-q"}, []string{"volume", "rm", "-f"}},
+	}
+	for _, listing := range listings {
+		output, err := runner.Output(ctx, "docker", append(listing.list, labelFlags...)...)
+		if err != nil {
+			return err
+		}
+		ids := splitLines(output)
+		if len(ids) == 0 {
+			continue
+		}
+		if _, err := runner.Run(ctx, "docker", append(listing.remove, ids...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeLabeledAPI(ctx context.Context, client *dockerapi.Client, filters []string) error {
+	containers, err := client.ContainerList(ctx, true, dockerapi.Filters{"label": filters})
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if err := client.ContainerRemove(ctx, container.ID); err != nil && !dockerapi.IsNotFound(err) {
+			return err
+		}
+	}
+	networks, err := client.NetworkList(ctx, dockerapi.Filters{"label": filters})
+	if err != nil {
+		return err
+	}
+	for _, network := range networks {
+		if err := client.NetworkRemove(ctx, network.ID); err != nil && !dockerapi.IsNotFound(err) {
+			return err
+		}
+	}
+	volumes, err := client.VolumeList(ctx, dockerapi.Filters{"label": filters})
+	if err != nil {
+		return err
+	}
+	for _, volume := range volumes {
+		if err := client.VolumeRemove(ctx, volume.Name); err != nil && !dockerapi.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuiltImages returns the tags of the images Setup builds from source, so a
+// hybrid exercise can load them into its kind cluster.
+func BuiltImages(spec scenario.DockerSpec) []string {
+	var images []string
+	for _, container := range spec.Containers {
+		if container.Build != "" {
+			images = append(images, containerImage(container))
+		}
+	}
+	return images
+}
+
+func containerImage(container scenario.DockerContainer) string {
+	if container.Build != "" {
+		return fmt.Sprintf("%s:latest", container.Name)
+	}
+	return container.Image
+}
+
 func resolvePath(baseDir string, value string) string {
 	if filepath.IsAbs(value) {
 		return value
@@ -0,0 +1,191 @@
+package environment
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/dockerapi/dockertest"
+	"gymctl/internal/scenario"
+)
+
+func TestDockerManagerWithEngineAPI(t *testing.T) {
+	server := dockertest.NewServer(t)
+	ctx := dockerapi.WithClient(context.Background(), server.Client())
+
+	entryDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(entryDir, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(entryDir, "app", "Dockerfile"), []byte("FROM alpine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec := scenario.DockerSpec{Containers: []scenario.DockerContainer{
+		{Name: "api", Build: "app", Ports: []string{"3000:3000"}},
+		{Name: "cache", Image: "redis:7"},
+	}}
+	labels := Labels{Exercise: "demo", Variant: "slow", Session: "abc123"}
+	manager := DockerManager{WorkDir: filepath.Join(t.TempDir(), "work"), Labels: labels}
+
+	if err := manager.Setup(ctx, entryDir, spec); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if len(server.Builds) != 1 || server.Builds[0].Tag != "api:latest" {
+		t.Errorf("builds = %+v", server.Builds)
+	} else if !reflect.DeepEqual(server.Builds[0].Labels, labels.Map()) {
+		t.Errorf("build labels = %v", server.Builds[0].Labels)
+	}
+	for _, name := range []string{"api", "cache"} {
+		container, ok := server.Container(name)
+		if !ok || container.State.Status != "running" {
+			t.Errorf("container %s = %+v, %t", name, container, ok)
+		}
+		if container.Config.Labels[LabelSession] != "abc123" || container.Config.Labels[LabelManagedBy] != ManagedBy {
+			t.Errorf("container %s labels = %v", name, container.Config.Labels)
+		}
+	}
+
+	if err := manager.Teardown(ctx, entryDir, spec); err != nil {
+		t.Fatalf("Teardown: %v", err)
+	}
+	for _, name := range []string{"api", "cache"} {
+		if _, ok := server.Container(name); ok {
+			t.Errorf("container %s still exists after teardown", name)
+		}
+	}
+}
+
+func TestCopyFiles(t *testing.T) {
+	entryDir := t.TempDir()
+	files := map[string]string{
+		"setup/Dockerfile.broken": "FROM alpine\n",
+		"setup/app/start.sh":      "#!/bin/sh\n",
+		"solution/Dockerfile":     "FROM alpine\nUSER app\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(entryDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spec := scenario.DockerSpec{CopyFiles: []scenario.CopyFile{
+		{From: "setup/Dockerfile.broken", To: "Dockerfile"},
+		{From: "setup/app/", To: "app/"},
+	}}
+
+	workDir := filepath.Join(t.TempDir(), "work")
+	if err := CopyFiles(entryDir, workDir, spec); err != nil {
+		t.Fatalf("CopyFiles: %v", err)
+	}
+	for name, want := range map[string]string{"Dockerfile": "FROM alpine\n", "app/start.sh": "#!/bin/sh\n"} {
+		got, err := os.ReadFile(filepath.Join(workDir, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "Dockerfile.broken")); err == nil {
+		t.Error("copied the source name instead of the target")
+	}
+
+	spec.CopyFiles = append(spec.CopyFiles, scenario.CopyFile{From: "setup/missing", To: "missing"})
+	if err := CopyFiles(entryDir, workDir, spec); err == nil {
+		t.Error("expected an error for a missing source")
+	}
+}
+
+func TestRemoveLabeled(t *testing.T) {
+	server := dockertest.NewServer(t)
+	ctx := dockerapi.WithClient(context.Background(), server.Client())
+
+	ours := Labels{Exercise: "demo", Session: "old"}.Map()
+	other := Labels{Exercise: "other"}.Map()
+	stale := dockerapi.Container{}
+	stale.Config.Labels = ours
+	server.AddContainer("stale", stale, "")
+	unrelated := dockerapi.Container{}
+	unrelated.Config.Labels = other
+	server.AddContainer("unrelated", unrelated, "")
+	server.AddContainer("unlabelled", dockerapi.Container{}, "")
+	server.AddNetwork(dockerapi.Network{Name: "demo_default", Labels: ours})
+	server.AddNetwork(dockerapi.Network{Name: "bridge"})
+	server.AddVolume(dockerapi.Volume{Name: "demo_data", Labels: ours}, 0)
+	// Only gymctl's own label set counts, not a look-alike.
+	server.AddVolume(dockerapi.Volume{Name: "lookalike", Labels: map[string]string{LabelExercise: "demo"}}, 0)
+
+	if err := RemoveLabeled(ctx, "demo"); err != nil {
+		t.Fatalf("RemoveLabeled: %v", err)
+	}
+	want := []string{"container:stale", "network:demo_default", "volume:demo_data"}
+	if !reflect.DeepEqual(server.Removed, want) {
+		t.Errorf("removed = %v, want %v", server.Removed, want)
+	}
+}
+
+func TestWriteComposeOverride(t *testing.T) {
+	compose := filepath.Join(t.TempDir(), "docker-compose.yml")
+	err := os.WriteFile(compose, []byte(`services:
+  web:
+    build: ./web
+  db:
+    image: postgres:16
+volumes:
+  pgdata: {}
+  shared:
+    external: true
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := Labels{Exercise: "demo", Session: "abc"}.Map()
+	path, err := writeComposeOverride(compose, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var override struct {
+		Services map[string]struct {
+			Labels map[string]string `json:"labels"`
+			Build  *struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"build"`
+		} `json:"services"`
+		Volumes  map[string]interface{} `json:"volumes"`
+		Networks map[string]interface{} `json:"networks"`
+	}
+	if err := yaml.Unmarshal(data, &override); err != nil {
+		t.Fatalf("override is not valid YAML: %v\n%s", err, data)
+	}
+	for _, name := range []string{"web", "db"} {
+		if !reflect.DeepEqual(override.Services[name].Labels, labels) {
+			t.Errorf("service %s labels = %v", name, override.Services[name].Labels)
+		}
+	}
+	if build := override.Services["web"].Build; build == nil || !reflect.DeepEqual(build.Labels, labels) {
+		t.Errorf("web build labels = %+v", build)
+	}
+	if override.Services["db"].Build != nil {
+		t.Error("a service without a build got build labels")
+	}
+	if _, ok := override.Volumes["pgdata"]; !ok {
+		t.Error("volume pgdata is not labelled")
+	}
+	if _, ok := override.Volumes["shared"]; ok {
+		t.Error("external volume shared was labelled")
+	}
+	if _, ok := override.Networks["default"]; !ok {
+		t.Error("default network is not labelled")
+	}
+}
@@ -2,10 +2,15 @@ package environment
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	"sigs.k8s.io/yaml"
+
 	"gymctl/internal/runner"
 )
 
@@ -13,6 +18,28 @@ type KindManager struct {
 	ClusterName string
 }
 
+// KindContext returns the kubeconfig context kind creates for a cluster.
+func KindContext(clusterName string) string {
+	return "kind-" + clusterName
+}
+
+// KindConfigHash fingerprints a kind cluster configuration. Formatting,
+// comments and key order do not change it, so two exercises that ask for
+// the same cluster get the same hash. The empty configuration hashes like
+// any other.
+func KindConfigHash(kindConfig string) (string, error) {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(kindConfig), &value); err != nil {
+		return "", fmt.Errorf("parse kind config: %w", err)
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func (k KindManager) Create(ctx context.Context, kindConfig string) error {
 	args := []string{"create", "cluster", "--name", k.ClusterName}
 	var tempFile string
@@ -56,6 +83,14 @@ func (k KindManager) Exists(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+// UseContext makes the cluster's context the current one in the
+// kubeconfig, as creating the cluster does, so the learner's kubectl
+// reaches a reused cluster too.
+func (k KindManager) UseContext(ctx context.Context) error {
+	_, err := runner.Run(ctx, "kubectl", "config", "use-context", KindContext(k.ClusterName))
+	return err
+}
+
 func (k KindManager) LoadImage(ctx context.Context, image string) error {
 	_, err := runner.Run(ctx, "kind", "load", "docker-image", "--name", k.ClusterName, image)
 	return err
@@ -0,0 +1,54 @@
+package environment
+
+import (
+	"context"
+	"testing"
+
+	"gymctl/internal/runner"
+)
+
+func TestKindConfigHash(t *testing.T) {
+	hash := func(config string) string {
+		t.Helper()
+		value, err := KindConfigHash(config)
+		if err != nil {
+			t.Fatalf("KindConfigHash(%q): %v", config, err)
+		}
+		return value
+	}
+
+	base := hash("kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodes:\n  - role: control-plane\n")
+	reordered := hash("# same cluster\napiVersion: kind.x-k8s.io/v1alpha4\nkind: Cluster\nnodes:\n- role: control-plane\n")
+	if base != reordered {
+		t.Error("formatting and key order changed the hash")
+	}
+	if base == hash("kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodes:\n  - role: control-plane\n  - role: worker\n") {
+		t.Error("an extra node did not change the hash")
+	}
+	if hash("") == base {
+		t.Error("the default configuration hashed like a custom one")
+	}
+	if _, err := KindConfigHash("nodes: [unterminated"); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
+
+func TestUseContextSelectsReusedCluster(t *testing.T) {
+	replayer := runner.NewReplayer(runner.Fixture{Interactions: []runner.Interaction{
+		{Command: "kind", Args: []string{"get", "clusters"}, Stdout: "gym-a\ngym-b\n", Combined: "gym-a\ngym-b\n"},
+		{Command: "kubectl", Args: []string{"config", "use-context", "kind-gym-a"}, Combined: `Switched to context "kind-gym-a".`},
+	}})
+	ctx := runner.WithExecutor(context.Background(), replayer)
+
+	manager := KindManager{ClusterName: "gym-a"}
+	exists, err := manager.Exists(ctx)
+	if err != nil || !exists {
+		t.Fatalf("Exists = %v, %v", exists, err)
+	}
+	if err := manager.UseContext(ctx); err != nil {
+		t.Fatalf("UseContext: %v", err)
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("expected commands were not run: %+v", unused)
+	}
+}
@@ -2,26 +2,184 @@ package environment
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"gymctl/internal/kube"
 	"gymctl/internal/runner"
+	"gymctl/internal/scenario"
 )
 
-func ApplyManifests(ctx context.Context, namespace string, manifestPaths []string) error {
+// ApplyManifests applies each manifest file, server-side through the native
+// client when a kubeconfig is available and with kubectl otherwise. Every
+// object, and the pod template of every workload, gets labels.
+func ApplyManifests(ctx context.Context, namespace string, manifestPaths []string, labels map[string]string) error {
+	if client, err := kube.ClientFor(ctx); err == nil {
+		for _, path := range manifestPaths {
+			objects, err := readManifest(path)
+			if err != nil {
+				return err
+			}
+			for _, obj := range objects {
+				LabelObject(obj, labels)
+				if err := client.Apply(ctx, namespace, obj); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+		}
+		return nil
+	}
+
 	for _, path := range manifestPaths {
+		if len(labels) > 0 {
+			labelled, err := writeLabelledManifest(path, labels)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(labelled)
+			path = labelled
+		}
 		args := []string{"apply", "-f", path}
 		if namespace != "" {
 			args = append(args, "-n", namespace)
 		}
-		if _, err := runner.Run(ctx, "kubectl", args...); err != nil {
+		if _, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// LabelObject adds labels to the object's metadata and, for workloads, to
+// the pods it creates. Selectors are left alone so existing objects keep
+// matching their pods.
+func LabelObject(obj kube.Object, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	addLabels(obj, labels)
+	spec, _ := obj["spec"].(kube.Object)
+	if jobTemplate, ok := spec["jobTemplate"].(kube.Object); ok {
+		spec, _ = jobTemplate["spec"].(kube.Object)
+	}
+	if template, ok := spec["template"].(kube.Object); ok {
+		addLabels(template, labels)
+	}
+}
+
+func addLabels(obj kube.Object, labels map[string]string) {
+	metadata, _ := obj["metadata"].(kube.Object)
+	if metadata == nil {
+		metadata = kube.Object{}
+		obj["metadata"] = metadata
+	}
+	existing, _ := metadata["labels"].(kube.Object)
+	if existing == nil {
+		existing = kube.Object{}
+		metadata["labels"] = existing
+	}
+	for key, value := range labels {
+		existing[key] = value
+	}
+}
+
+// writeLabelledManifest writes the labelled objects of a manifest to a
+// temporary file for kubectl. The caller removes it.
+func writeLabelledManifest(path string, labels map[string]string) (string, error) {
+	objects, err := readManifest(path)
+	if err != nil {
+		return "", err
+	}
+	items := make([]interface{}, 0, len(objects))
+	for _, obj := range objects {
+		LabelObject(obj, labels)
+		items = append(items, obj)
+	}
+	data, err := json.Marshal(kube.Object{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp("", "gymctl-manifest-*.json")
+	if err != nil {
+		return "", fmt.Errorf("create labelled manifest: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("write labelled manifest: %w", err)
+	}
+	return file.Name(), nil
+}
+
+func readManifest(path string) ([]kube.Object, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := kube.DecodeManifests(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return objects, nil
+}
+
+// DeleteLabeled deletes the objects labelled as belonging to exercise, in
+// every namespace, for each kind the manifests declare. It is how an
+// exercise is cleaned out of a cluster gymctl does not own.
+func DeleteLabeled(ctx context.Context, exercise string, manifestPaths []string) error {
+	type typeMeta struct{ apiVersion, kind string }
+	var types []typeMeta
+	var kinds []string
+	seen := map[typeMeta]bool{}
+	for _, path := range manifestPaths {
+		objects, err := readManifest(path)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			apiVersion, _ := obj["apiVersion"].(string)
+			kind, _ := obj["kind"].(string)
+			meta := typeMeta{apiVersion, kind}
+			if kind != "" && !seen[meta] {
+				seen[meta] = true
+				types = append(types, meta)
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	selector := ExerciseSelector(exercise)
+
+	client, err := kube.ClientFor(ctx)
+	if err != nil {
+		args := []string{"delete", strings.Join(kinds, ","), "--all-namespaces", "-l", selector, "--ignore-not-found"}
+		_, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...)
+		return err
+	}
+	for _, meta := range types {
+		objects, err := client.List(ctx, "", meta.kind, selector)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			// List items do not repeat their type.
+			obj["apiVersion"], obj["kind"] = meta.apiVersion, meta.kind
+			if err := client.Delete(ctx, "", obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WaitForCondition blocks until resource reports condition, watching it
+// natively when possible and through `kubectl wait` otherwise.
 func WaitForCondition(ctx context.Context, namespace string, resource string, condition string, timeout string) error {
 	if resource == "" || condition == "" {
 		return nil
@@ -29,15 +187,179 @@ func WaitForCondition(ctx context.Context, namespace string, resource string, co
 	if timeout == "" {
 		timeout = "120s"
 	}
+	if client, err := kube.ClientFor(ctx); err == nil {
+		duration, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+		}
+		return client.WaitForCondition(ctx, namespace, resource, condition, duration)
+	}
 
 	args := []string{"wait", "--for=condition=" + condition, "--timeout=" + timeout, resource}
 	if namespace != "" {
 		args = append(args, "-n", namespace)
 	}
-	_, err := runner.Run(ctx, "kubectl", args...)
+	_, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...)
 	return err
 }
 
+// wipeTimeout bounds how long a namespace may take to terminate.
+const wipeTimeout = 2 * time.Minute
+
+// builtinNamespaces belong to the cluster and are never deleted when an
+// exercise is wiped.
+var builtinNamespaces = map[string]bool{
+	"default":            true,
+	"kube-system":        true,
+	"kube-public":        true,
+	"kube-node-lease":    true,
+	"local-path-storage": true,
+}
+
+// CheckedObject names an object an exercise's checks read. In a reused
+// cluster it may be left over from the learner's last attempt.
+type CheckedObject struct {
+	Namespace string
+	Ref       string
+}
+
+// CheckedObjects lists the named objects the checks read, in the check's
+// namespace or in namespace when it has none.
+func CheckedObjects(checks []scenario.Check, namespace string) []CheckedObject {
+	var objects []CheckedObject
+	seen := map[CheckedObject]bool{}
+	var visit func(checks []scenario.Check)
+	visit = func(checks []scenario.Check) {
+		for _, check := range checks {
+			if check.IsComposite() {
+				visit(check.Checks)
+				continue
+			}
+			if _, name := kube.SplitRef(check.Resource); name == "" {
+				continue
+			}
+			object := CheckedObject{Namespace: namespace, Ref: check.Resource}
+			if check.Namespace != "" {
+				object.Namespace = check.Namespace
+			}
+			if !seen[object] {
+				seen[object] = true
+				objects = append(objects, object)
+			}
+		}
+	}
+	visit(checks)
+	return objects
+}
+
+// namespaceName returns the namespace a ref such as "ns/backend" names.
+func namespaceName(ref string) (string, bool) {
+	resourceType, name := kube.SplitRef(ref)
+	switch strings.ToLower(resourceType) {
+	case "namespace", "namespaces", "ns":
+		return name, true
+	}
+	return "", false
+}
+
+// WipeManifests removes what an earlier run of an exercise left in a reused
+// cluster. The exercise namespace and any namespace the manifests create
+// are deleted with everything in them; objects the manifests put anywhere
+// else are deleted one by one, and so are the objects the checks read,
+// which the learner may have created in a namespace that stays, such as
+// default.
+func WipeManifests(ctx context.Context, namespace string, manifestPaths []string, checked []CheckedObject) error {
+	if client, err := kube.ClientFor(ctx); err == nil {
+		return wipeNative(ctx, client, namespace, manifestPaths, checked)
+	}
+
+	for _, path := range manifestPaths {
+		args := []string{"delete", "-f", path, "--ignore-not-found", "--timeout=" + wipeTimeout.String()}
+		if namespace != "" {
+			args = append(args, "-n", namespace)
+		}
+		if _, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...); err != nil {
+			return err
+		}
+	}
+	namespaces := []string{namespace}
+	for _, object := range checked {
+		if name, ok := namespaceName(object.Ref); ok {
+			namespaces = append(namespaces, name)
+			continue
+		}
+		args := []string{"delete", object.Ref, "--ignore-not-found", "--timeout=" + wipeTimeout.String()}
+		if object.Namespace != "" {
+			args = append(args, "-n", object.Namespace)
+		}
+		if _, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...); err != nil {
+			return err
+		}
+	}
+	for _, name := range namespaces {
+		if name == "" || builtinNamespaces[name] {
+			continue
+		}
+		_, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, "delete", "namespace", name, "--ignore-not-found", "--timeout="+wipeTimeout.String())...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func wipeNative(ctx context.Context, client *kube.Client, namespace string, manifestPaths []string, checked []CheckedObject) error {
+	var namespaces []string
+	owned := map[string]bool{}
+	own := func(name string) {
+		if name != "" && !builtinNamespaces[name] && !owned[name] {
+			owned[name] = true
+			namespaces = append(namespaces, name)
+		}
+	}
+	own(namespace)
+
+	for _, path := range manifestPaths {
+		objects, err := readManifest(path)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			if obj["kind"] == "Namespace" && obj["apiVersion"] == "v1" {
+				metadata, _ := obj["metadata"].(kube.Object)
+				name, _ := metadata["name"].(string)
+				own(name)
+				continue
+			}
+			if err := client.Delete(ctx, namespace, obj); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	for _, object := range checked {
+		if name, ok := namespaceName(object.Ref); ok {
+			own(name)
+			continue
+		}
+		if err := client.DeleteRef(ctx, object.Namespace, object.Ref); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range namespaces {
+		obj := kube.Object{"apiVersion": "v1", "kind": "Namespace", "metadata": kube.Object{"name": name}}
+		if err := client.Delete(ctx, "", obj); err != nil {
+			return err
+		}
+	}
+	for _, name := range namespaces {
+		if err := client.WaitForDeletion(ctx, "", "namespace/"+name, wipeTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func ResolveManifestPaths(baseDir string, manifests []string) []string {
 	paths := make([]string, 0, len(manifests))
 	for _, manifest := range manifests {
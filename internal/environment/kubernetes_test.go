@@ -0,0 +1,143 @@
+package environment
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gymctl/internal/kube"
+	"gymctl/internal/kube/kubetest"
+	"gymctl/internal/scenario"
+)
+
+func TestWipeManifests(t *testing.T) {
+	server := kubetest.NewServer(t)
+	ctx := kube.WithClient(context.Background(), server.Client())
+
+	manifest := filepath.Join(t.TempDir(), "setup.yaml")
+	err := os.WriteFile(manifest, []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: extra
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared-settings
+  namespace: default
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyManifests(ctx, "shop", []string{manifest}, nil); err != nil {
+		t.Fatalf("ApplyManifests: %v", err)
+	}
+	// Left behind by the learner, and by nobody.
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "Namespace", "metadata": kube.Object{"name": "shop"}})
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "Pod", "metadata": kube.Object{"name": "debug", "namespace": "shop"}})
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "Pod", "metadata": kube.Object{"name": "keep", "namespace": "default"}})
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "ConfigMap", "metadata": kube.Object{"name": "app-config", "namespace": "default"}})
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "Namespace", "metadata": kube.Object{"name": "backend"}})
+
+	checked := CheckedObjects([]scenario.Check{
+		{Type: "exists", Resource: "configmap/app-config", Namespace: "default"},
+		{Type: scenario.CheckAllOf, Checks: []scenario.Check{
+			{Type: "exists", Resource: "namespace/backend"},
+			{Type: "exists", Resource: "configmap/app-config", Namespace: "default"},
+		}},
+		{Type: "jsonpath", Resource: "pods"},
+	}, "shop")
+	want := []CheckedObject{{"default", "configmap/app-config"}, {"shop", "namespace/backend"}}
+	if !reflect.DeepEqual(checked, want) {
+		t.Errorf("CheckedObjects = %v, want %v", checked, want)
+	}
+	if err := WipeManifests(ctx, "shop", []string{manifest}, checked); err != nil {
+		t.Fatalf("WipeManifests: %v", err)
+	}
+
+	for _, path := range []string{
+		"/api/v1/namespaces/extra",
+		"/api/v1/namespaces/shop",
+		"/api/v1/namespaces/shop/pods/debug",
+		"/api/v1/namespaces/default/configmaps/shared-settings",
+		"/apis/apps/v1/namespaces/shop/deployments/web",
+		"/api/v1/namespaces/default/configmaps/app-config",
+		"/api/v1/namespaces/backend",
+	} {
+		if _, ok := server.Get(path); ok {
+			t.Errorf("%s survived the wipe", path)
+		}
+	}
+	if _, ok := server.Get("/api/v1/namespaces/default/pods/keep"); !ok {
+		t.Error("an object outside the exercise was deleted")
+	}
+}
+
+func TestApplyManifestsLabelsAndDeleteLabeled(t *testing.T) {
+	server := kubetest.NewServer(t)
+	ctx := kube.WithClient(context.Background(), server.Client())
+
+	manifest := filepath.Join(t.TempDir(), "setup.yaml")
+	err := os.WriteFile(manifest, []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := Labels{Exercise: "demo", Variant: "slow", Session: "abc"}
+	if err := ApplyManifests(ctx, "shop", []string{manifest}, labels.Map()); err != nil {
+		t.Fatalf("ApplyManifests: %v", err)
+	}
+
+	deployment, ok := server.Get("/apis/apps/v1/namespaces/shop/deployments/web")
+	if !ok {
+		t.Fatal("deployment was not applied")
+	}
+	want := kube.Object{"app": "web", LabelExercise: "demo", LabelVariant: "slow", LabelSession: "abc", LabelManagedBy: ManagedBy}
+	if got := field(deployment, "metadata", "labels"); !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v", got)
+	}
+	if got := field(deployment, "spec", "template", "metadata", "labels"); !reflect.DeepEqual(got, want) {
+		t.Errorf("pod template labels = %v", got)
+	}
+	if got := field(deployment, "spec", "selector", "matchLabels"); !reflect.DeepEqual(got, kube.Object{"app": "web"}) {
+		t.Errorf("selector was changed: %v", got)
+	}
+
+	server.Set(kube.Object{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": kube.Object{"name": "mine", "namespace": "shop"}})
+	if err := DeleteLabeled(ctx, "demo", []string{manifest}); err != nil {
+		t.Fatalf("DeleteLabeled: %v", err)
+	}
+	if _, ok := server.Get("/apis/apps/v1/namespaces/shop/deployments/web"); ok {
+		t.Error("labelled deployment survived")
+	}
+	if _, ok := server.Get("/apis/apps/v1/namespaces/shop/deployments/mine"); !ok {
+		t.Error("an unlabelled deployment was deleted")
+	}
+}
+
+func field(obj kube.Object, path ...string) kube.Object {
+	for _, key := range path {
+		obj, _ = obj[key].(kube.Object)
+	}
+	return obj
+}
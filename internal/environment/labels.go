@@ -0,0 +1,89 @@
+package environment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Labels put on every container, image, volume, network and Kubernetes
+// object gymctl provisions, so cleanup can find exactly what belongs to an
+// exercise.
+const (
+	LabelExercise  = "gym.exercise"
+	LabelVariant   = "gym.variant"
+	LabelSession   = "gym.session"
+	LabelManagedBy = "gym.managed-by"
+	// ManagedBy is the value of LabelManagedBy.
+	ManagedBy = "gymctl"
+)
+
+// Labels identifies one provisioning of an exercise.
+type Labels struct {
+	Exercise string
+	Variant  string
+	// Session is a random ID that changes with every start and reset.
+	Session string
+}
+
+// NewSessionID returns a fresh session ID.
+func NewSessionID() string {
+	var buf [6]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(fmt.Sprintf("read random session ID: %v", err))
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// Map returns the label set, or nil for the zero Labels so callers can
+// skip labelling resources that belong to no exercise.
+func (l Labels) Map() map[string]string {
+	if l.Exercise == "" {
+		return nil
+	}
+	labels := map[string]string{
+		LabelExercise:  l.Exercise,
+		LabelManagedBy: ManagedBy,
+	}
+	if l.Variant != "" {
+		labels[LabelVariant] = l.Variant
+	}
+	if l.Session != "" {
+		labels[LabelSession] = l.Session
+	}
+	return labels
+}
+
+// Selector matches everything gymctl provisioned for the exercise, in
+// any session.
+func (l Labels) Selector() string {
+	return ExerciseSelector(l.Exercise)
+}
+
+// ExerciseSelector is the label selector for the resources of an exercise.
+func ExerciseSelector(exercise string) string {
+	return LabelExercise + "=" + exercise + "," + LabelManagedBy + "=" + ManagedBy
+}
+
+// ExerciseFilters returns the docker `label` filter values matching the
+// resources of an exercise.
+func ExerciseFilters(exercise string) []string {
+	return strings.Split(ExerciseSelector(exercise), ",")
+}
+
+// labelArgs renders labels as repeated `--label key=value` flags in a
+// stable order.
+func labelArgs(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var args []string
+	for _, key := range keys {
+		args = append(args, "--label", key+"="+labels[key])
+	}
+	return args
+}
@@ -0,0 +1,376 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/kube"
+	"gymctl/internal/runner"
+)
+
+// Kinds of provisioned resources, in the order they are safe to remove:
+// containers before the networks and volumes they use, images last.
+const (
+	KindContainer = "container"
+	KindNamespace = "namespace"
+	KindCluster   = "cluster"
+	KindNetwork   = "network"
+	KindVolume    = "volume"
+	KindImage     = "image"
+)
+
+var removalOrder = map[string]int{
+	KindContainer: 0,
+	KindNamespace: 1,
+	KindCluster:   2,
+	KindNetwork:   3,
+	KindVolume:    4,
+	KindImage:     5,
+}
+
+// Resource is something gymctl provisioned, found by its labels or, for
+// kind clusters, by name.
+type Resource struct {
+	Kind string
+	Name string
+	// ID is what removal needs; the name when empty.
+	ID string
+	// KubeContext is the kubeconfig context of a namespace.
+	KubeContext string
+	Labels      map[string]string
+	// Created is zero when unknown.
+	Created time.Time
+	// Size is in bytes, zero when unknown.
+	Size int64
+}
+
+// Exercise returns the exercise the resource was labelled with.
+func (r Resource) Exercise() string {
+	return r.Labels[LabelExercise]
+}
+
+// Session returns the session the resource was labelled with.
+func (r Resource) Session() string {
+	return r.Labels[LabelSession]
+}
+
+// DockerResources lists every container, image, volume and network that
+// carries gymctl's labels.
+func DockerResources(ctx context.Context) ([]Resource, error) {
+	filters := []string{LabelManagedBy + "=" + ManagedBy}
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		return dockerResourcesAPI(ctx, client, filters)
+	}
+	return dockerResourcesCLI(ctx, filters)
+}
+
+func dockerResourcesAPI(ctx context.Context, client *dockerapi.Client, filters []string) ([]Resource, error) {
+	var resources []Resource
+	containers, err := client.ContainerList(ctx, true, dockerapi.Filters{"label": filters})
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		name := container.ID
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		resources = append(resources, Resource{
+			Kind: KindContainer, Name: name, ID: container.ID,
+			Labels: container.Labels, Created: time.Unix(container.Created, 0),
+		})
+	}
+
+	images, err := client.ImageList(ctx, dockerapi.Filters{"label": filters})
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		resources = append(resources, Resource{
+			Kind: KindImage, Name: imageName(image.ID, image.RepoTags), ID: image.ID,
+			Labels: image.Labels, Created: time.Unix(image.Created, 0), Size: image.Size,
+		})
+	}
+
+	volumes, err := client.VolumeList(ctx, dockerapi.Filters{"label": filters})
+	if err != nil {
+		return nil, err
+	}
+	var sizes map[string]int64
+	if len(volumes) > 0 {
+		if sizes, err = client.VolumeSizes(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for _, volume := range volumes {
+		resources = append(resources, Resource{
+			Kind: KindVolume, Name: volume.Name,
+			Labels: volume.Labels, Created: parseTime(volume.CreatedAt), Size: sizes[volume.Name],
+		})
+	}
+
+	networks, err := client.NetworkList(ctx, dockerapi.Filters{"label": filters})
+	if err != nil {
+		return nil, err
+	}
+	for _, network := range networks {
+		resources = append(resources, Resource{
+			Kind: KindNetwork, Name: network.Name, ID: network.ID,
+			Labels: network.Labels, Created: parseTime(network.Created),
+		})
+	}
+	return resources, nil
+}
+
+// inspected covers the fields gymctl reads from `docker <kind> inspect`
+// for containers, images, volumes and networks alike.
+type inspected struct {
+	ID        string            `json:"Id"`
+	Name      string            `json:"Name"`
+	RepoTags  []string          `json:"RepoTags"`
+	Created   string            `json:"Created"`
+	CreatedAt string            `json:"CreatedAt"`
+	Size      int64             `json:"Size"`
+	Labels    map[string]string `json:"Labels"`
+	Config    struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+func dockerResourcesCLI(ctx context.Context, filters []string) ([]Resource, error) {
+	var filterArgs []string
+	for _, filter := range filters {
+		filterArgs = append(filterArgs, "--filter", "label="+filter)
+	}
+	listings := []struct {
+		kind string
+		list []string
+	}{
+		{KindContainer, []string{"ps", "-aq"}},
+		{KindImage, []string{"images", "-q"}},
+		{KindVolume, []string{"volume", "ls", "-q"}},
+		{KindNetwork, []string{"network", "ls", "-q"}},
+	}
+
+	var resources []Resource
+	for _, listing := range listings {
+		output, err := runner.Output(ctx, "docker", append(listing.list, filterArgs...)...)
+		if err != nil {
+			return nil, err
+		}
+		ids := uniqueLines(output)
+		if len(ids) == 0 {
+			continue
+		}
+		output, err = runner.Output(ctx, "docker", append([]string{listing.kind, "inspect"}, ids...)...)
+		if err != nil {
+			return nil, err
+		}
+		var items []inspected
+		if err := json.Unmarshal([]byte(output), &items); err != nil {
+			return nil, fmt.Errorf("parse docker %s inspect: %w", listing.kind, err)
+		}
+		for _, item := range items {
+			resource := Resource{Kind: listing.kind, Name: strings.TrimPrefix(item.Name, "/"), ID: item.ID, Labels: item.Labels, Size: item.Size}
+			if resource.Labels == nil {
+				resource.Labels = item.Config.Labels
+			}
+			resource.Created = parseTime(item.Created)
+			if resource.Created.IsZero() {
+				resource.Created = parseTime(item.CreatedAt)
+			}
+			if listing.kind == KindImage {
+				resource.Name = imageName(item.ID, item.RepoTags)
+			}
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+// Namespaces lists the namespaces carrying gymctl's labels in the cluster
+// ctx points at.
+func Namespaces(ctx context.Context) ([]Resource, error) {
+	selector := LabelManagedBy + "=" + ManagedBy
+	var items []kube.Object
+	if client, err := kube.ClientFor(ctx); err == nil {
+		if items, err = client.List(ctx, "", "namespaces", selector); err != nil {
+			return nil, err
+		}
+	} else {
+		output, err := runner.Output(ctx, "kubectl", kube.KubectlArgs(ctx, "get", "namespaces", "-l", selector, "-o", "json")...)
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Items []kube.Object `json:"items"`
+		}
+		if err := json.Unmarshal([]byte(output), &list); err != nil {
+			return nil, fmt.Errorf("parse namespaces: %w", err)
+		}
+		items = list.Items
+	}
+
+	resources := make([]Resource, 0, len(items))
+	for _, item := range items {
+		metadata, _ := item["metadata"].(kube.Object)
+		name, _ := metadata["name"].(string)
+		created, _ := metadata["creationTimestamp"].(string)
+		labels := map[string]string{}
+		rawLabels, _ := metadata["labels"].(kube.Object)
+		for key, value := range rawLabels {
+			labels[key], _ = value.(string)
+		}
+		resources = append(resources, Resource{
+			Kind: KindNamespace, Name: name, KubeContext: kube.ContextName(ctx),
+			Labels: labels, Created: parseTime(created),
+		})
+	}
+	return resources, nil
+}
+
+// Clusters lists the kind clusters on this machine with their creation
+// time, taken from their control plane container.
+func (k KindManager) Clusters(ctx context.Context) ([]Resource, error) {
+	output, err := runner.Run(ctx, "kind", "get", "clusters")
+	if err != nil {
+		return nil, err
+	}
+	var clusters []Resource
+	for _, name := range splitLines(output) {
+		// kind prints this instead of an empty list.
+		if strings.HasPrefix(name, "No kind clusters found") {
+			continue
+		}
+		cluster := Resource{Kind: KindCluster, Name: name}
+		cluster.Created, _ = clusterCreated(ctx, name)
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+func clusterCreated(ctx context.Context, cluster string) (time.Time, error) {
+	filter := "io.x-k8s.kind.cluster=" + cluster
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		nodes, err := client.ContainerList(ctx, true, dockerapi.Filters{"label": {filter}})
+		if err != nil || len(nodes) == 0 {
+			return time.Time{}, err
+		}
+		return time.Unix(nodes[0].Created, 0), nil
+	}
+	output, err := runner.Output(ctx, "docker", "ps", "-a", "--filter", "label="+filter, "--format", "{{.CreatedAt}}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range splitLines(output) {
+		return time.Parse("2006-01-02 15:04:05 -0700 MST", line)
+	}
+	return time.Time{}, nil
+}
+
+// RemoveResource deletes one provisioned resource. Resources that are
+// already gone are not an error.
+func RemoveResource(ctx context.Context, resource Resource) error {
+	id := resource.ID
+	if id == "" {
+		id = resource.Name
+	}
+	switch resource.Kind {
+	case KindCluster:
+		return KindManager{ClusterName: resource.Name}.Delete(ctx)
+	case KindNamespace:
+		if resource.KubeContext != "" {
+			ctx = kube.WithContextName(ctx, resource.KubeContext)
+		}
+		if client, err := kube.ClientFor(ctx); err == nil {
+			return client.Delete(ctx, "", kube.Object{"apiVersion": "v1", "kind": "Namespace", "metadata": kube.Object{"name": resource.Name}})
+		}
+		_, err := runner.Run(ctx, "kubectl", kube.KubectlArgs(ctx, "delete", "namespace", resource.Name, "--ignore-not-found", "--wait=false")...)
+		return err
+	}
+
+	if client, err := dockerapi.ClientFor(ctx); err == nil {
+		var err error
+		switch resource.Kind {
+		case KindContainer:
+			err = client.ContainerRemove(ctx, id)
+		case KindImage:
+			err = client.ImageRemove(ctx, id, true)
+		case KindVolume:
+			err = client.VolumeRemove(ctx, id)
+		case KindNetwork:
+			err = client.NetworkRemove(ctx, id)
+		default:
+			return fmt.Errorf("unknown resource kind %q", resource.Kind)
+		}
+		if dockerapi.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	args := map[string][]string{
+		KindContainer: {"rm", "-f", id},
+		KindImage:     {"rmi", "-f", id},
+		KindVolume:    {"volume", "rm", "-f", id},
+		KindNetwork:   {"network", "rm", id},
+	}[resource.Kind]
+	if args == nil {
+		return fmt.Errorf("unknown resource kind %q", resource.Kind)
+	}
+	_, err := runner.Run(ctx, "docker", args...)
+	return err
+}
+
+// SortForRemoval orders resources so that removing them in order does not
+// trip over dependencies.
+func SortForRemoval(resources []Resource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if a.Kind != b.Kind {
+			return removalOrder[a.Kind] < removalOrder[b.Kind]
+		}
+		return a.Name < b.Name
+	})
+}
+
+func imageName(id string, tags []string) string {
+	for _, tag := range tags {
+		if tag != "<none>:<none>" {
+			return tag
+		}
+	}
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return id
+}
+
+func parseTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+func uniqueLines(output string) []string {
+	seen := map[string]bool{}
+	var lines []string
+	for _, line := range splitLines(output) {
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,96 @@
+package environment
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/dockerapi/dockertest"
+	"gymctl/internal/kube"
+	"gymctl/internal/kube/kubetest"
+)
+
+func TestDockerResources(t *testing.T) {
+	server := dockertest.NewServer(t)
+	ctx := dockerapi.WithClient(context.Background(), server.Client())
+
+	labels := Labels{Exercise: "demo", Session: "abc"}.Map()
+	built := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	image := dockerapi.Image{Created: built.Format(time.RFC3339Nano), Size: 40 << 20}
+	image.Config.Labels = labels
+	server.AddImage("api:latest", image)
+	server.AddImage("redis:7", dockerapi.Image{})
+	container := dockerapi.Container{}
+	container.Config.Labels = labels
+	server.AddContainer("api", container, "")
+	server.AddContainer("someone-else", dockerapi.Container{}, "")
+	server.AddVolume(dockerapi.Volume{Name: "demo_data", Labels: labels, CreatedAt: built.Format(time.RFC3339)}, 1<<20)
+	server.AddNetwork(dockerapi.Network{Name: "demo_default", Labels: labels})
+	server.AddNetwork(dockerapi.Network{Name: "bridge"})
+
+	resources, err := DockerResources(ctx)
+	if err != nil {
+		t.Fatalf("DockerResources: %v", err)
+	}
+	SortForRemoval(resources)
+	var got []string
+	for _, resource := range resources {
+		got = append(got, resource.Kind+":"+resource.Name)
+		if resource.Exercise() != "demo" || resource.Session() != "abc" {
+			t.Errorf("%s %s labels = %v", resource.Kind, resource.Name, resource.Labels)
+		}
+	}
+	want := []string{"container:api", "network:demo_default", "volume:demo_data", "image:api:latest"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("resources = %v, want %v", got, want)
+	}
+	if volume := resources[2]; volume.Size != 1<<20 || !volume.Created.Equal(built) {
+		t.Errorf("volume = %+v", volume)
+	}
+	if image := resources[3]; image.Size != 40<<20 || !image.Created.Equal(built) {
+		t.Errorf("image = %+v", image)
+	}
+
+	for _, resource := range resources {
+		if err := RemoveResource(ctx, resource); err != nil {
+			t.Errorf("RemoveResource(%s %s): %v", resource.Kind, resource.Name, err)
+		}
+	}
+	if err := RemoveResource(ctx, resources[0]); err != nil {
+		t.Errorf("removing a container twice: %v", err)
+	}
+	if left, _ := DockerResources(ctx); len(left) != 0 {
+		t.Errorf("left after removal: %+v", left)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	server := kubetest.NewServer(t)
+	ctx := kube.WithClient(context.Background(), server.Client())
+
+	labels := map[string]interface{}{LabelExercise: "demo", LabelManagedBy: ManagedBy, LabelSession: "abc"}
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "Namespace", "metadata": kube.Object{
+		"name": "shop", "labels": labels, "creationTimestamp": "2026-03-01T12:00:00Z",
+	}})
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "Namespace", "metadata": kube.Object{"name": "kube-system"}})
+
+	namespaces, err := Namespaces(ctx)
+	if err != nil {
+		t.Fatalf("Namespaces: %v", err)
+	}
+	if len(namespaces) != 1 || namespaces[0].Name != "shop" || namespaces[0].Exercise() != "demo" {
+		t.Fatalf("namespaces = %+v", namespaces)
+	}
+	if want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC); !namespaces[0].Created.Equal(want) {
+		t.Errorf("created = %v", namespaces[0].Created)
+	}
+
+	if err := RemoveResource(ctx, namespaces[0]); err != nil {
+		t.Fatalf("RemoveResource: %v", err)
+	}
+	if _, ok := server.Get("/api/v1/namespaces/shop"); ok {
+		t.Error("namespace shop was not deleted")
+	}
+}
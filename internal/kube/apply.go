@@ -0,0 +1,114 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ApplyFile server-side applies every object in a (multi-document) YAML
+// manifest. Objects without a namespace of their own go to namespace.
+func (c *Client) ApplyFile(ctx context.Context, namespace, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	objects, err := DecodeManifests(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, obj := range objects {
+		if err := c.Apply(ctx, namespace, obj); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// DecodeManifests splits a YAML stream into objects, skipping empty
+// documents and flattening List kinds.
+func DecodeManifests(data []byte) ([]Object, error) {
+	var objects []Object
+	for _, document := range splitDocuments(data) {
+		jsonDocument, err := yaml.YAMLToJSON(document)
+		if err != nil {
+			return nil, err
+		}
+		var obj Object
+		if err := json.Unmarshal(jsonDocument, &obj); err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		if obj["kind"] == "List" {
+			items, _ := obj["items"].([]interface{})
+			for _, item := range items {
+				if itemObj, ok := item.(Object); ok {
+					objects = append(objects, itemObj)
+				}
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// splitDocuments splits a YAML stream at its "---" separator lines.
+func splitDocuments(data []byte) [][]byte {
+	var documents [][]byte
+	var current []byte
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if rest, ok := bytes.CutPrefix(line, []byte("---")); ok && len(bytes.TrimSpace(rest)) == 0 {
+			documents = append(documents, current)
+			current = nil
+			continue
+		}
+		current = append(current, line...)
+	}
+	return append(documents, current)
+}
+
+// Apply server-side applies one object.
+func (c *Client) Apply(ctx context.Context, namespace string, obj Object) error {
+	resource, name, err := c.objectResource(ctx, obj)
+	if err != nil {
+		return err
+	}
+	kind := resource.Kind
+	if resource.Namespaced {
+		if objNamespace := objectNamespace(obj); objNamespace != "" {
+			namespace = objNamespace
+		}
+		if namespace == "" {
+			namespace = c.defaultNamespace()
+		}
+		obj["metadata"].(Object)["namespace"] = namespace
+	}
+
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"fieldManager": {FieldManager}, "force": {"true"}}
+	resp, err := c.do(ctx, http.MethodPatch, resource.path(namespace, name), query, "application/apply-patch+yaml", body)
+	if err != nil {
+		return fmt.Errorf("apply %s/%s: %w", kind, name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) defaultNamespace() string {
+	if c.Namespace != "" {
+		return c.Namespace
+	}
+	return "default"
+}
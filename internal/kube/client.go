@@ -0,0 +1,205 @@
+// Package kube talks to the Kubernetes API server directly, so checks and
+// environment setup do not have to shell out to kubectl for every read.
+// Pod exec, which needs a streaming protocol, and users authenticating
+// through exec plugins or auth providers are left to kubectl.
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// FieldManager is the server-side apply field manager used for manifests.
+const FieldManager = "gymctl"
+
+// Client is a minimal Kubernetes API client.
+type Client struct {
+	// Server is the API server base URL, e.g. https://127.0.0.1:6443.
+	Server string
+	// Namespace is the kubeconfig context namespace, if any.
+	Namespace string
+	HTTP      *http.Client
+	Token     string
+	Username  string
+	Password  string
+
+	mu        sync.Mutex
+	discovery *discovery
+}
+
+// discoveries shares discovery results between clients of one server, so
+// building a client per check does not repeat discovery.
+var discoveries sync.Map
+
+// NewClient returns a client for the given configuration.
+func NewClient(config *Config) *Client {
+	cached, _ := discoveries.LoadOrStore(config.Server, &discovery{})
+	return &Client{
+		discovery: cached.(*discovery),
+		Server:    config.Server,
+		Namespace: config.Namespace,
+		HTTP:      config.HTTPClient(),
+		Token:     config.Token,
+		Username:  config.Username,
+		Password:  config.Password,
+	}
+}
+
+// StatusError is a failed API request, decoded from the server's Status
+// object when it sent one.
+type StatusError struct {
+	Code    int
+	Reason  string
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Reason != "" {
+		return fmt.Sprintf("%s (%d)", e.Reason, e.Code)
+	}
+	return fmt.Sprintf("kubernetes API returned status %d", e.Code)
+}
+
+// IsNotFound reports whether err is a 404 from the API server.
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound
+}
+
+// Object is a decoded Kubernetes object.
+type Object = map[string]interface{}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, contentType string, body []byte) (*http.Response, error) {
+	target := c.Server + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, decodeStatusError(resp)
+}
+
+func decodeStatusError(resp *http.Response) error {
+	statusErr := &StatusError{Code: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var status struct {
+		Kind    string `json:"kind"`
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &status) == nil && status.Kind == "Status" {
+		statusErr.Reason = status.Reason
+		statusErr.Message = status.Message
+	} else {
+		statusErr.Message = strings.TrimSpace(string(data))
+	}
+	return statusErr
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, into interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	return decoder.Decode(into)
+}
+
+// Get fetches the object named by ref, for example "deployment/web" or
+// "configmaps/app-config", in namespace.
+func (c *Client) Get(ctx context.Context, namespace, ref string) (Object, error) {
+	resource, name, err := c.resolveRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("resource %q has no name", ref)
+	}
+	var obj Object
+	if err := c.getJSON(ctx, resource.path(namespace, name), nil, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// List returns the objects of the resource type in namespace matching the
+// label selector.
+func (c *Client) List(ctx context.Context, namespace, resourceType, labelSelector string) ([]Object, error) {
+	resource, err := c.resolveType(ctx, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if labelSelector != "" {
+		query.Set("labelSelector", labelSelector)
+	}
+	var list struct {
+		Items []Object `json:"items"`
+	}
+	if err := c.getJSON(ctx, resource.path(namespace, ""), query, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Exists reports whether the object named by ref exists.
+func (c *Client) Exists(ctx context.Context, namespace, ref string) (bool, error) {
+	_, err := c.Get(ctx, namespace, ref)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SplitRef splits "type/name" into its parts. A bare type has no name.
+func SplitRef(ref string) (string, string) {
+	resourceType, name, _ := strings.Cut(ref, "/")
+	return resourceType, name
+}
+
+func (c *Client) resolveRef(ctx context.Context, ref string) (apiResource, string, error) {
+	resourceType, name := SplitRef(ref)
+	resource, err := c.resolveType(ctx, resourceType)
+	return resource, name, err
+}
@@ -0,0 +1,255 @@
+package kube_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gymctl/internal/kube"
+	"gymctl/internal/kube/kubetest"
+)
+
+func deployment(available string) kube.Object {
+	return kube.Object{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "jerry"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": available},
+		}},
+	}
+}
+
+func pod(name string) kube.Object {
+	return kube.Object{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "jerry",
+			"labels":    map[string]interface{}{"app": "web"},
+		},
+	}
+}
+
+func TestGetResolvesResourceNames(t *testing.T) {
+	server := kubetest.NewServer(t)
+	server.Set(deployment("True"))
+	client := server.Client()
+	ctx := context.Background()
+
+	for _, ref := range []string{"deployment/web", "deployments/web", "deploy/web", "deployments.apps/web", "Deployment/web"} {
+		obj, err := client.Get(ctx, "jerry", ref)
+		if err != nil {
+			t.Errorf("Get(%q) error: %v", ref, err)
+			continue
+		}
+		if got := kube.ConditionStatus(obj, "Available"); got != "True" {
+			t.Errorf("Get(%q) Available = %q, want True", ref, got)
+		}
+	}
+
+	if _, err := client.Get(ctx, "jerry", "widgets/web"); err == nil {
+		t.Error("expected an error for an unknown resource type")
+	}
+}
+
+func TestGetRediscoversInstalledCRD(t *testing.T) {
+	var installed atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api":
+			io.WriteString(w, `{"versions": ["v1"]}`)
+		case "/api/v1":
+			io.WriteString(w, `{"groupVersion": "v1", "resources": []}`)
+		case "/apis":
+			if !installed.Load() {
+				io.WriteString(w, `{"groups": []}`)
+				return
+			}
+			io.WriteString(w, `{"groups": [{"name": "gym.example.com", "preferredVersion": {"groupVersion": "gym.example.com/v1"}}]}`)
+		case "/apis/gym.example.com/v1":
+			io.WriteString(w, `{"groupVersion": "gym.example.com/v1", "resources": [{"name": "widgets", "singularName": "widget", "namespaced": true, "kind": "Widget"}]}`)
+		case "/apis/gym.example.com/v1/namespaces/jerry/widgets/web":
+			io.WriteString(w, `{"apiVersion": "gym.example.com/v1", "kind": "Widget", "metadata": {"name": "web"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := kube.NewClient(&kube.Config{Server: server.URL})
+	ctx := context.Background()
+
+	if _, err := client.Get(ctx, "jerry", "widget/web"); err == nil {
+		t.Fatal("expected an error before the CRD is installed")
+	}
+	installed.Store(true)
+	if _, err := client.Get(ctx, "jerry", "widget/web"); err != nil {
+		t.Errorf("Get after installing the CRD: %v", err)
+	}
+}
+
+func TestExistsAndNotFound(t *testing.T) {
+	server := kubetest.NewServer(t)
+	server.Set(kube.Object{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]interface{}{"name": "backend"}})
+	client := server.Client()
+	ctx := context.Background()
+
+	exists, err := client.Exists(ctx, "default", "namespace/backend")
+	if err != nil || !exists {
+		t.Errorf("namespace/backend exists = %t, %v", exists, err)
+	}
+	exists, err = client.Exists(ctx, "default", "namespace/frontend")
+	if err != nil || exists {
+		t.Errorf("namespace/frontend exists = %t, %v", exists, err)
+	}
+
+	_, err = client.Get(ctx, "jerry", "configmap/missing")
+	if !kube.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestApplyFile(t *testing.T) {
+	server := kubetest.NewServer(t)
+	client := server.Client()
+
+	manifest := filepath.Join(t.TempDir(), "app.yaml")
+	content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  LOG_LEVEL: debug
+---
+# an empty document
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: other
+spec:
+  replicas: 2
+`
+	if err := os.WriteFile(manifest, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ApplyFile(context.Background(), "jerry", manifest); err != nil {
+		t.Fatalf("ApplyFile: %v", err)
+	}
+
+	want := []string{
+		"/api/v1/namespaces/jerry/configmaps/app-config",
+		"/apis/apps/v1/namespaces/other/deployments/web",
+	}
+	if strings.Join(server.Applied, ",") != strings.Join(want, ",") {
+		t.Errorf("applied %v, want %v", server.Applied, want)
+	}
+	obj, _ := server.Get(want[0])
+	if got, _ := kube.JSONPath("{.data.LOG_LEVEL}", obj); got != "debug" {
+		t.Errorf("LOG_LEVEL = %q, want debug", got)
+	}
+}
+
+func TestWaitForCondition(t *testing.T) {
+	server := kubetest.NewServer(t)
+	server.Set(deployment("False"))
+	client := server.Client()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.Set(deployment("True"))
+	}()
+	if err := client.WaitForCondition(context.Background(), "jerry", "deployment/web", "Available", 5*time.Second); err != nil {
+		t.Fatalf("WaitForCondition: %v", err)
+	}
+
+	err := client.WaitForCondition(context.Background(), "jerry", "deployment/web", "Available=False", 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	server := kubetest.NewServer(t)
+	server.Set(deployment("False"))
+	client := server.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan kube.WatchEvent, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Watch(ctx, "jerry", "deployments", func(event kube.WatchEvent) {
+			events <- event
+		})
+	}()
+
+	// The watch starts asynchronously; keep changing the deployment until
+	// a change comes through.
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-events:
+			metadata, _ := event.Object["metadata"].(map[string]interface{})
+			if event.Type != "MODIFIED" || metadata["name"] != "web" {
+				t.Errorf("event = %s %v", event.Type, metadata["name"])
+			}
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("Watch: %v", err)
+			}
+			return
+		case <-ticker.C:
+			server.Set(pod("web-a"))
+			server.Set(deployment("True"))
+		case <-ctx.Done():
+			t.Fatal("no watch event received")
+		}
+	}
+}
+
+func TestLogs(t *testing.T) {
+	server := kubetest.NewServer(t)
+	server.Set(deployment("True"))
+	server.Set(pod("web-b"))
+	server.Set(pod("web-a"))
+	server.SetLogs("jerry", "web-a", "connected to db\n")
+	server.SetLogs("jerry", "web-b", "connection refused\n")
+	client := server.Client()
+	ctx := context.Background()
+
+	logs, err := client.Logs(ctx, "jerry", "pod/web-b", kube.LogOptions{Container: "app", Since: time.Minute})
+	if err != nil || logs != "connection refused\n" {
+		t.Errorf("pod logs = %q, %v", logs, err)
+	}
+	if got := server.LogQueries[len(server.LogQueries)-1]; got != "container=app&sinceSeconds=60" {
+		t.Errorf("log query = %q", got)
+	}
+
+	logs, err = client.Logs(ctx, "jerry", "deployment/web", kube.LogOptions{})
+	if err != nil || logs != "connected to db\n" {
+		t.Errorf("deployment logs = %q, %v", logs, err)
+	}
+
+	logs, err = client.SelectorLogs(ctx, "jerry", "app=web", kube.LogOptions{})
+	if err != nil || logs != "connected to db\nconnection refused\n" {
+		t.Errorf("selector logs = %q, %v", logs, err)
+	}
+	if got := server.LogQueries[len(server.LogQueries)-1]; got != "tailLines=10" {
+		t.Errorf("selector log query = %q, want tailLines=10", got)
+	}
+}
@@ -0,0 +1,253 @@
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ErrNoConfig is returned when no kubeconfig with a current context is found.
+var ErrNoConfig = errors.New("no kubeconfig with a current context")
+
+// ErrUnsupportedAuth is returned for kubeconfig users that authenticate
+// through an exec plugin or auth provider, as EKS, GKE, AKS and OIDC setups
+// do. Only kubectl can run those.
+var ErrUnsupportedAuth = errors.New("authentication needs kubectl")
+
+// Config is the part of a kubeconfig needed to reach the current cluster.
+type Config struct {
+	Server    string
+	Namespace string
+	Token     string
+	Username  string
+	Password  string
+	TLS       *tls.Config
+}
+
+type kubeconfig struct {
+	CurrentContext string         `json:"current-context"`
+	Clusters       []namedCluster `json:"clusters"`
+	Users          []namedUser    `json:"users"`
+	Contexts       []namedContext `json:"contexts"`
+}
+
+type namedCluster struct {
+	Name    string `json:"name"`
+	Cluster struct {
+		Server                   string `json:"server"`
+		CertificateAuthority     string `json:"certificate-authority"`
+		CertificateAuthorityData []byte `json:"certificate-authority-data"`
+		InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		TLSServerName            string `json:"tls-server-name"`
+	} `json:"cluster"`
+	// baseDir is the directory of the kubeconfig file that defined the
+	// entry. Like kubectl, relative paths are resolved against it.
+	baseDir string
+}
+
+type namedUser struct {
+	Name string `json:"name"`
+	User struct {
+		Token                 string      `json:"token"`
+		TokenFile             string      `json:"tokenFile"`
+		ClientCertificate     string      `json:"client-certificate"`
+		ClientCertificateData []byte      `json:"client-certificate-data"`
+		ClientKey             string      `json:"client-key"`
+		ClientKeyData         []byte      `json:"client-key-data"`
+		Username              string      `json:"username"`
+		Password              string      `json:"password"`
+		Exec                  interface{} `json:"exec"`
+		AuthProvider          interface{} `json:"auth-provider"`
+	} `json:"user"`
+	// baseDir is as for namedCluster.
+	baseDir string
+}
+
+type namedContext struct {
+	Name    string `json:"name"`
+	Context struct {
+		Cluster   string `json:"cluster"`
+		User      string `json:"user"`
+		Namespace string `json:"namespace"`
+	} `json:"context"`
+}
+
+// KubeconfigPaths returns the kubeconfig files kubectl would read: the
+// entries of $KUBECONFIG, or ~/.kube/config.
+func KubeconfigPaths() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		var paths []string
+		for _, path := range filepath.SplitList(env) {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// LoadConfig reads the current context from the given kubeconfig files.
+// Like kubectl, the first file to set a value wins.
+func LoadConfig(paths ...string) (*Config, error) {
+	return LoadConfigContext("", paths...)
+}
+
+// LoadConfigContext reads the named context, or the current context when
+// contextName is empty, from the given kubeconfig files.
+func LoadConfigContext(contextName string, paths ...string) (*Config, error) {
+	var merged kubeconfig
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read kubeconfig: %w", err)
+		}
+		var file kubeconfig
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parse kubeconfig %s: %w", path, err)
+		}
+		if merged.CurrentContext == "" {
+			merged.CurrentContext = file.CurrentContext
+		}
+		baseDir := filepath.Dir(path)
+		for i := range file.Clusters {
+			file.Clusters[i].baseDir = baseDir
+		}
+		for i := range file.Users {
+			file.Users[i].baseDir = baseDir
+		}
+		merged.Clusters = append(merged.Clusters, file.Clusters...)
+		merged.Users = append(merged.Users, file.Users...)
+		merged.Contexts = append(merged.Contexts, file.Contexts...)
+	}
+	if contextName != "" {
+		merged.CurrentContext = contextName
+	}
+	if merged.CurrentContext == "" {
+		return nil, ErrNoConfig
+	}
+	return merged.resolve()
+}
+
+func (k kubeconfig) resolve() (*Config, error) {
+	config := &Config{}
+	contextFound := false
+	var clusterName, userName string
+	for _, context := range k.Contexts {
+		if context.Name == k.CurrentContext {
+			clusterName = context.Context.Cluster
+			userName = context.Context.User
+			config.Namespace = context.Context.Namespace
+			contextFound = true
+			break
+		}
+	}
+	if !contextFound {
+		return nil, fmt.Errorf("kubeconfig context %q not found", k.CurrentContext)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	clusterFound := false
+	for _, cluster := range k.Clusters {
+		if cluster.Name != clusterName {
+			continue
+		}
+		clusterFound = true
+		config.Server = strings.TrimSuffix(cluster.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = cluster.Cluster.InsecureSkipTLSVerify
+		tlsConfig.ServerName = cluster.Cluster.TLSServerName
+		ca, err := readData(cluster.Cluster.CertificateAuthorityData, cluster.Cluster.CertificateAuthority, cluster.baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("read certificate authority: %w", err)
+		}
+		if len(ca) > 0 {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("invalid certificate authority for cluster %q", clusterName)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		break
+	}
+	if !clusterFound || config.Server == "" {
+		return nil, fmt.Errorf("kubeconfig cluster %q not found", clusterName)
+	}
+
+	for _, user := range k.Users {
+		if user.Name != userName {
+			continue
+		}
+		switch {
+		case user.User.Exec != nil:
+			return nil, fmt.Errorf("kubeconfig user %q uses an exec plugin: %w", userName, ErrUnsupportedAuth)
+		case user.User.AuthProvider != nil:
+			return nil, fmt.Errorf("kubeconfig user %q uses an auth provider: %w", userName, ErrUnsupportedAuth)
+		}
+		config.Token = user.User.Token
+		if config.Token == "" && user.User.TokenFile != "" {
+			token, err := os.ReadFile(resolveFile(user.User.TokenFile, user.baseDir))
+			if err != nil {
+				return nil, fmt.Errorf("read token file: %w", err)
+			}
+			config.Token = strings.TrimSpace(string(token))
+		}
+		config.Username = user.User.Username
+		config.Password = user.User.Password
+
+		cert, err := readData(user.User.ClientCertificateData, user.User.ClientCertificate, user.baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("read client certificate: %w", err)
+		}
+		key, err := readData(user.User.ClientKeyData, user.User.ClientKey, user.baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("read client key: %w", err)
+		}
+		if len(cert) > 0 && len(key) > 0 {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+		break
+	}
+
+	config.TLS = tlsConfig
+	return config, nil
+}
+
+// HTTPClient returns an HTTP client that authenticates to the cluster.
+func (c *Config) HTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = c.TLS
+	return &http.Client{Transport: transport, Timeout: 2 * time.Minute}
+}
+
+func readData(data []byte, file, baseDir string) ([]byte, error) {
+	if len(data) > 0 || file == "" {
+		return data, nil
+	}
+	return os.ReadFile(resolveFile(file, baseDir))
+}
+
+func resolveFile(path, baseDir string) string {
+	if filepath.IsAbs(path) || baseDir == "" {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	if err := os.WriteFile(first, []byte(`apiVersion: v1
+kind: Config
+current-context: kind-jerry-gym
+contexts:
+- name: kind-jerry-gym
+  context:
+    cluster: kind-jerry-gym
+    user: kind-jerry-gym
+    namespace: jerry
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`apiVersion: v1
+kind: Config
+current-context: other
+clusters:
+- name: kind-jerry-gym
+  cluster:
+    server: https://127.0.0.1:6443/
+    insecure-skip-tls-verify: true
+users:
+- name: kind-jerry-gym
+  user:
+    token: secret
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(filepath.Join(dir, "missing"), first, second)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.Server != "https://127.0.0.1:6443" {
+		t.Errorf("Server = %q", config.Server)
+	}
+	if config.Namespace != "jerry" || config.Token != "secret" {
+		t.Errorf("Namespace = %q, Token = %q", config.Namespace, config.Token)
+	}
+	if !config.TLS.InsecureSkipVerify {
+		t.Error("expected insecure-skip-tls-verify to be honoured")
+	}
+
+	if _, err := LoadConfigContext("kind-other", first, second); err == nil {
+		t.Error("expected an error for a context that is not in the kubeconfig")
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing")); !errors.Is(err, ErrNoConfig) {
+		t.Errorf("expected ErrNoConfig, got %v", err)
+	}
+}
+
+func TestLoadConfigResolvesPathsPerFile(t *testing.T) {
+	contexts := t.TempDir()
+	users := t.TempDir()
+	if err := os.WriteFile(filepath.Join(users, "token"), []byte("from-users-dir\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	first := filepath.Join(contexts, "config")
+	if err := os.WriteFile(first, []byte(`current-context: dev
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example:6443
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(users, "config")
+	if err := os.WriteFile(second, []byte(`users:
+- name: dev
+  user:
+    tokenFile: token
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(first, second)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.Token != "from-users-dir" {
+		t.Errorf("Token = %q, want the token file next to the file defining the user", config.Token)
+	}
+}
+
+func TestLoadConfigRejectsPluginAuth(t *testing.T) {
+	for name, user := range map[string]string{
+		"exec": `exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args: [eks, get-token, --cluster-name, jerry]`,
+		"auth-provider": `auth-provider:
+      name: oidc
+      config:
+        idp-issuer-url: https://issuer.example`,
+	} {
+		path := filepath.Join(t.TempDir(), "config")
+		content := `current-context: cloud
+contexts:
+- name: cloud
+  context:
+    cluster: cloud
+    user: cloud
+clusters:
+- name: cloud
+  cluster:
+    server: https://cloud.example
+users:
+- name: cloud
+  user:
+    ` + user + "\n"
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); !errors.Is(err, ErrUnsupportedAuth) {
+			t.Errorf("%s: expected ErrUnsupportedAuth, got %v", name, err)
+		}
+	}
+}
+
+func TestScriptEnvPinsContext(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: kind-other
+contexts:
+- name: kind-gym-a
+  context: {cluster: a, user: a}
+- name: kind-other
+  context: {cluster: other, user: a}
+clusters:
+- name: a
+  cluster: {server: "https://127.0.0.1:6443"}
+- name: other
+  cluster: {server: "https://127.0.0.1:7443"}
+users:
+- name: a
+  user: {token: secret}
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	env, cleanup, err := ScriptEnv(context.Background())
+	if err != nil || len(env) != 0 {
+		t.Fatalf("ScriptEnv without a context = %v, %v", env, err)
+	}
+	cleanup()
+
+	env, cleanup, err = ScriptEnv(WithContextName(context.Background(), "kind-gym-a"))
+	if err != nil {
+		t.Fatalf("ScriptEnv: %v", err)
+	}
+	values := map[string]string{}
+	for _, entry := range env {
+		key, value, _ := strings.Cut(entry, "=")
+		values[key] = value
+	}
+	if values["GYM_KUBE_CONTEXT"] != "kind-gym-a" {
+		t.Errorf("GYM_KUBE_CONTEXT = %q", values["GYM_KUBE_CONTEXT"])
+	}
+	paths := filepath.SplitList(values["KUBECONFIG"])
+	if len(paths) != 2 || paths[1] != kubeconfig {
+		t.Fatalf("KUBECONFIG = %q", values["KUBECONFIG"])
+	}
+	config, err := LoadConfig(paths...)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.Server != "https://127.0.0.1:6443" {
+		t.Errorf("Server = %q, want the pinned context's cluster", config.Server)
+	}
+
+	cleanup()
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Errorf("pinning kubeconfig not removed: %v", err)
+	}
+}
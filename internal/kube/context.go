@@ -0,0 +1,99 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrKubectl is returned by ClientFor when the caller asked for the
+// kubectl fallback.
+var ErrKubectl = errors.New("native kubernetes client disabled")
+
+type clientKey struct{}
+type kubectlKey struct{}
+type contextNameKey struct{}
+
+// WithClient returns a context whose Kubernetes calls use client.
+func WithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// WithKubectl returns a context whose Kubernetes calls go through kubectl
+// instead of the native client.
+func WithKubectl(ctx context.Context) context.Context {
+	return context.WithValue(ctx, kubectlKey{}, true)
+}
+
+// WithContextName returns a context whose Kubernetes calls target the
+// named kubeconfig context instead of the current one.
+func WithContextName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextNameKey{}, name)
+}
+
+// ContextName returns the kubeconfig context set by WithContextName.
+func ContextName(ctx context.Context) string {
+	name, _ := ctx.Value(contextNameKey{}).(string)
+	return name
+}
+
+// KubectlArgs prefixes args with --context when ctx names a kubeconfig
+// context, so kubectl fallbacks hit the same cluster as the client.
+func KubectlArgs(ctx context.Context, args ...string) []string {
+	if name := ContextName(ctx); name != "" {
+		return append([]string{"--context", name}, args...)
+	}
+	return args
+}
+
+// ClientFor returns the client carried by ctx or, failing that, one built
+// from the kubeconfig. Callers fall back to kubectl on any error.
+func ClientFor(ctx context.Context) (*Client, error) {
+	if client, ok := ctx.Value(clientKey{}).(*Client); ok && client != nil {
+		return client, nil
+	}
+	if disabled, _ := ctx.Value(kubectlKey{}).(bool); disabled {
+		return nil, ErrKubectl
+	}
+	config, err := LoadConfigContext(ContextName(ctx), KubeconfigPaths()...)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(config), nil
+}
+
+// ScriptEnv returns the environment for scripts that run kubectl
+// themselves, pinning them to the kubeconfig context ctx names: KUBECONFIG
+// lists first a file that only selects that context, then the kubeconfig
+// files as before, and GYM_KUBE_CONTEXT names it. Call cleanup once the
+// script is done. Without a context name the environment is left alone.
+func ScriptEnv(ctx context.Context) (env []string, cleanup func(), err error) {
+	name := ContextName(ctx)
+	if name == "" {
+		return nil, func() {}, nil
+	}
+	file, err := os.CreateTemp("", "gymctl-kubeconfig-*.yaml")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create kubeconfig: %w", err)
+	}
+	cleanup = func() { os.Remove(file.Name()) }
+	// Like kubectl, LoadConfigContext takes current-context from the first
+	// file that sets it, and the rest of the configuration from all of them.
+	_, err = fmt.Fprintf(file, "apiVersion: v1\nkind: Config\ncurrent-context: %q\n", name)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("write kubeconfig: %w", err)
+	}
+	paths := append([]string{file.Name()}, KubeconfigPaths()...)
+	env = []string{
+		"KUBECONFIG=" + strings.Join(paths, string(filepath.ListSeparator)),
+		"GYM_KUBE_CONTEXT=" + name,
+	}
+	return env, cleanup, nil
+}
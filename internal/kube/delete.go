@@ -0,0 +1,94 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Delete removes one object, cascading to its dependents in the
+// background. An object that is already gone is not an error.
+func (c *Client) Delete(ctx context.Context, namespace string, obj Object) error {
+	resource, name, err := c.objectResource(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if objNamespace := objectNamespace(obj); objNamespace != "" {
+		namespace = objNamespace
+	}
+	return c.deleteObject(ctx, resource, namespace, name)
+}
+
+// DeleteRef removes the object named by ref, for example
+// "configmap/app-config", in namespace. An object that is already gone is
+// not an error.
+func (c *Client) DeleteRef(ctx context.Context, namespace, ref string) error {
+	resource, name, err := c.resolveRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("resource %q has no name", ref)
+	}
+	return c.deleteObject(ctx, resource, namespace, name)
+}
+
+func (c *Client) deleteObject(ctx context.Context, resource apiResource, namespace, name string) error {
+	if resource.Namespaced && namespace == "" {
+		namespace = c.defaultNamespace()
+	}
+	query := url.Values{"propagationPolicy": {"Background"}}
+	resp, err := c.do(ctx, http.MethodDelete, resource.path(namespace, name), query, "", nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("delete %s/%s: %w", resource.Kind, name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// WaitForDeletion polls the object named by ref until the server no longer
+// has it, which for a namespace means its finalizers have run.
+func (c *Client) WaitForDeletion(ctx context.Context, namespace, ref string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		exists, err := c.Exists(ctx, namespace, ref)
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out after %s waiting for %s to be deleted", timeout, ref)
+		}
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func (c *Client) objectResource(ctx context.Context, obj Object) (apiResource, string, error) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(Object)
+	name, _ := metadata["name"].(string)
+	if apiVersion == "" || kind == "" || name == "" {
+		return apiResource{}, "", errors.New("object is missing apiVersion, kind or metadata.name")
+	}
+	resource, err := c.resolveKind(ctx, apiVersion, kind)
+	return resource, name, err
+}
+
+func objectNamespace(obj Object) string {
+	metadata, _ := obj["metadata"].(Object)
+	namespace, _ := metadata["namespace"].(string)
+	return namespace
+}
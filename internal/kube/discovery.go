@@ -0,0 +1,203 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// apiResource is one entry of the server's discovery documents.
+type apiResource struct {
+	Group      string
+	Version    string
+	Name       string
+	Singular   string
+	Kind       string
+	ShortNames []string
+	Namespaced bool
+}
+
+// path builds the REST path of the resource collection, or of one object
+// when name is set.
+func (r apiResource) path(namespace, name string) string {
+	path := "/api/" + r.Version
+	if r.Group != "" {
+		path = "/apis/" + r.Group + "/" + r.Version
+	}
+	if r.Namespaced && namespace != "" {
+		path += "/namespaces/" + url.PathEscape(namespace)
+	}
+	path += "/" + r.Name
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+	return path
+}
+
+func (r apiResource) matches(name string) bool {
+	if name == r.Name || name == r.Singular || strings.EqualFold(name, r.Kind) {
+		return true
+	}
+	for _, short := range r.ShortNames {
+		if name == short {
+			return true
+		}
+	}
+	return false
+}
+
+type discovery struct {
+	mu        sync.Mutex
+	loaded    bool
+	resources []apiResource
+}
+
+type resourceList struct {
+	GroupVersion string `json:"groupVersion"`
+	Resources    []struct {
+		Name         string   `json:"name"`
+		SingularName string   `json:"singularName"`
+		Namespaced   bool     `json:"namespaced"`
+		Kind         string   `json:"kind"`
+		ShortNames   []string `json:"shortNames"`
+	} `json:"resources"`
+}
+
+// discover loads the core and preferred group versions. A successful
+// result is kept for the lifetime of the client unless refresh asks for it
+// to be loaded again; failures are retried.
+func (c *Client) discover(ctx context.Context, refresh bool) ([]apiResource, error) {
+	c.mu.Lock()
+	if c.discovery == nil {
+		c.discovery = &discovery{}
+	}
+	d := c.discovery
+	c.mu.Unlock()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.loaded && !refresh {
+		return d.resources, nil
+	}
+
+	var core struct {
+		Versions []string `json:"versions"`
+	}
+	if err := c.getJSON(ctx, "/api", nil, &core); err != nil {
+		return nil, err
+	}
+	groupVersions := append([]string(nil), core.Versions...)
+
+	var groups struct {
+		Groups []struct {
+			PreferredVersion struct {
+				GroupVersion string `json:"groupVersion"`
+			} `json:"preferredVersion"`
+		} `json:"groups"`
+	}
+	if err := c.getJSON(ctx, "/apis", nil, &groups); err != nil {
+		return nil, err
+	}
+	for _, group := range groups.Groups {
+		groupVersions = append(groupVersions, group.PreferredVersion.GroupVersion)
+	}
+
+	var resources []apiResource
+	for _, groupVersion := range groupVersions {
+		found, err := c.groupVersionResources(ctx, groupVersion)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, found...)
+	}
+	d.resources = resources
+	d.loaded = true
+	return resources, nil
+}
+
+func (c *Client) groupVersionResources(ctx context.Context, groupVersion string) ([]apiResource, error) {
+	group, version := "", groupVersion
+	path := "/api/" + groupVersion
+	if g, v, ok := strings.Cut(groupVersion, "/"); ok {
+		group, version = g, v
+		path = "/apis/" + groupVersion
+	}
+	var list resourceList
+	if err := c.getJSON(ctx, path, nil, &list); err != nil {
+		return nil, fmt.Errorf("discover %s: %w", groupVersion, err)
+	}
+	var resources []apiResource
+	for _, resource := range list.Resources {
+		if strings.Contains(resource.Name, "/") {
+			continue // subresource
+		}
+		singular := resource.SingularName
+		if singular == "" {
+			singular = strings.ToLower(resource.Kind)
+		}
+		resources = append(resources, apiResource{
+			Group:      group,
+			Version:    version,
+			Name:       resource.Name,
+			Singular:   singular,
+			Kind:       resource.Kind,
+			ShortNames: resource.ShortNames,
+			Namespaced: resource.Namespaced,
+		})
+	}
+	return resources, nil
+}
+
+// resolveType maps a kubectl style type such as "deploy", "deployment",
+// "deployments.apps" or "Deployment" to an API resource. The core group
+// wins over others when a name is ambiguous, as it does in kubectl. An
+// unknown type triggers discovery once more, since a CRD may have been
+// installed since it was loaded.
+func (c *Client) resolveType(ctx context.Context, resourceType string) (apiResource, error) {
+	name, group, hasGroup := strings.Cut(resourceType, ".")
+	for _, refresh := range []bool{false, true} {
+		resources, err := c.discover(ctx, refresh)
+		if err != nil {
+			return apiResource{}, err
+		}
+		for _, resource := range resources {
+			if hasGroup && resource.Group != group {
+				continue
+			}
+			if resource.matches(name) {
+				return resource, nil
+			}
+		}
+	}
+	return apiResource{}, fmt.Errorf("the server doesn't have a resource type %q", resourceType)
+}
+
+// resolveKind maps an object's apiVersion and kind to its API resource.
+func (c *Client) resolveKind(ctx context.Context, apiVersion, kind string) (apiResource, error) {
+	group, version := "", apiVersion
+	if g, v, ok := strings.Cut(apiVersion, "/"); ok {
+		group, version = g, v
+	}
+	resources, err := c.discover(ctx, false)
+	if err != nil {
+		return apiResource{}, err
+	}
+	for _, resource := range resources {
+		if resource.Group == group && resource.Version == version && resource.Kind == kind {
+			return resource, nil
+		}
+	}
+	// The preferred version may not be the one the manifest uses.
+	extra, err := c.groupVersionResources(ctx, apiVersion)
+	if err != nil {
+		return apiResource{}, err
+	}
+	for _, resource := range extra {
+		if resource.Kind == kind {
+			return resource, nil
+		}
+	}
+	return apiResource{}, fmt.Errorf("no resource for %s %s", apiVersion, kind)
+}
@@ -0,0 +1,413 @@
+package kube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedJSONPath is returned by JSONPath for templates outside the
+// subset it implements.
+var ErrUnsupportedJSONPath = errors.New("unsupported jsonpath")
+
+// JSONPath evaluates a kubectl style JSONPath template against obj and
+// formats the result the way `kubectl get -o jsonpath=...` does: text
+// outside braces is copied, multiple matches are joined with spaces, maps
+// and lists are printed as JSON and missing keys yield nothing.
+//
+// The supported subset covers field access, ['quoted'] keys, indexes
+// (negative ones count from the end), [*], .. recursive descent and
+// [?(@.path op value)] filters with ==, !=, <, <=, >, >= or a bare
+// existence test. Templates beyond that, such as range/end blocks or
+// slices, fail with ErrUnsupportedJSONPath so callers can hand them to
+// kubectl.
+func JSONPath(template string, obj interface{}) (string, error) {
+	var out strings.Builder
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			out.WriteString(rest)
+			break
+		}
+		out.WriteString(rest[:open])
+		end := matchingBrace(rest, open)
+		if end < 0 {
+			return "", fmt.Errorf("unclosed action in jsonpath %q", template)
+		}
+		expr := strings.TrimSpace(rest[open+1 : end])
+		rest = rest[end+1:]
+
+		if strings.HasPrefix(expr, "range") || expr == "end" {
+			return "", fmt.Errorf("%w %q: range is not supported", ErrUnsupportedJSONPath, template)
+		}
+		if quoted, ok := unquote(expr); ok {
+			out.WriteString(quoted)
+			continue
+		}
+		steps, err := parsePath(expr)
+		if err != nil {
+			return "", fmt.Errorf("%w %q: %v", ErrUnsupportedJSONPath, template, err)
+		}
+		values := evaluate(steps, []interface{}{obj})
+		for i, value := range values {
+			if i > 0 {
+				out.WriteByte(' ')
+			}
+			out.WriteString(formatValue(value))
+		}
+	}
+	return out.String(), nil
+}
+
+func matchingBrace(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepWildcard
+	stepRecursive
+	stepFilter
+)
+
+type step struct {
+	kind  stepKind
+	field string
+	index int
+	// filter
+	path     []step
+	operator string
+	operand  interface{}
+}
+
+func parsePath(expr string) ([]step, error) {
+	expr = strings.TrimPrefix(expr, "$")
+	var steps []step
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			if strings.HasPrefix(expr[i:], "..") {
+				i += 2
+				name, n := readName(expr[i:])
+				if name == "" {
+					return nil, fmt.Errorf("expected a field name after ..")
+				}
+				steps = append(steps, step{kind: stepRecursive, field: name})
+				i += n
+				continue
+			}
+			i++
+			name, n := readName(expr[i:])
+			i += n
+			switch name {
+			case "":
+			case "*":
+				steps = append(steps, step{kind: stepWildcard})
+			default:
+				steps = append(steps, step{kind: stepField, field: name})
+			}
+		case '[':
+			end := matchingBracket(expr, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", expr)
+			}
+			parsed, err := parseBracket(strings.TrimSpace(expr[i+1 : end]))
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, parsed)
+			i = end + 1
+		default:
+			name, n := readName(expr[i:])
+			if n == 0 {
+				return nil, fmt.Errorf("unexpected %q in %q", expr[i], expr)
+			}
+			steps = append(steps, step{kind: stepField, field: name})
+			i += n
+		}
+	}
+	return steps, nil
+}
+
+// readName reads a field name up to the next '.' or '['; "\." escapes a
+// literal dot as kubectl allows.
+func readName(s string) (string, int) {
+	var name strings.Builder
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			name.WriteByte(s[i+1])
+			i += 2
+			continue
+		}
+		if c == '.' || c == '[' {
+			break
+		}
+		name.WriteByte(c)
+		i++
+	}
+	return name.String(), i
+}
+
+func matchingBracket(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseBracket(inner string) (step, error) {
+	switch {
+	case inner == "*":
+		return step{kind: stepWildcard}, nil
+	case strings.HasPrefix(inner, "?(") && strings.HasSuffix(inner, ")"):
+		return parseFilter(strings.TrimSpace(inner[2 : len(inner)-1]))
+	}
+	if quoted, ok := unquote(inner); ok {
+		return step{kind: stepField, field: quoted}, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, fmt.Errorf("unsupported subscript [%s]", inner)
+	}
+	return step{kind: stepIndex, index: index}, nil
+}
+
+func parseFilter(expr string) (step, error) {
+	filter := step{kind: stepFilter}
+	left := expr
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if i := strings.Index(expr, operator); i >= 0 {
+			filter.operator = operator
+			left = strings.TrimSpace(expr[:i])
+			right := strings.TrimSpace(expr[i+len(operator):])
+			if quoted, ok := unquote(right); ok {
+				filter.operand = quoted
+			} else if number, err := strconv.ParseFloat(right, 64); err == nil {
+				filter.operand = number
+			} else if right == "true" || right == "false" {
+				filter.operand = right == "true"
+			} else {
+				return step{}, fmt.Errorf("unsupported filter value %q", right)
+			}
+			break
+		}
+	}
+	if !strings.HasPrefix(left, "@") {
+		return step{}, fmt.Errorf("filter must start with @: %q", expr)
+	}
+	path, err := parsePath(left[1:])
+	if err != nil {
+		return step{}, err
+	}
+	filter.path = path
+	return filter, nil
+}
+
+func unquote(s string) (string, bool) {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+func evaluate(steps []step, values []interface{}) []interface{} {
+	for _, s := range steps {
+		var next []interface{}
+		for _, value := range values {
+			next = append(next, apply(s, value)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func apply(s step, value interface{}) []interface{} {
+	switch s.kind {
+	case stepField:
+		if m, ok := value.(map[string]interface{}); ok {
+			if child, ok := m[s.field]; ok {
+				return []interface{}{child}
+			}
+		}
+	case stepIndex:
+		if list, ok := value.([]interface{}); ok {
+			index := s.index
+			if index < 0 {
+				index += len(list)
+			}
+			if index >= 0 && index < len(list) {
+				return []interface{}{list[index]}
+			}
+		}
+	case stepWildcard:
+		return children(value)
+	case stepRecursive:
+		var found []interface{}
+		walk(value, func(v interface{}) {
+			if m, ok := v.(map[string]interface{}); ok {
+				if child, ok := m[s.field]; ok {
+					found = append(found, child)
+				}
+			}
+		})
+		return found
+	case stepFilter:
+		var kept []interface{}
+		for _, child := range children(value) {
+			if s.keep(child) {
+				kept = append(kept, child)
+			}
+		}
+		return kept
+	}
+	return nil
+}
+
+func children(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		out := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			out = append(out, v[key])
+		}
+		return out
+	}
+	return nil
+}
+
+func walk(value interface{}, visit func(interface{})) {
+	visit(value)
+	for _, child := range children(value) {
+		walk(child, visit)
+	}
+}
+
+func (s step) keep(candidate interface{}) bool {
+	matches := evaluate(s.path, []interface{}{candidate})
+	if s.operator == "" {
+		return len(matches) > 0
+	}
+	for _, match := range matches {
+		if compare(match, s.operator, s.operand) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(actual interface{}, operator string, operand interface{}) bool {
+	if number, ok := operand.(float64); ok {
+		value, err := strconv.ParseFloat(formatValue(actual), 64)
+		if err != nil {
+			return operator == "!="
+		}
+		switch operator {
+		case "==":
+			return value == number
+		case "!=":
+			return value != number
+		case "<":
+			return value < number
+		case "<=":
+			return value <= number
+		case ">":
+			return value > number
+		case ">=":
+			return value >= number
+		}
+		return false
+	}
+	left, right := formatValue(actual), fmt.Sprint(operand)
+	switch operator {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	}
+	return false
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
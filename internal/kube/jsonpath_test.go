@@ -0,0 +1,75 @@
+package kube
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const deploymentJSON = `{
+  "metadata": {"name": "web", "labels": {"app.kubernetes.io/name": "web"}},
+  "spec": {
+    "replicas": 3,
+    "template": {"spec": {"containers": [
+      {"name": "app", "resources": {"limits": {"memory": "256Mi"}}, "readinessProbe": {"httpGet": {"path": "/healthz", "port": 8080}}},
+      {"name": "sidecar", "resources": {}}
+    ]}}
+  },
+  "status": {"conditions": [
+    {"type": "Progressing", "status": "True"},
+    {"type": "Available", "status": "False"}
+  ]}
+}`
+
+func TestJSONPath(t *testing.T) {
+	var obj interface{}
+	decoder := json.NewDecoder(strings.NewReader(deploymentJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&obj); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{.metadata.name}", "web"},
+		{"{$.metadata.name}", "web"},
+		{"{.spec.replicas}", "3"},
+		{"{.spec.template.spec.containers[0].resources.limits.memory}", "256Mi"},
+		{"{.spec.template.spec.containers[-1].name}", "sidecar"},
+		{"{.spec.template.spec.containers[*].name}", "app sidecar"},
+		{"{.spec.template.spec.containers[0].readinessProbe}", `{"httpGet":{"path":"/healthz","port":8080}}`},
+		{"{.spec.template.spec.containers[1].readinessProbe}", ""},
+		{"{.metadata.labels['app.kubernetes.io/name']}", "web"},
+		{`{.metadata.labels.app\.kubernetes\.io/name}`, "web"},
+		{`{.status.conditions[?(@.type=="Available")].status}`, "False"},
+		{`{.status.conditions[?(@.status!="False")].type}`, "Progressing"},
+		{`{.spec.template.spec.containers[?(@.readinessProbe)].name}`, "app"},
+		{`{..memory}`, "256Mi"},
+		{`name={.metadata.name} replicas={.spec.replicas}`, "name=web replicas=3"},
+		{`{"literal"}`, "literal"},
+	}
+	for _, tt := range tests {
+		got, err := JSONPath(tt.template, obj)
+		if err != nil {
+			t.Errorf("JSONPath(%q) error: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("JSONPath(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	if _, err := JSONPath("{.metadata.name", map[string]interface{}{}); err == nil || errors.Is(err, ErrUnsupportedJSONPath) {
+		t.Errorf("JSONPath with an unclosed action = %v, want a plain error", err)
+	}
+	for _, template := range []string{"{range .items[*]}{.name}{end}", "{.items[1:2]}"} {
+		if _, err := JSONPath(template, map[string]interface{}{}); !errors.Is(err, ErrUnsupportedJSONPath) {
+			t.Errorf("JSONPath(%q) = %v, want ErrUnsupportedJSONPath", template, err)
+		}
+	}
+}
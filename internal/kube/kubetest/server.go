@@ -0,0 +1,423 @@
+// Package kubetest provides a fake Kubernetes API server for tests. It
+// serves discovery for the core and apps groups and keeps objects in
+// memory; it supports get, list with label selectors, server-side apply,
+// delete, watch and pod logs.
+package kubetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gymctl/internal/kube"
+)
+
+type resource struct {
+	group, version, name, singular, kind string
+	shortNames                           []string
+	namespaced                           bool
+}
+
+var resources = []resource{
+	{"", "v1", "namespaces", "namespace", "Namespace", []string{"ns"}, false},
+	{"", "v1", "pods", "pod", "Pod", []string{"po"}, true},
+	{"", "v1", "services", "service", "Service", []string{"svc"}, true},
+	{"", "v1", "configmaps", "configmap", "ConfigMap", []string{"cm"}, true},
+	{"", "v1", "secrets", "secret", "Secret", nil, true},
+	{"apps", "v1", "deployments", "deployment", "Deployment", []string{"deploy"}, true},
+	{"apps", "v1", "statefulsets", "statefulset", "StatefulSet", []string{"sts"}, true},
+	{"apps", "v1", "daemonsets", "daemonset", "DaemonSet", []string{"ds"}, true},
+}
+
+func (r resource) prefix() string {
+	if r.group == "" {
+		return "/api/" + r.version
+	}
+	return "/apis/" + r.group + "/" + r.version
+}
+
+func (r resource) collection(namespace string) string {
+	if r.namespaced {
+		return r.prefix() + "/namespaces/" + namespace + "/" + r.name
+	}
+	return r.prefix() + "/" + r.name
+}
+
+// Server is an in-memory API server.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string]kube.Object
+	logs     map[string]string
+	version  int
+	watchers map[chan event]string
+	// Applied lists the object paths written with server-side apply.
+	Applied []string
+	// Deleted lists the object paths removed with DELETE.
+	Deleted []string
+	// LogQueries records the query string of each log request.
+	LogQueries []string
+}
+
+type event struct {
+	Type   string      `json:"type"`
+	Object kube.Object `json:"object"`
+}
+
+// NewServer starts a fake API server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		objects:  map[string]kube.Object{},
+		logs:     map[string]string{},
+		watchers: map[chan event]string{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a client for the server.
+func (s *Server) Client() *kube.Client {
+	return kube.NewClient(&kube.Config{Server: s.URL})
+}
+
+// Set stores obj, replacing any previous version, and notifies watchers.
+func (s *Server) Set(obj kube.Object) {
+	path, err := objectPath(obj)
+	if err != nil {
+		panic(err)
+	}
+	s.store(path, obj)
+}
+
+// SetLogs sets the logs returned for a pod.
+func (s *Server) SetLogs(namespace, pod, logs string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs[namespace+"/"+pod] = logs
+}
+
+// Get returns the stored object at the given API path.
+func (s *Server) Get(path string) (kube.Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[path]
+	return obj, ok
+}
+
+func (s *Server) store(path string, obj kube.Object) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	metadata["resourceVersion"] = strconv.Itoa(s.version)
+	eventType := "MODIFIED"
+	if _, ok := s.objects[path]; !ok {
+		eventType = "ADDED"
+	}
+	s.objects[path] = obj
+	s.notify(path, event{Type: eventType, Object: obj})
+}
+
+// notify sends e to the watchers of the object at path and of its
+// collection. The caller holds s.mu.
+func (s *Server) notify(path string, e event) {
+	for watcher, watched := range s.watchers {
+		if watched == path || watchesCollection(watched, path) {
+			watcher <- e
+		}
+	}
+}
+
+// watchesCollection reports whether watched, a collection path ending in a
+// slash, directly contains the object at path.
+func watchesCollection(watched, path string) bool {
+	rest, ok := strings.CutPrefix(path, watched)
+	return ok && strings.HasSuffix(watched, "/") && rest != "" && !strings.Contains(rest, "/")
+}
+
+// remove deletes the object at path. Deleting a namespace deletes the
+// objects in it too, at once rather than after a terminating phase.
+func (s *Server) remove(path string) (kube.Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[path]
+	if !ok {
+		return nil, false
+	}
+	delete(s.objects, path)
+	s.Deleted = append(s.Deleted, path)
+	s.notify(path, event{Type: "DELETED", Object: obj})
+	if namespace, found := strings.CutPrefix(path, "/api/v1/namespaces/"); found && !strings.Contains(namespace, "/") {
+		for other := range s.objects {
+			if strings.Contains(other, "/namespaces/"+namespace+"/") {
+				delete(s.objects, other)
+			}
+		}
+	}
+	return obj, true
+}
+
+func objectPath(obj kube.Object) (string, error) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if namespace == "" {
+		namespace = "default"
+	}
+	for _, r := range resources {
+		groupVersion := r.version
+		if r.group != "" {
+			groupVersion = r.group + "/" + r.version
+		}
+		if groupVersion == apiVersion && r.kind == kind {
+			return r.collection(namespace) + "/" + name, nil
+		}
+	}
+	return "", fmt.Errorf("kubetest: unknown kind %s %s", apiVersion, kind)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api":
+		writeJSON(w, map[string]interface{}{"versions": []string{"v1"}})
+		return
+	case "/apis":
+		writeJSON(w, map[string]interface{}{"groups": []map[string]interface{}{{
+			"name":             "apps",
+			"preferredVersion": map[string]string{"groupVersion": "apps/v1"},
+		}}})
+		return
+	case "/api/v1", "/apis/apps/v1":
+		writeJSON(w, discoveryDocument(r.URL.Path))
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/log") {
+		s.serveLogs(w, r)
+		return
+	}
+	if _, ok := collectionOf(r.URL.Path); ok {
+		if r.URL.Query().Get("watch") != "" {
+			s.serveWatch(w, r)
+			return
+		}
+		s.serveList(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		obj, ok := s.Get(r.URL.Path)
+		if !ok {
+			writeStatus(w, http.StatusNotFound, "NotFound", "not found: "+r.URL.Path)
+			return
+		}
+		writeJSON(w, obj)
+	case http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/apply-patch+yaml" || r.URL.Query().Get("fieldManager") == "" {
+			writeStatus(w, http.StatusUnsupportedMediaType, "UnsupportedMediaType", "expected server-side apply")
+			return
+		}
+		var obj kube.Object
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &obj); err != nil {
+			writeStatus(w, http.StatusBadRequest, "BadRequest", err.Error())
+			return
+		}
+		s.mu.Lock()
+		s.Applied = append(s.Applied, r.URL.Path)
+		s.mu.Unlock()
+		s.store(r.URL.Path, obj)
+		writeJSON(w, obj)
+	case http.MethodDelete:
+		obj, ok := s.remove(r.URL.Path)
+		if !ok {
+			writeStatus(w, http.StatusNotFound, "NotFound", "not found: "+r.URL.Path)
+			return
+		}
+		writeJSON(w, obj)
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// collectionOf reports whether path names a collection rather than an
+// object, returning the resource.
+func collectionOf(path string) (resource, bool) {
+	for _, r := range resources {
+		// A namespaced resource listed without a namespace spans all.
+		if path == r.prefix()+"/"+r.name {
+			return r, true
+		}
+		if !r.namespaced || !strings.HasPrefix(path, r.prefix()+"/namespaces/") {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(path, r.prefix()+"/namespaces/"), "/")
+		if len(parts) == 2 && parts[1] == r.name {
+			return r, true
+		}
+	}
+	return resource{}, false
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request) {
+	selector := r.URL.Query().Get("labelSelector")
+	s.mu.Lock()
+	var paths []string
+	all, _ := collectionOf(r.URL.Path)
+	allNamespaces := all.namespaced && r.URL.Path == all.prefix()+"/"+all.name
+	for path := range s.objects {
+		if strings.HasPrefix(path, r.URL.Path+"/") && !strings.Contains(strings.TrimPrefix(path, r.URL.Path+"/"), "/") {
+			paths = append(paths, path)
+			continue
+		}
+		if allNamespaces && strings.HasPrefix(path, all.prefix()+"/namespaces/") {
+			parts := strings.Split(strings.TrimPrefix(path, all.prefix()+"/namespaces/"), "/")
+			if len(parts) == 3 && parts[1] == all.name {
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	items := []kube.Object{}
+	for _, path := range paths {
+		if matchesSelector(s.objects[path], selector) {
+			items = append(items, s.objects[path])
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"kind": "List", "items": items})
+}
+
+func matchesSelector(obj kube.Object, selector string) bool {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	for _, requirement := range strings.Split(selector, ",") {
+		if requirement == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(requirement, "=")
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) serveWatch(w http.ResponseWriter, r *http.Request) {
+	// Without a field selector the whole collection is watched.
+	name := strings.TrimPrefix(r.URL.Query().Get("fieldSelector"), "metadata.name=")
+	path := r.URL.Path + "/" + name
+	events := make(chan event, 16)
+	s.mu.Lock()
+	s.watchers[events] = path
+	// Replay a change made since the version the client last saw.
+	if obj, ok := s.objects[path]; ok {
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		current, _ := strconv.Atoi(fmt.Sprint(metadata["resourceVersion"]))
+		seen, err := strconv.Atoi(r.URL.Query().Get("resourceVersion"))
+		if err == nil && current > seen {
+			events <- event{Type: "MODIFIED", Object: obj}
+		}
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, events)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-events:
+			if err := encoder.Encode(e); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func (s *Server) serveLogs(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/"), "/")
+	if len(parts) != 4 || parts[1] != "pods" {
+		writeStatus(w, http.StatusNotFound, "NotFound", "not found: "+r.URL.Path)
+		return
+	}
+	s.mu.Lock()
+	logs, ok := s.logs[parts[0]+"/"+parts[2]]
+	s.LogQueries = append(s.LogQueries, r.URL.RawQuery)
+	s.mu.Unlock()
+	if !ok {
+		writeStatus(w, http.StatusNotFound, "NotFound", fmt.Sprintf("pods %q not found", parts[2]))
+		return
+	}
+	io.WriteString(w, logs)
+}
+
+func discoveryDocument(path string) map[string]interface{} {
+	groupVersion := strings.TrimPrefix(strings.TrimPrefix(path, "/apis/"), "/api/")
+	var list []map[string]interface{}
+	for _, r := range resources {
+		if r.prefix() != path {
+			continue
+		}
+		list = append(list, map[string]interface{}{
+			"name":         r.name,
+			"singularName": r.singular,
+			"namespaced":   r.namespaced,
+			"kind":         r.kind,
+			"shortNames":   r.shortNames,
+		})
+		list = append(list, map[string]interface{}{
+			"name":       r.name + "/status",
+			"namespaced": r.namespaced,
+			"kind":       r.kind,
+		})
+	}
+	return map[string]interface{}{"kind": "APIResourceList", "groupVersion": groupVersion, "resources": list}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"kind":    "Status",
+		"status":  "Failure",
+		"code":    code,
+		"reason":  reason,
+		"message": message,
+	})
+}
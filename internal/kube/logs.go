@@ -0,0 +1,127 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogOptions narrows the logs returned by Logs.
+type LogOptions struct {
+	Container string
+	// Since limits the logs to recent entries when non-zero.
+	Since time.Duration
+	// TailLines limits the logs of each pod when positive.
+	TailLines int
+}
+
+// Logs returns the logs of the pod named by ref. For other workloads
+// (deployment/web, statefulset/db, ...) the first pod matched by the
+// workload's selector is used, as kubectl does.
+func (c *Client) Logs(ctx context.Context, namespace, ref string, opts LogOptions) (string, error) {
+	resource, name, err := c.resolveRef(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if resource.Group == "" && resource.Name == "pods" {
+		return c.podLogs(ctx, namespace, name, opts)
+	}
+
+	obj, err := c.Get(ctx, namespace, ref)
+	if err != nil {
+		return "", err
+	}
+	selector := workloadSelector(obj)
+	if selector == "" {
+		return "", fmt.Errorf("cannot find pods for %s: no selector", ref)
+	}
+	pods, err := c.podNames(ctx, namespace, selector)
+	if err != nil {
+		return "", err
+	}
+	if len(pods) == 0 {
+		return "", fmt.Errorf("no pods found for %s", ref)
+	}
+	return c.podLogs(ctx, namespace, pods[0], opts)
+}
+
+// SelectorLogs returns the concatenated logs of every pod matching the
+// label selector. Like `kubectl logs -l`, only the last 10 lines of each
+// pod are returned unless TailLines says otherwise.
+func (c *Client) SelectorLogs(ctx context.Context, namespace, selector string, opts LogOptions) (string, error) {
+	if opts.TailLines == 0 {
+		opts.TailLines = 10
+	}
+	pods, err := c.podNames(ctx, namespace, selector)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for _, pod := range pods {
+		logs, err := c.podLogs(ctx, namespace, pod, opts)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(logs)
+	}
+	return out.String(), nil
+}
+
+func (c *Client) podLogs(ctx context.Context, namespace, pod string, opts LogOptions) (string, error) {
+	query := url.Values{}
+	if opts.Container != "" {
+		query.Set("container", opts.Container)
+	}
+	if opts.Since > 0 {
+		query.Set("sinceSeconds", fmt.Sprint(int64(opts.Since.Seconds())))
+	}
+	if opts.TailLines > 0 {
+		query.Set("tailLines", fmt.Sprint(opts.TailLines))
+	}
+	path := "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods/" + url.PathEscape(pod) + "/log"
+	resp, err := c.do(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+func (c *Client) podNames(ctx context.Context, namespace, selector string) ([]string, error) {
+	pods, err := c.List(ctx, namespace, "pods", selector)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pod := range pods {
+		metadata, _ := pod["metadata"].(Object)
+		if name, _ := metadata["name"].(string); name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// workloadSelector renders spec.selector.matchLabels (or a plain
+// spec.selector map, as services use) as a label selector.
+func workloadSelector(obj Object) string {
+	spec, _ := obj["spec"].(Object)
+	selector, _ := spec["selector"].(Object)
+	if matchLabels, ok := selector["matchLabels"].(Object); ok {
+		selector = matchLabels
+	}
+	var parts []string
+	for _, key := range sortedKeys(selector) {
+		if value, ok := selector[key].(string); ok {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,118 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ConditionStatus returns the status of the named entry in
+// .status.conditions, or "" when the object does not report it.
+func ConditionStatus(obj Object, conditionType string) string {
+	status, _ := obj["status"].(Object)
+	conditions, _ := status["conditions"].([]interface{})
+	for _, item := range conditions {
+		condition, _ := item.(Object)
+		if condition["type"] == conditionType {
+			value, _ := condition["status"].(string)
+			return value
+		}
+	}
+	return ""
+}
+
+// WaitForCondition watches the object named by ref until its condition
+// reaches the wanted status. condition is "Type" (meaning "True") or
+// "Type=Status", as in `kubectl wait --for=condition=...`.
+func (c *Client) WaitForCondition(ctx context.Context, namespace, ref, condition string, timeout time.Duration) error {
+	conditionType, want, found := strings.Cut(condition, "=")
+	if !found {
+		want = "True"
+	}
+	resource, name, err := c.resolveRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	timedOut := func() error {
+		return fmt.Errorf("timed out after %s waiting for the condition %s on %s", timeout, condition, ref)
+	}
+
+	for {
+		var obj Object
+		if err := c.getJSON(ctx, resource.path(namespace, name), nil, &obj); err != nil {
+			if ctx.Err() != nil {
+				return timedOut()
+			}
+			return err
+		}
+		if ConditionStatus(obj, conditionType) == want {
+			return nil
+		}
+		metadata, _ := obj["metadata"].(Object)
+		resourceVersion, _ := metadata["resourceVersion"].(string)
+
+		met, err := c.watchCondition(ctx, resource, namespace, name, resourceVersion, conditionType, want)
+		if met {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return timedOut()
+		}
+		if err != nil {
+			return err
+		}
+		// The server closed the watch; start over from a fresh read.
+		select {
+		case <-ctx.Done():
+			return timedOut()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (c *Client) watchCondition(ctx context.Context, resource apiResource, namespace, name, resourceVersion, conditionType, want string) (bool, error) {
+	query := url.Values{
+		"watch":         {"1"},
+		"fieldSelector": {"metadata.name=" + name},
+	}
+	if resourceVersion != "" {
+		query.Set("resourceVersion", resourceVersion)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		query.Set("timeoutSeconds", fmt.Sprint(int(time.Until(deadline).Seconds())+1))
+	}
+	resp, err := c.do(ctx, http.MethodGet, resource.path(namespace, ""), query, "", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event struct {
+			Type   string `json:"type"`
+			Object Object `json:"object"`
+		}
+		if err := decoder.Decode(&event); err != nil {
+			// The stream ended: a timeout, cancellation or server restart.
+			return false, nil
+		}
+		switch event.Type {
+		case "ERROR":
+			// Usually an expired resourceVersion; the caller re-reads.
+			return false, nil
+		case "DELETED":
+			return false, fmt.Errorf("%s/%s was deleted while waiting", resource.Singular, name)
+		}
+		if ConditionStatus(event.Object, conditionType) == want {
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// WatchEvent is one change reported by Watch: ADDED, MODIFIED or DELETED.
+type WatchEvent struct {
+	Type   string `json:"type"`
+	Object Object `json:"object"`
+}
+
+// Watch reports changes to the objects of resourceType in namespace, or in
+// all namespaces when it is empty, until ctx ends or the server closes the
+// stream; callers that want to keep watching call it again. Only changes
+// made after the call are reported.
+func (c *Client) Watch(ctx context.Context, namespace, resourceType string, onEvent func(WatchEvent)) error {
+	resource, err := c.resolveType(ctx, resourceType)
+	if err != nil {
+		return err
+	}
+	path := resource.path(namespace, "")
+
+	var list struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	if err := c.getJSON(ctx, path, nil, &list); err != nil {
+		return err
+	}
+
+	query := url.Values{"watch": {"1"}}
+	if list.Metadata.ResourceVersion != "" {
+		query.Set("resourceVersion", list.Metadata.ResourceVersion)
+	}
+	resp, err := c.do(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event WatchEvent
+		if err := decoder.Decode(&event); err != nil {
+			// The stream ended: a timeout, cancellation or server restart.
+			return nil
+		}
+		switch event.Type {
+		case "ERROR":
+			// Usually an expired resourceVersion; the caller starts over.
+			return nil
+		case "BOOKMARK":
+			continue
+		}
+		onEvent(event)
+	}
+}
@@ -0,0 +1,101 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"gymctl/internal/atomicfile"
+)
+
+// backupCount is how many last-known-good copies are kept next to the
+// progress file, as <path>.1 (newest) to <path>.3.
+const backupCount = 3
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// rotateBackups shifts the last-known-good copies down by one and saves the
+// current progress file as the newest. A missing or unparsable file is not
+// worth keeping and leaves the copies alone.
+func rotateBackups(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !valid(data) {
+		return nil
+	}
+	for n := backupCount - 1; n >= 1; n-- {
+		err := os.Rename(backupPath(path, n), backupPath(path, n+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return atomicfile.Write(backupPath(path, 1), data)
+}
+
+// valid reports whether data is a progress file worth keeping.
+func valid(data []byte) bool {
+	var file File
+	return len(bytes.TrimSpace(data)) > 0 && yaml.Unmarshal(data, &file) == nil
+}
+
+// Backup is a last-known-good copy of the progress file.
+type Backup struct {
+	Path    string
+	ModTime time.Time
+}
+
+// Backups returns the last-known-good copies of the progress file at path
+// that still parse, newest first.
+func Backups(path string) []Backup {
+	var backups []Backup
+	for n := 1; n <= backupCount; n++ {
+		candidate := backupPath(path, n)
+		info, err := os.Stat(candidate)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(candidate)
+		if err != nil || !valid(data) {
+			continue
+		}
+		backups = append(backups, Backup{Path: candidate, ModTime: info.ModTime()})
+	}
+	return backups
+}
+
+// ErrNoBackup is returned by RestoreBackup when there is no usable copy.
+var ErrNoBackup = errors.New("no usable progress backup")
+
+// RestoreBackup replaces the progress file at path with its newest usable
+// last-known-good copy and returns that copy.
+func RestoreBackup(path string) (Backup, error) {
+	unlock, err := Lock(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	defer unlock()
+
+	backups := Backups(path)
+	if len(backups) == 0 {
+		return Backup{}, ErrNoBackup
+	}
+	data, err := os.ReadFile(backups[0].Path)
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := atomicfile.Write(path, data); err != nil {
+		return Backup{}, fmt.Errorf("restore progress: %w", err)
+	}
+	return backups[0], nil
+}
@@ -0,0 +1,10 @@
+package progress
+
+import "gymctl/internal/atomicfile"
+
+// Lock takes the advisory lock guarding read-modify-write cycles of the
+// progress file at path. The lock lives in a sibling "<path>.lock" file and
+// is released by the returned function or when the process exits.
+func Lock(path string) (func() error, error) {
+	return atomicfile.Lock(path)
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"sigs.k8s.io/yaml"
+
+	"gymctl/internal/atomicfile"
 )
 
 type File struct {
@@ -21,6 +24,43 @@ type ExerciseStatus struct {
 	HintsUsed   int    `yaml:"hintsUsed,omitempty"`
 	Resets      int    `yaml:"resets,omitempty"`
 	Score       int    `yaml:"score,omitempty"`
+	Variant     string `yaml:"variant,omitempty"`
+
+	ScoreBreakdown *ScoreBreakdown `yaml:"scoreBreakdown,omitempty"`
+	Sessions       []Session       `yaml:"sessions,omitempty"`
+}
+
+// ScoreBreakdown records how the score of a completed exercise was derived.
+type ScoreBreakdown struct {
+	Base int `yaml:"base"`
+	// Missed is what the failed optional checks were worth.
+	Missed       int `yaml:"missed,omitempty"`
+	HintPenalty  int `yaml:"hintPenalty,omitempty"`
+	ResetPenalty int `yaml:"resetPenalty,omitempty"`
+	TimePenalty  int `yaml:"timePenalty,omitempty"`
+	Total        int `yaml:"total"`
+
+	Checks []CheckScore `yaml:"checks,omitempty"`
+}
+
+// CheckScore is one check's share of a score.
+type CheckScore struct {
+	Name     string `yaml:"name"`
+	Points   int    `yaml:"points"`
+	Passed   bool   `yaml:"passed"`
+	Optional bool   `yaml:"optional,omitempty"`
+}
+
+// Complete marks the exercise completed and records breakdown as its score
+// unless an earlier attempt scored higher. The best score stands even
+// after the exercise was reset or started again.
+func (s *ExerciseStatus) Complete(breakdown ScoreBreakdown, now time.Time) {
+	if breakdown.Total >= s.Score {
+		s.CompletedAt = now.UTC().Format(time.RFC3339)
+		s.Score = breakdown.Total
+		s.ScoreBreakdown = &breakdown
+	}
+	s.Status = "completed"
 }
 
 func Load(path string) (*File, error) {
@@ -46,6 +86,12 @@ func Load(path string) (*File, error) {
 	return &file, nil
 }
 
+// Save writes the progress file atomically: the new content goes to a
+// temporary file that replaces the old one, so a crash leaves either
+// version intact, never a truncated file. The file being replaced is kept
+// as the newest last-known-good copy when it still parses.
+//
+// Save does not lock; callers changing a loaded file use Update.
 func Save(path string, file *File) error {
 	data, err := yaml.Marshal(file)
 	if err != nil {
@@ -56,9 +102,43 @@ func Save(path string, file *File) error {
 		return fmt.Errorf("create progress dir: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0o644); err != nil {
+	if err := rotateBackups(path); err != nil {
+		return fmt.Errorf("back up progress: %w", err)
+	}
+	if err := atomicfile.Write(path, data); err != nil {
 		return fmt.Errorf("write progress: %w", err)
 	}
 
 	return nil
 }
+
+// Replace saves file over the progress file under the progress lock,
+// without reading what is there, for starting over from a corrupted file.
+func Replace(path string, file *File) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return Save(path, file)
+}
+
+// Update loads the progress file, applies change and saves the result while
+// holding the progress lock, so concurrent gymctl commands do not lose each
+// other's updates. Nothing is written when change fails.
+func Update(path string, change func(*File) error) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := change(file); err != nil {
+		return err
+	}
+	return Save(path, file)
+}
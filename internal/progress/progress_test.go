@@ -0,0 +1,161 @@
+package progress
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSaveKeepsLastKnownGood(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.yaml")
+	for hints := 1; hints <= 5; hints++ {
+		file := &File{Version: 1, Exercises: map[string]ExerciseStatus{"demo": {Status: "in_progress", HintsUsed: hints}}}
+		if err := Save(path, file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups := Backups(path)
+	if len(backups) != backupCount {
+		t.Fatalf("kept %d backups, want %d", len(backups), backupCount)
+	}
+	newest, err := Load(backups[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := newest.Exercises["demo"].HintsUsed; got != 4 {
+		t.Errorf("newest backup has %d hints, want the previous save's 4", got)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".progress.yaml.tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestRestoreBackupAfterCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.yaml")
+	good := &File{Version: 1, Exercises: map[string]ExerciseStatus{"demo": {Status: "completed", Score: 90}}}
+	if err := Save(path, good); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, good); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("Exercises: [\n  demo: {Status"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected the corrupted file to fail to load")
+	}
+
+	// Saving over a corrupted file must not push it into the backups.
+	if err := Save(path, good); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, backup := range Backups(path) {
+		data, _ := os.ReadFile(backup.Path)
+		if !valid(data) {
+			t.Errorf("backup %s is not valid", backup.Path)
+		}
+	}
+
+	if _, err := RestoreBackup(path); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Exercises["demo"].Score; got != 90 {
+		t.Errorf("restored score = %d, want 90", got)
+	}
+}
+
+func TestRestoreBackupWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.yaml")
+	if _, err := RestoreBackup(path); err != ErrNoBackup {
+		t.Errorf("RestoreBackup = %v, want ErrNoBackup", err)
+	}
+}
+
+func TestUpdateSerializesWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.yaml")
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- Update(path, func(file *File) error {
+				entry := file.Exercises["demo"]
+				entry.HintsUsed++
+				file.Exercises["demo"] = entry
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := file.Exercises["demo"].HintsUsed; got != writers {
+		t.Errorf("HintsUsed = %d after %d updates, want no lost updates", got, writers)
+	}
+}
+
+func TestUpdateSkipsSaveOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.yaml")
+	err := Update(path, func(file *File) error {
+		file.Exercises["demo"] = ExerciseStatus{Status: "completed"}
+		return os.ErrInvalid
+	})
+	if err != os.ErrInvalid {
+		t.Fatalf("Update = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("progress file written despite the error: %v", err)
+	}
+}
+
+func TestCompleteKeepsBestScoreAcrossResets(t *testing.T) {
+	first := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	var status ExerciseStatus
+	status.Complete(ScoreBreakdown{Base: 100, Total: 90}, first)
+
+	// A reset puts the exercise back in progress, and its penalty lowers
+	// the next completion.
+	status.Resets++
+	status.Status = "in_progress"
+	status.Complete(ScoreBreakdown{Base: 100, ResetPenalty: 20, Total: 70}, first.Add(time.Hour))
+
+	if status.Status != "completed" {
+		t.Errorf("Status = %q, want completed", status.Status)
+	}
+	if status.Score != 90 || status.ScoreBreakdown == nil || status.ScoreBreakdown.Total != 90 {
+		t.Errorf("Score = %d, breakdown = %+v, want the best attempt's 90", status.Score, status.ScoreBreakdown)
+	}
+	if status.CompletedAt != "2024-03-01T10:00:00Z" {
+		t.Errorf("CompletedAt = %q, want the best attempt's", status.CompletedAt)
+	}
+
+	status.Status = "in_progress"
+	status.Complete(ScoreBreakdown{Base: 100, Total: 95}, first.Add(2*time.Hour))
+	if status.Score != 95 || status.ScoreBreakdown.Total != 95 {
+		t.Errorf("Score = %d, want the better attempt's 95", status.Score)
+	}
+}
@@ -0,0 +1,77 @@
+package progress
+
+import (
+	"fmt"
+	"time"
+)
+
+// Session is one interval of active work on an exercise, from a start (or
+// resume) until the matching stop. EndedAt is empty while the session is open.
+type Session struct {
+	StartedAt string `yaml:"startedAt"`
+	EndedAt   string `yaml:"endedAt,omitempty"`
+}
+
+// StartSession opens a new session unless one is already running.
+func (s *ExerciseStatus) StartSession(now time.Time) {
+	if s.openSession() != nil {
+		return
+	}
+	s.Sessions = append(s.Sessions, Session{StartedAt: now.UTC().Format(time.RFC3339)})
+}
+
+// EndSession closes the running session, if any, and refreshes TimeSpent.
+func (s *ExerciseStatus) EndSession(now time.Time) {
+	if open := s.openSession(); open != nil {
+		open.EndedAt = now.UTC().Format(time.RFC3339)
+	}
+	s.TimeSpent = FormatDuration(s.ActiveTime(now))
+}
+
+// ActiveTime sums the length of all sessions, counting a running session up
+// to now. Sessions with unparseable timestamps are ignored.
+func (s *ExerciseStatus) ActiveTime(now time.Time) time.Duration {
+	var total time.Duration
+	for _, session := range s.Sessions {
+		start, err := time.Parse(time.RFC3339, session.StartedAt)
+		if err != nil {
+			continue
+		}
+		end := now
+		if session.EndedAt != "" {
+			end, err = time.Parse(time.RFC3339, session.EndedAt)
+			if err != nil {
+				continue
+			}
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+func (s *ExerciseStatus) openSession() *Session {
+	if len(s.Sessions) == 0 {
+		return nil
+	}
+	last := &s.Sessions[len(s.Sessions)-1]
+	if last.EndedAt != "" {
+		return nil
+	}
+	return last
+}
+
+// FormatDuration renders a duration the way progress displays time spent,
+// e.g. "45s", "12m" or "1h05m".
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
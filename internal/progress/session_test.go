@@ -0,0 +1,71 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionsActiveTime(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	var status ExerciseStatus
+
+	status.StartSession(base)
+	// A second start while a session is open must not open another one.
+	status.StartSession(base.Add(5 * time.Minute))
+	status.EndSession(base.Add(10 * time.Minute))
+
+	if len(status.Sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(status.Sessions))
+	}
+	if status.TimeSpent != "10m" {
+		t.Errorf("TimeSpent = %q, want 10m", status.TimeSpent)
+	}
+
+	// Time between stop and the next start is not counted.
+	status.StartSession(base.Add(2 * time.Hour))
+	if got := status.ActiveTime(base.Add(2*time.Hour + 20*time.Minute)); got != 30*time.Minute {
+		t.Errorf("ActiveTime with open session = %v, want 30m", got)
+	}
+
+	status.EndSession(base.Add(2*time.Hour + 50*time.Minute))
+	if got := status.ActiveTime(base.Add(5 * time.Hour)); got != time.Hour {
+		t.Errorf("ActiveTime after stop = %v, want 1h", got)
+	}
+	if status.TimeSpent != "1h00m" {
+		t.Errorf("TimeSpent = %q, want 1h00m", status.TimeSpent)
+	}
+
+	// Ending without an open session only refreshes TimeSpent.
+	status.EndSession(base.Add(6 * time.Hour))
+	if len(status.Sessions) != 2 {
+		t.Errorf("expected 2 sessions, got %d", len(status.Sessions))
+	}
+}
+
+func TestActiveTimeSkipsInvalidSessions(t *testing.T) {
+	status := ExerciseStatus{Sessions: []Session{
+		{StartedAt: "garbage", EndedAt: "2024-03-01T10:10:00Z"},
+		{StartedAt: "2024-03-01T10:00:00Z", EndedAt: "2024-03-01T10:03:00Z"},
+	}}
+	if got := status.ActiveTime(time.Now()); got != 3*time.Minute {
+		t.Errorf("ActiveTime = %v, want 3m", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 40*time.Second, "12m"},
+		{time.Hour + 5*time.Minute, "1h05m"},
+		{26 * time.Hour, "26h00m"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.in); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
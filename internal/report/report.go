@@ -0,0 +1,273 @@
+// Package report renders check results in machine-readable formats for CI.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gymctl/internal/checks"
+	"gymctl/internal/scenario"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+	FormatTAP   = "tap"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatJSON, FormatJUnit, FormatTAP}
+
+// Report is the outcome of one `gymctl check` run. It passes when every
+// required check passes; Failed counts optional checks too.
+type Report struct {
+	Exercise   Exercise `json:"exercise"`
+	Passed     bool     `json:"passed"`
+	Total      int      `json:"total"`
+	Failed     int      `json:"failed"`
+	DurationMs int64    `json:"durationMs"`
+	Checks     []Check  `json:"checks"`
+}
+
+// Exercise carries the metadata of the checked exercise.
+type Exercise struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Track       string `json:"track"`
+	Week        int    `json:"week,omitempty"`
+	Difficulty  string `json:"difficulty,omitempty"`
+	Environment string `json:"environment"`
+	Variant     string `json:"variant,omitempty"`
+}
+
+// Check is a single check result.
+type Check struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Passed     bool   `json:"passed"`
+	Optional   bool   `json:"optional,omitempty"`
+	Points     int    `json:"points,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Feedback   string `json:"feedback,omitempty"`
+	Hint       int    `json:"hint,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	// Branches holds the nested results of a composite check.
+	Branches []Check `json:"branches,omitempty"`
+}
+
+// New builds a report from the results of an exercise's checks.
+func New(exercise *scenario.Exercise, variant string, results []checks.Result, duration time.Duration) Report {
+	report := Report{
+		Exercise: Exercise{
+			Name:        exercise.Metadata.Name,
+			Title:       exercise.Metadata.Title,
+			Track:       exercise.Metadata.Track,
+			Week:        exercise.Metadata.Week,
+			Difficulty:  exercise.Spec.Difficulty,
+			Environment: exercise.Spec.Environment.Type,
+			Variant:     variant,
+		},
+		Passed:     true,
+		Total:      len(results),
+		DurationMs: duration.Milliseconds(),
+		Checks:     make([]Check, 0, len(results)),
+	}
+	points, _, _ := exercise.Spec.CheckPoints()
+	for i, result := range results {
+		if !result.Passed {
+			report.Failed++
+			if !result.Optional {
+				report.Passed = false
+			}
+		}
+		check := newCheck(result)
+		if i < len(points) {
+			check.Points = points[i]
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	return report
+}
+
+func newCheck(result checks.Result) Check {
+	check := Check{
+		Name:       result.Name,
+		Type:       result.Type,
+		Passed:     result.Passed,
+		Optional:   result.Optional,
+		Message:    result.Message,
+		Feedback:   result.Feedback,
+		Hint:       result.Hint,
+		Attempts:   result.Attempts,
+		DurationMs: result.Duration.Milliseconds(),
+	}
+	for _, branch := range result.Branches {
+		check.Branches = append(check.Branches, newCheck(branch))
+	}
+	return check
+}
+
+// Write renders the report in the given format.
+func Write(w io.Writer, format string, report Report) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, report)
+	case FormatJUnit:
+		return WriteJUnit(w, report)
+	case FormatTAP:
+		return WriteTAP(w, report)
+	default:
+		return fmt.Errorf("unsupported output format: %s (use %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// WriteJSON renders the report as indented JSON.
+func WriteJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr,omitempty"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit renders the report as a JUnit XML test suite, one test case
+// per check, so CI systems can display the results natively. Failed
+// optional checks are reported as skipped, since they do not fail the run.
+func WriteJUnit(w io.Writer, report Report) error {
+	suite := junitSuite{
+		Name:     report.Exercise.Name,
+		Tests:    report.Total,
+		Failures: report.Failed,
+		Time:     seconds(report.DurationMs),
+	}
+	for _, property := range []junitProperty{
+		{Name: "title", Value: report.Exercise.Title},
+		{Name: "track", Value: report.Exercise.Track},
+		{Name: "difficulty", Value: report.Exercise.Difficulty},
+		{Name: "environment", Value: report.Exercise.Environment},
+		{Name: "variant", Value: report.Exercise.Variant},
+	} {
+		if property.Value != "" {
+			suite.Properties = append(suite.Properties, property)
+		}
+	}
+	for _, check := range report.Checks {
+		testCase := junitCase{
+			Name:      check.Name,
+			Classname: report.Exercise.Name,
+			Time:      seconds(check.DurationMs),
+		}
+		switch {
+		case !check.Passed && check.Optional:
+			testCase.Skipped = &junitSkipped{Message: "optional check failed: " + firstLine(check.Message)}
+			suite.Failures--
+			suite.Skipped++
+		case !check.Passed:
+			testCase.Failure = &junitFailure{Message: firstLine(check.Message), Type: check.Type, Text: check.Message}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP renders the report in the Test Anything Protocol, version 13,
+// with failure details in YAML diagnostic blocks. Optional checks carry a
+// TODO directive so their failures do not fail the run.
+func WriteTAP(w io.Writer, report Report) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", report.Total)
+	fmt.Fprintf(&b, "# %s (%s)\n", report.Exercise.Name, report.Exercise.Title)
+	for i, check := range report.Checks {
+		status := "ok"
+		if !check.Passed {
+			status = "not ok"
+		}
+		fmt.Fprintf(&b, "%s %d - %s", status, i+1, check.Name)
+		if check.Optional {
+			b.WriteString(" # TODO optional")
+		}
+		b.WriteString("\n")
+		if check.Passed {
+			continue
+		}
+		b.WriteString("  ---\n")
+		fmt.Fprintf(&b, "  type: %s\n", check.Type)
+		fmt.Fprintf(&b, "  duration_ms: %d\n", check.DurationMs)
+		if check.Feedback != "" {
+			fmt.Fprintf(&b, "  feedback: %q\n", check.Feedback)
+		}
+		if check.Hint > 0 {
+			fmt.Fprintf(&b, "  hint: %d\n", check.Hint)
+		}
+		if check.Message != "" {
+			b.WriteString("  message: |\n")
+			for _, line := range strings.Split(strings.TrimRight(check.Message, "\n"), "\n") {
+				fmt.Fprintf(&b, "    %s\n", line)
+			}
+		}
+		b.WriteString("  ...\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
@@ -0,0 +1,172 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"gymctl/internal/checks"
+	"gymctl/internal/scenario"
+)
+
+func sampleReport() Report {
+	exercise := &scenario.Exercise{
+		Metadata: scenario.ExerciseMeta{Name: "jerry-demo", Title: "Jerry's Demo", Track: "docker", Week: 1},
+		Spec: scenario.ExerciseSpec{
+			Difficulty:  "beginner",
+			Environment: scenario.EnvironmentSpec{Type: "docker"},
+		},
+	}
+	results := []checks.Result{
+		{Name: "image exists", Type: "docker-image", Passed: true, Attempts: 1, Duration: 120 * time.Millisecond},
+		{Name: "runs as non-root", Type: "script", Message: "expected exit code 0, got 1\nuid=0(root)", Feedback: "The container still runs as root.", Hint: 2, Attempts: 3, Duration: 2 * time.Second},
+	}
+	return New(exercise, "tight", results, 3*time.Second)
+}
+
+func TestNew(t *testing.T) {
+	report := sampleReport()
+	if report.Passed || report.Total != 2 || report.Failed != 1 {
+		t.Errorf("unexpected totals: passed=%t total=%d failed=%d", report.Passed, report.Total, report.Failed)
+	}
+	if report.Exercise.Variant != "tight" || report.Exercise.Environment != "docker" {
+		t.Errorf("unexpected exercise metadata: %+v", report.Exercise)
+	}
+	if report.Checks[1].DurationMs != 2000 {
+		t.Errorf("unexpected duration: %d", report.Checks[1].DurationMs)
+	}
+}
+
+func TestNewKeepsBranches(t *testing.T) {
+	exercise := &scenario.Exercise{Metadata: scenario.ExerciseMeta{Name: "jerry-demo"}}
+	results := []checks.Result{{
+		Name: "either user", Type: scenario.CheckAnyOf, Passed: true,
+		Branches: []checks.Result{
+			{Name: "USER set", Type: "dockerfile"},
+			{Name: "runs as 1000", Type: "docker-container", Passed: true},
+		},
+	}}
+	report := New(exercise, "", results, time.Second)
+	if report.Total != 1 || !report.Passed {
+		t.Errorf("branches must not count as checks: total=%d passed=%t", report.Total, report.Passed)
+	}
+	branches := report.Checks[0].Branches
+	if len(branches) != 2 || branches[0].Passed || !branches[1].Passed {
+		t.Errorf("unexpected branches: %+v", branches)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if decoded.Exercise.Name != "jerry-demo" || len(decoded.Checks) != 2 || decoded.DurationMs != 3000 {
+		t.Errorf("unexpected decoded report: %+v", decoded)
+	}
+	if decoded.Checks[1].Type != "script" || decoded.Checks[1].Passed || decoded.Checks[1].Hint != 2 || decoded.Checks[1].Feedback == "" {
+		t.Errorf("unexpected check: %+v", decoded.Checks[1])
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJUnit, sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	suite := decoded.Suites[0]
+	if suite.Name != "jerry-demo" || suite.Tests != 2 || suite.Failures != 1 || suite.Time != "3.000" {
+		t.Errorf("unexpected suite: %+v", suite)
+	}
+	if suite.Cases[0].Failure != nil {
+		t.Errorf("passing check reported a failure")
+	}
+	failure := suite.Cases[1].Failure
+	if failure == nil || failure.Message != "expected exit code 0, got 1" || failure.Type != "script" {
+		t.Errorf("unexpected failure: %+v", failure)
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatTAP, sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"TAP version 13\n1..2\n",
+		"ok 1 - image exists\n",
+		"not ok 2 - runs as non-root\n",
+		"  type: script\n",
+		"  feedback: \"The container still runs as root.\"\n",
+		"  hint: 2\n",
+		"    uid=0(root)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("TAP output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestOptionalChecks(t *testing.T) {
+	optional := false
+	exercise := &scenario.Exercise{
+		Metadata: scenario.ExerciseMeta{Name: "jerry-demo"},
+		Spec: scenario.ExerciseSpec{Checks: []scenario.Check{
+			{Name: "builds", Points: 80},
+			{Name: "small image", Required: &optional},
+		}},
+	}
+	results := []checks.Result{
+		{Name: "builds", Type: "script", Passed: true},
+		{Name: "small image", Type: "docker-image", Message: "image is 900MB", Optional: true},
+	}
+	report := New(exercise, "", results, time.Second)
+	if !report.Passed || report.Failed != 1 {
+		t.Errorf("failed optional checks must not fail the report: passed=%t failed=%d", report.Passed, report.Failed)
+	}
+	if report.Checks[0].Points != 80 || report.Checks[1].Points != 20 || !report.Checks[1].Optional {
+		t.Errorf("unexpected checks: %+v", report.Checks)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTAP(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "not ok 2 - small image # TODO optional\n") {
+		t.Errorf("TAP output does not mark the optional check:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteJUnit(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	suite := decoded.Suites[0]
+	if suite.Failures != 0 || suite.Skipped != 1 || suite.Cases[1].Skipped == nil {
+		t.Errorf("expected the optional check to be skipped: %+v", suite)
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "yaml", sampleReport()); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
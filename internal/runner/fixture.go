@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Interaction is one recorded command and its outcome.
+type Interaction struct {
+	Command  string   `json:"command"`
+	Args     []string `json:"args,omitempty"`
+	Dir      string   `json:"dir,omitempty"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Combined string   `json:"combined,omitempty"`
+	ExitCode int      `json:"exitCode,omitempty"`
+	// Error is set when the command could not run at all.
+	Error string `json:"error,omitempty"`
+}
+
+// Fixture is the on-disk format shared by Recorder and Replayer.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadFixture reads a fixture file.
+func LoadFixture(path string) (Fixture, error) {
+	var fixture Fixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fixture, fmt.Errorf("read fixture: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return fixture, fmt.Errorf("parse fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// Save writes the fixture to path.
+func (f Fixture) Save(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encode fixture: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write fixture: %w", err)
+	}
+	return nil
+}
+
+// Recorder passes commands through to another executor and captures each
+// command, its output and exit code.
+type Recorder struct {
+	Next Executor
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder records the commands executed through next.
+func NewRecorder(next Executor) *Recorder {
+	return &Recorder{Next: next}
+}
+
+func (r *Recorder) Execute(ctx context.Context, command Command) (Result, error) {
+	output, err := r.Next.Execute(ctx, command)
+
+	interaction := Interaction{
+		Command:  command.Name,
+		Args:     command.Args,
+		Dir:      command.Dir,
+		Stdout:   output.Stdout,
+		Combined: output.Combined,
+	}
+	if code, ok := ExitCode(err); ok {
+		interaction.ExitCode = code
+	} else {
+		interaction.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return output, err
+}
+
+// Fixture returns everything recorded so far.
+func (r *Recorder) Fixture() Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Fixture{Interactions: append([]Interaction(nil), r.interactions...)}
+}
+
+// Replayer answers commands from a fixture instead of running them. Each
+// recorded interaction is used once, matched on command and arguments in
+// recording order, so repeated calls replay successive outcomes.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer replays the interactions of fixture.
+func NewReplayer(fixture Fixture) *Replayer {
+	return &Replayer{
+		interactions: fixture.Interactions,
+		used:         make([]bool, len(fixture.Interactions)),
+	}
+}
+
+func (r *Replayer) Execute(ctx context.Context, command Command) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Command != command.Name || !slices.Equal(interaction.Args, command.Args) {
+			continue
+		}
+		r.used[i] = true
+
+		output := Result{Stdout: interaction.Stdout, Combined: interaction.Combined}
+		switch {
+		case interaction.Error != "":
+			return output, errors.New(interaction.Error)
+		case interaction.ExitCode != 0:
+			return output, &ExitError{Code: interaction.ExitCode}
+		}
+		return output, nil
+	}
+	return Result{}, fmt.Errorf("no recorded interaction for %q", command.String())
+}
+
+// Unused returns the interactions that were never replayed, so tests can
+// assert that every expected command ran.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Logger writes every command executed through Next to Out, with its exit
+// status and duration, for --debug output.
+type Logger struct {
+	Next Executor
+	Out  io.Writer
+
+	mu sync.Mutex
+}
+
+// NewLogger logs the commands executed through next to out.
+func NewLogger(next Executor, out io.Writer) *Logger {
+	return &Logger{Next: next, Out: out}
+}
+
+func (l *Logger) Execute(ctx context.Context, command Command) (Result, error) {
+	started := time.Now()
+	output, err := l.Next.Execute(ctx, command)
+	elapsed := time.Since(started).Round(time.Millisecond)
+
+	status := "ok"
+	if code, ok := ExitCode(err); !ok {
+		status = err.Error()
+	} else if code != 0 {
+		status = fmt.Sprintf("exit %d", code)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if command.Dir != "" {
+		fmt.Fprintf(l.Out, "[debug] $ %s (in %s) -> %s, %s\n", command, command.Dir, status, elapsed)
+	} else {
+		fmt.Fprintf(l.Out, "[debug] $ %s -> %s, %s\n", command, status, elapsed)
+	}
+	return output, err
+}
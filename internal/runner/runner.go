@@ -1,29 +1,150 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
-func Run(ctx context.Context, name string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	output, err := cmd.CombinedOutput()
-	trimmed := strings.TrimSpace(string(output))
-	if err != nil {
-		return trimmed, fmt.Errorf("%s %s failed: %w\n%s", name, strings.Join(args, " "), err, trimmed)
+// Command is a single external command invocation.
+type Command struct {
+	Name string
+	Args []string
+	Dir  string
+	// Env is appended to the current process environment.
+	Env []string
+}
+
+func (c Command) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// Result holds what a command wrote: its stdout alone and stdout and stderr
+// interleaved as they were written.
+type Result struct {
+	Stdout   string
+	Combined string
+}
+
+// Executor runs external commands. A command that ran but exited non-zero
+// returns an error that ExitCode understands.
+type Executor interface {
+	Execute(ctx context.Context, command Command) (Result, error)
+}
+
+// Exec runs commands on the host with os/exec.
+type Exec struct{}
+
+func (Exec) Execute(ctx context.Context, command Command) (Result, error) {
+	cmd := exec.CommandContext(ctx, command.Name, command.Args...)
+	cmd.Dir = command.Dir
+	if len(command.Env) > 0 {
+		cmd.Env = append(os.Environ(), command.Env...)
 	}
-	return trimmed, nil
+
+	var stdout bytes.Buffer
+	combined := &lockedBuffer{}
+	cmd.Stdout = io.MultiWriter(&stdout, combined)
+	cmd.Stderr = combined
+	err := cmd.Run()
+	return Result{Stdout: stdout.String(), Combined: combined.String()}, err
 }
 
-func RunInDir(ctx context.Context, dir string, name string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = dir
-	output, err := cmd.CombinedOutput()
-	trimmed := strings.TrimSpace(string(output))
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// ExitError reports a command that exited with a non-zero status without
+// an os/exec process behind it, as returned by the Replayer.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode extracts the exit status of a command from an error returned by
+// an Executor or by the Run helpers. It reports false when the command did
+// not run to completion, for example because the binary is missing.
+func ExitCode(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	var replayed *ExitError
+	if errors.As(err, &replayed) {
+		return replayed.Code, true
+	}
+	return -1, false
+}
+
+type executorKey struct{}
+
+// WithExecutor returns a context whose commands run through executor.
+func WithExecutor(ctx context.Context, executor Executor) context.Context {
+	return context.WithValue(ctx, executorKey{}, executor)
+}
+
+// ExecutorFrom returns the executor carried by ctx, or Exec when there is
+// none.
+func ExecutorFrom(ctx context.Context) Executor {
+	if executor, ok := ctx.Value(executorKey{}).(Executor); ok && executor != nil {
+		return executor
+	}
+	return Exec{}
+}
+
+func run(ctx context.Context, command Command) (Result, error) {
+	output, err := ExecutorFrom(ctx).Execute(ctx, command)
 	if err != nil {
-		return trimmed, fmt.Errorf("%s %s failed: %w\n%s", name, strings.Join(args, " "), err, trimmed)
+		return output, fmt.Errorf("%s failed: %w\n%s", command, err, strings.TrimSpace(output.Combined))
 	}
-	return trimmed, nil
+	return output, nil
+}
+
+func Run(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := run(ctx, Command{Name: name, Args: args})
+	return strings.TrimSpace(output.Combined), err
+}
+
+func RunInDir(ctx context.Context, dir string, name string, args ...string) (string, error) {
+	output, err := run(ctx, Command{Name: name, Args: args, Dir: dir})
+	return strings.TrimSpace(output.Combined), err
+}
+
+// RunWithEnv runs a command in dir with extra environment variables appended
+// to the current process environment.
+func RunWithEnv(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
+	output, err := run(ctx, Command{Name: name, Args: args, Dir: dir, Env: env})
+	return strings.TrimSpace(output.Combined), err
+}
+
+// Output runs a command and returns only what it wrote to stdout, for
+// callers that parse the output and must not see warnings on stderr.
+func Output(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := run(ctx, Command{Name: name, Args: args})
+	return strings.TrimSpace(output.Stdout), err
 }
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecSeparatesStdout(t *testing.T) {
+	output, err := Exec{}.Execute(context.Background(), Command{Name: "sh", Args: []string{"-c", "echo out; echo err >&2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Stdout != "out\n" {
+		t.Errorf("Stdout = %q", output.Stdout)
+	}
+	if !strings.Contains(output.Combined, "out") || !strings.Contains(output.Combined, "err") {
+		t.Errorf("Combined = %q", output.Combined)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	_, err := Run(context.Background(), "sh", "-c", "exit 3")
+	if code, ok := ExitCode(err); !ok || code != 3 {
+		t.Errorf("ExitCode(exec error) = %d, %t", code, ok)
+	}
+	if code, ok := ExitCode(&ExitError{Code: 2}); !ok || code != 2 {
+		t.Errorf("ExitCode(replayed error) = %d, %t", code, ok)
+	}
+	if _, ok := ExitCode(errors.New("executable file not found")); ok {
+		t.Error("ExitCode should not report a status for commands that did not run")
+	}
+	if code, ok := ExitCode(nil); !ok || code != 0 {
+		t.Errorf("ExitCode(nil) = %d, %t", code, ok)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	recorder := NewRecorder(Exec{})
+	ctx := WithExecutor(context.Background(), recorder)
+
+	if _, err := Run(ctx, "sh", "-c", "echo first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(ctx, "sh", "-c", "echo second >&2; exit 4"); err == nil {
+		t.Fatal("expected failure")
+	}
+	_, _ = Run(ctx, "gymctl-missing-binary")
+
+	path := filepath.Join(t.TempDir(), "fixture.yaml")
+	if err := recorder.Fixture().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixture.Interactions) != 3 {
+		t.Fatalf("expected 3 interactions, got %d", len(fixture.Interactions))
+	}
+
+	replayer := NewReplayer(fixture)
+	ctx = WithExecutor(context.Background(), replayer)
+
+	output, err := Run(ctx, "sh", "-c", "echo first")
+	if err != nil || output != "first" {
+		t.Errorf("replayed output = %q, %v", output, err)
+	}
+	output, err = Run(ctx, "sh", "-c", "echo second >&2; exit 4")
+	if code, _ := ExitCode(err); code != 4 || output != "second" {
+		t.Errorf("replayed failure = %q, exit %d", output, code)
+	}
+	if _, err := Run(ctx, "gymctl-missing-binary"); err == nil || !strings.Contains(err.Error(), "executable file not found") {
+		t.Errorf("replayed start error = %v", err)
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("unused interactions: %+v", unused)
+	}
+	if _, err := Run(ctx, "sh", "-c", "echo first"); err == nil {
+		t.Error("interactions should only be replayed once")
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	replayer := NewReplayer(Fixture{Interactions: []Interaction{
+		{Command: "kubectl", Args: []string{"get", "pods"}, Combined: "No resources found"},
+		{Command: "kind", Args: []string{"get", "clusters"}, ExitCode: 1},
+	}})
+	ctx := WithExecutor(context.Background(), NewLogger(replayer, &buf))
+
+	_, _ = Run(ctx, "kubectl", "get", "pods")
+	_, _ = RunInDir(ctx, "/tmp", "kind", "get", "clusters")
+
+	logged := buf.String()
+	if !strings.Contains(logged, "$ kubectl get pods -> ok") {
+		t.Errorf("missing successful command in log:\n%s", logged)
+	}
+	if !strings.Contains(logged, "$ kind get clusters (in /tmp) -> exit 1") {
+		t.Errorf("missing failed command in log:\n%s", logged)
+	}
+}
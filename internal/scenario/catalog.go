@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 type CatalogEntry struct {
@@ -60,3 +61,58 @@ func FindByName(entries []CatalogEntry, name string) (*CatalogEntry, bool) {
 	}
 	return nil, false
 }
+
+// SortCatalog orders entries by track, week, order and name, the order in
+// which exercises are listed and meant to be worked through.
+func SortCatalog(entries []CatalogEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		ai := entries[i].Exercise
+		aj := entries[j].Exercise
+		if ai.Metadata.Track != aj.Metadata.Track {
+			return ai.Metadata.Track < aj.Metadata.Track
+		}
+		if ai.Metadata.Week != aj.Metadata.Week {
+			return ai.Metadata.Week < aj.Metadata.Week
+		}
+		if ai.Metadata.Order != aj.Metadata.Order {
+			return ai.Metadata.Order < aj.Metadata.Order
+		}
+		return ai.Metadata.Name < aj.Metadata.Name
+	})
+}
+
+// NextExercise resolves what to work on after the current exercise. The
+// current exercise's nextExercise wins when it is still open; otherwise the
+// catalog is walked in SortCatalog order starting after the current exercise,
+// wrapping around, and the first exercise that is neither completed nor
+// locked by prerequisites is returned. An empty current name starts from the
+// beginning of the catalog.
+func NextExercise(entries []CatalogEntry, current string, completed func(name string) bool) (*CatalogEntry, bool) {
+	available := func(entry *CatalogEntry) bool {
+		name := entry.Exercise.Metadata.Name
+		return name != current && !completed(name) && len(UnmetPrerequisites(entry.Exercise, completed)) == 0
+	}
+
+	if entry, ok := FindByName(entries, current); ok && entry.Exercise.Spec.NextExercise != "" {
+		if next, ok := FindByName(entries, entry.Exercise.Spec.NextExercise); ok && available(next) {
+			return next, true
+		}
+	}
+
+	ordered := append([]CatalogEntry{}, entries...)
+	SortCatalog(ordered)
+	start := 0
+	for i := range ordered {
+		if ordered[i].Exercise.Metadata.Name == current {
+			start = i + 1
+			break
+		}
+	}
+	for offset := 0; offset < len(ordered); offset++ {
+		candidate := &ordered[(start+offset)%len(ordered)]
+		if available(candidate) {
+			return FindByName(entries, candidate.Exercise.Metadata.Name)
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,52 @@
+package scenario
+
+import (
+	"testing"
+)
+
+func orderedEntry(name string, week, order int, next string, prerequisites ...string) CatalogEntry {
+	return CatalogEntry{Exercise: &Exercise{
+		Metadata: ExerciseMeta{Name: name, Track: "kubernetes", Week: week, Order: order},
+		Spec:     ExerciseSpec{NextExercise: next, Prerequisites: prerequisites},
+	}}
+}
+
+func TestNextExercise(t *testing.T) {
+	entries := []CatalogEntry{
+		orderedEntry("c", 2, 1, ""),
+		orderedEntry("a", 1, 1, ""),
+		orderedEntry("b", 1, 2, "d"),
+		orderedEntry("d", 2, 2, "", "c"),
+	}
+
+	tests := []struct {
+		name      string
+		current   string
+		completed []string
+		want      string
+	}{
+		{name: "no current starts at the beginning", want: "a"},
+		{name: "follows catalog order", current: "a", completed: []string{"a"}, want: "b"},
+		{name: "nextExercise when unlocked", current: "b", completed: []string{"a", "b", "c"}, want: "d"},
+		{name: "locked nextExercise falls back to order", current: "b", completed: []string{"a", "b"}, want: "c"},
+		{name: "wraps around skipping completed", current: "d", completed: []string{"b", "c", "d"}, want: "a"},
+		{name: "everything completed", current: "d", completed: []string{"a", "b", "c", "d"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := map[string]bool{}
+			for _, name := range tt.completed {
+				done[name] = true
+			}
+			next, ok := NextExercise(entries, tt.current, func(name string) bool { return done[name] })
+			got := ""
+			if ok {
+				got = next.Exercise.Metadata.Name
+			}
+			if got != tt.want {
+				t.Errorf("NextExercise() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package scenario
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Composite check types combine the results of their nested checks.
+const (
+	// CheckAllOf passes when every nested check passes.
+	CheckAllOf = "allOf"
+	// CheckAnyOf passes when at least one nested check passes.
+	CheckAnyOf = "anyOf"
+	// CheckNot passes when none of the nested checks pass.
+	CheckNot = "not"
+)
+
+// IsComposite reports whether the check combines nested checks.
+func (c Check) IsComposite() bool {
+	switch c.Type {
+	case CheckAllOf, CheckAnyOf, CheckNot:
+		return true
+	}
+	return false
+}
+
+// ValidateChecks checks the nesting of composite checks, which the schema
+// cannot express fully: composites need nested checks, leaves must not have
+// any, and nested checks are scheduled and scored by their parent, so they
+// cannot use dependsOn, serial, points, weight or required.
+func ValidateChecks(checks []Check) error {
+	var problems []string
+	for i, check := range checks {
+		validateCheck(check, fmt.Sprintf("checks[%d]", i), false, &problems)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid checks: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validateCheck(check Check, path string, nested bool, problems *[]string) {
+	if check.Name != "" {
+		path += " (" + check.Name + ")"
+	}
+	if nested && len(check.DependsOn) > 0 {
+		*problems = append(*problems, path+": nested checks cannot use dependsOn")
+	}
+	if nested && check.Serial {
+		*problems = append(*problems, path+": nested checks cannot be serial")
+	}
+	if nested && (check.Points != 0 || check.Weight != 0 || check.Required != nil) {
+		*problems = append(*problems, path+": nested checks cannot set points, weight or required")
+	}
+	if check.Points != 0 && check.Weight != 0 {
+		*problems = append(*problems, path+": set either points or weight, not both")
+	}
+	if !check.IsComposite() {
+		if len(check.Checks) > 0 {
+			*problems = append(*problems, fmt.Sprintf("%s: only allOf, anyOf and not checks hold nested checks, not %q", path, check.Type))
+		}
+		return
+	}
+	if len(check.Checks) == 0 {
+		*problems = append(*problems, fmt.Sprintf("%s: %s needs at least one nested check", path, check.Type))
+	}
+	for i, child := range check.Checks {
+		validateCheck(child, fmt.Sprintf("%s.checks[%d]", path, i), true, problems)
+	}
+}
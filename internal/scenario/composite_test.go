@@ -0,0 +1,118 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const compositeExercise = `apiVersion: gym.dev/v1
+kind: Exercise
+metadata:
+  name: composite
+  title: Composite
+  track: docker
+spec:
+  difficulty: beginner
+  description: Composite checks
+  environment:
+    type: docker
+  hints:
+    - level: 1
+      text: Read the checks
+      cost: 0
+  checks:
+%s
+`
+
+func loadChecks(t *testing.T, checks string) (*Exercise, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "task.yaml")
+	data := strings.ReplaceAll(compositeExercise, "%s", checks)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadExerciseFile(path)
+}
+
+func TestLoadCompositeChecks(t *testing.T) {
+	exercise, err := loadChecks(t, `
+    - name: Runs as non-root
+      type: anyOf
+      checks:
+        - name: Dockerfile sets USER
+          type: dockerfile
+          check: hasUser
+        - name: Not root and not privileged
+          type: allOf
+          checks:
+            - name: uid is not 0
+              type: script
+              script: test "$(id -u)" != 0
+            - name: not privileged
+              type: not
+              checks:
+                - name: privileged
+                  type: script
+                  script: grep -q privileged config`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := exercise.Spec.Checks[0]
+	if !check.IsComposite() || len(check.Checks) != 2 {
+		t.Fatalf("check = %+v", check)
+	}
+	if nested := check.Checks[1].Checks[1]; nested.Type != CheckNot || nested.Checks[0].Name != "privileged" {
+		t.Errorf("nested not = %+v", nested)
+	}
+}
+
+func TestLoadRejectsBadNesting(t *testing.T) {
+	tests := map[string]string{
+		"composite without checks": `
+    - name: Either
+      type: anyOf`,
+		"leaf with checks": `
+    - name: Leaf
+      type: script
+      script: "true"
+      checks:
+        - name: child
+          type: script`,
+		"nested check without a type": `
+    - name: Both
+      type: allOf
+      checks:
+        - name: child`,
+		"nested dependsOn": `
+    - name: First
+      type: script
+      script: "true"
+    - name: Both
+      type: allOf
+      checks:
+        - name: child
+          type: script
+          script: "true"
+          dependsOn: [First]`,
+	}
+	for name, checks := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadChecks(t, checks); err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
+
+func TestValidateChecksNamesThePath(t *testing.T) {
+	err := ValidateChecks([]Check{{
+		Name:   "outer",
+		Type:   CheckAllOf,
+		Checks: []Check{{Name: "inner", Type: CheckNot}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "checks[0] (outer).checks[0] (inner): not needs at least one nested check") {
+		t.Errorf("ValidateChecks = %v", err)
+	}
+}
@@ -24,9 +24,51 @@ func LoadExerciseFile(path string) (*Exercise, error) {
 		return nil, fmt.Errorf("parse exercise yaml: %w", err)
 	}
 
+	if err := ValidateChecks(exercise.Spec.Checks); err != nil {
+		return nil, err
+	}
+	if err := validateScoring(exercise.Spec); err != nil {
+		return nil, err
+	}
+	for _, variant := range exercise.Spec.Variants {
+		if err := ValidateChecks(variant.Checks); err != nil {
+			return nil, fmt.Errorf("variant %s: %w", variant.Name, err)
+		}
+		merged, err := exercise.WithVariant(variant.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateScoring(merged.Spec); err != nil {
+			return nil, fmt.Errorf("variant %s: %w", variant.Name, err)
+		}
+	}
+
 	return &exercise, nil
 }
 
+// validateScoring checks that the checks' points fit the exercise and that
+// the hints they point at exist.
+func validateScoring(spec ExerciseSpec) error {
+	if _, _, err := spec.CheckPoints(); err != nil {
+		return err
+	}
+	var problems []string
+	var visit func(checks []Check)
+	visit = func(checks []Check) {
+		for _, check := range checks {
+			if check.Hint > len(spec.Hints) {
+				problems = append(problems, fmt.Sprintf("check %q points at hint %d, but there are %d", check.Name, check.Hint, len(spec.Hints)))
+			}
+			visit(check.Checks)
+		}
+	}
+	visit(spec.Checks)
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid checks: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
 func ValidateExerciseYAML(data []byte) error {
 	jsonBytes, err := yaml.YAMLToJSON(data)
 	if err != nil {
@@ -0,0 +1,33 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadCheckFeedback(t *testing.T) {
+	exercise, err := loadChecks(t, `
+    - name: Memory limit
+      type: script
+      script: "true"
+      feedback: The container needs a memory limit below 256Mi.
+      hint: 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check := exercise.Spec.Checks[0]; check.Hint != 1 || !strings.HasPrefix(check.Feedback, "The container") {
+		t.Errorf("check = %+v", check)
+	}
+
+	_, err = loadChecks(t, `
+    - name: Either
+      type: anyOf
+      checks:
+        - name: Memory limit
+          type: script
+          script: "true"
+          hint: 2`)
+	if err == nil || !strings.Contains(err.Error(), `"Memory limit" points at hint 2, but there are 1`) {
+		t.Errorf("expected a missing hint error, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package scenario
+
+import "fmt"
+
+// DefaultPoints is what an exercise without spec.points is worth.
+const DefaultPoints = 100
+
+// IsRequired reports whether the check must pass for the exercise to
+// complete.
+func (c Check) IsRequired() bool {
+	return c.Required == nil || *c.Required
+}
+
+// WeighsChecks reports whether any check declares points, a weight or is
+// optional, so that its share of the score is worth showing.
+func (s ExerciseSpec) WeighsChecks() bool {
+	for _, check := range s.Checks {
+		if check.Points != 0 || check.Weight != 0 || !check.IsRequired() {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPoints splits the exercise's points across its checks, in order.
+// Checks declaring points get them and the others share the rest in
+// proportion to their weight; rounding leftovers go to the first of them.
+// When every check declares points and spec.points is unset, the exercise
+// is worth their sum. It returns the points of each check and the total.
+func (s ExerciseSpec) CheckPoints() ([]int, int, error) {
+	declared, weights := 0, 0
+	for _, check := range s.Checks {
+		if check.Points != 0 {
+			declared += check.Points
+		} else {
+			weights += checkWeight(check)
+		}
+	}
+
+	total := s.Points
+	switch {
+	case weights == 0 && len(s.Checks) > 0 && total == 0:
+		total = declared
+	case total == 0:
+		total = DefaultPoints
+	}
+	if weights == 0 && len(s.Checks) > 0 && declared != total {
+		return nil, 0, fmt.Errorf("check points add up to %d, but the exercise is worth %d", declared, total)
+	}
+	if declared > total {
+		return nil, 0, fmt.Errorf("check points add up to %d, more than the %d the exercise is worth", declared, total)
+	}
+
+	points := make([]int, len(s.Checks))
+	remaining := total - declared
+	shared := 0
+	for i, check := range s.Checks {
+		if check.Points != 0 {
+			points[i] = check.Points
+			continue
+		}
+		points[i] = remaining * checkWeight(check) / weights
+		shared += points[i]
+	}
+	for i := 0; shared < remaining && i < len(s.Checks); i++ {
+		if s.Checks[i].Points == 0 {
+			points[i]++
+			shared++
+		}
+	}
+	return points, total, nil
+}
+
+func checkWeight(check Check) int {
+	if check.Weight == 0 {
+		return 1
+	}
+	return check.Weight
+}
@@ -0,0 +1,123 @@
+package scenario
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckPoints(t *testing.T) {
+	optional := false
+
+	tests := []struct {
+		name      string
+		spec      ExerciseSpec
+		want      []int
+		wantTotal int
+		wantErr   string
+	}{
+		{
+			name:      "even split of the default",
+			spec:      ExerciseSpec{Checks: []Check{{}, {}, {}}},
+			want:      []int{34, 33, 33},
+			wantTotal: 100,
+		},
+		{
+			name:      "weights",
+			spec:      ExerciseSpec{Points: 60, Checks: []Check{{Weight: 2}, {}, {Weight: 3}}},
+			want:      []int{20, 10, 30},
+			wantTotal: 60,
+		},
+		{
+			name:      "fixed points leave the rest to the others",
+			spec:      ExerciseSpec{Points: 100, Checks: []Check{{Points: 40}, {}, {Required: &optional}}},
+			want:      []int{40, 30, 30},
+			wantTotal: 100,
+		},
+		{
+			name:      "all fixed without spec.points",
+			spec:      ExerciseSpec{Checks: []Check{{Points: 30}, {Points: 15}}},
+			want:      []int{30, 15},
+			wantTotal: 45,
+		},
+		{
+			name:    "all fixed must match spec.points",
+			spec:    ExerciseSpec{Points: 50, Checks: []Check{{Points: 30}, {Points: 15}}},
+			wantErr: "add up to 45, but the exercise is worth 50",
+		},
+		{
+			name:    "fixed points beyond the total",
+			spec:    ExerciseSpec{Points: 50, Checks: []Check{{Points: 60}, {}}},
+			wantErr: "more than the 50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := tt.spec.CheckPoints()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || total != tt.wantTotal {
+				t.Errorf("CheckPoints() = %v, %d, want %v, %d", got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestLoadWeightedChecks(t *testing.T) {
+	exercise, err := loadChecks(t, `
+    - name: Image builds
+      type: script
+      script: "true"
+      points: 60
+    - name: Image is small
+      type: script
+      script: "true"
+      required: false`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := exercise.Spec.Checks
+	if checks[0].Points != 60 || !checks[0].IsRequired() || checks[1].IsRequired() {
+		t.Errorf("unexpected checks: %+v", checks)
+	}
+	if !exercise.Spec.WeighsChecks() {
+		t.Error("expected the exercise to weigh its checks")
+	}
+
+	for name, yaml := range map[string]string{
+		"points and weight": `
+    - name: both
+      type: script
+      script: "true"
+      points: 10
+      weight: 2`,
+		"nested points": `
+    - name: either
+      type: anyOf
+      checks:
+        - name: inner
+          type: script
+          script: "true"
+          points: 10`,
+		"points beyond the total": `
+    - name: big
+      type: script
+      script: "true"
+      points: 150
+    - name: other
+      type: script
+      script: "true"`,
+	} {
+		if _, err := loadChecks(t, yaml); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
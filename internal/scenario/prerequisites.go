@@ -0,0 +1,118 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PrerequisiteIssue describes a problem in the prerequisite graph of a
+// catalog: a reference to an exercise that does not exist, or a cycle.
+type PrerequisiteIssue struct {
+	Exercises []string
+	Message   string
+}
+
+func (i PrerequisiteIssue) Error() string {
+	return i.Message
+}
+
+// Involves reports whether the named exercise takes part in the issue.
+func (i PrerequisiteIssue) Involves(name string) bool {
+	for _, exercise := range i.Exercises {
+		if exercise == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmetPrerequisites returns the prerequisites of the exercise that are not
+// completed, in declaration order.
+func UnmetPrerequisites(exercise *Exercise, completed func(name string) bool) []string {
+	var unmet []string
+	for _, name := range exercise.Spec.Prerequisites {
+		if !completed(name) {
+			unmet = append(unmet, name)
+		}
+	}
+	return unmet
+}
+
+// ValidatePrerequisites checks that every prerequisite names an exercise in
+// the catalog and that the prerequisite graph has no cycles.
+func ValidatePrerequisites(entries []CatalogEntry) []PrerequisiteIssue {
+	graph := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		graph[entry.Exercise.Metadata.Name] = entry.Exercise.Spec.Prerequisites
+	}
+	names := make([]string, 0, len(graph))
+	for name := range graph {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []PrerequisiteIssue
+	for _, name := range names {
+		for _, prerequisite := range graph[name] {
+			if _, ok := graph[prerequisite]; !ok {
+				issues = append(issues, PrerequisiteIssue{
+					Exercises: []string{name},
+					Message:   fmt.Sprintf("%s: unknown prerequisite %q", name, prerequisite),
+				})
+			}
+		}
+	}
+
+	return append(issues, findCycles(graph, names)...)
+}
+
+// findCycles walks the graph depth-first and reports each cycle once, the
+// first time one of its back edges is found.
+func findCycles(graph map[string][]string, names []string) []PrerequisiteIssue {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(graph))
+	var stack []string
+	var issues []PrerequisiteIssue
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, next := range graph[name] {
+			if _, ok := graph[next]; !ok {
+				continue
+			}
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				start := 0
+				for i := range stack {
+					if stack[i] == next {
+						start = i
+						break
+					}
+				}
+				cycle := append(append([]string{}, stack[start:]...), next)
+				issues = append(issues, PrerequisiteIssue{
+					Exercises: cycle[:len(cycle)-1],
+					Message:   fmt.Sprintf("prerequisite cycle: %s", strings.Join(cycle, " -> ")),
+				})
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return issues
+}
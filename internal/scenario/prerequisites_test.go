@@ -0,0 +1,77 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+)
+
+func catalogWith(prerequisites map[string][]string) []CatalogEntry {
+	var entries []CatalogEntry
+	for name, prereqs := range prerequisites {
+		entries = append(entries, CatalogEntry{Exercise: &Exercise{
+			Metadata: ExerciseMeta{Name: name},
+			Spec:     ExerciseSpec{Prerequisites: prereqs},
+		}})
+	}
+	return entries
+}
+
+func TestValidatePrerequisites(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph map[string][]string
+		want  []string
+	}{
+		{
+			name:  "valid chain",
+			graph: map[string][]string{"a": nil, "b": {"a"}, "c": {"a", "b"}},
+		},
+		{
+			name:  "unknown prerequisite",
+			graph: map[string][]string{"a": nil, "b": {"missing"}},
+			want:  []string{`b: unknown prerequisite "missing"`},
+		},
+		{
+			name:  "self reference",
+			graph: map[string][]string{"a": {"a"}},
+			want:  []string{"prerequisite cycle: a -> a"},
+		},
+		{
+			name:  "cycle",
+			graph: map[string][]string{"a": {"c"}, "b": {"a"}, "c": {"b"}, "d": {"a"}},
+			want:  []string{"prerequisite cycle: a -> c -> b -> a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := ValidatePrerequisites(catalogWith(tt.graph))
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("ValidatePrerequisites() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrerequisiteIssueInvolves(t *testing.T) {
+	issues := ValidatePrerequisites(catalogWith(map[string][]string{"a": {"b"}, "b": {"a"}, "c": nil}))
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d", len(issues))
+	}
+	if !issues[0].Involves("a") || !issues[0].Involves("b") || issues[0].Involves("c") {
+		t.Errorf("unexpected exercises in issue: %v", issues[0].Exercises)
+	}
+}
+
+func TestUnmetPrerequisites(t *testing.T) {
+	exercise := &Exercise{Spec: ExerciseSpec{Prerequisites: []string{"a", "b", "c"}}}
+	completed := map[string]bool{"b": true}
+	unmet := UnmetPrerequisites(exercise, func(name string) bool { return completed[name] })
+	if strings.Join(unmet, ",") != "a,c" {
+		t.Errorf("UnmetPrerequisites() = %v, want [a c]", unmet)
+	}
+}
@@ -31,6 +31,16 @@ type ExerciseSpec struct {
 	References       []Reference     `yaml:"references,omitempty"`
 	Variants         []Variant       `yaml:"variants,omitempty"`
 	VariantSelection string          `yaml:"variantSelection,omitempty"`
+	Scoring          *ScoringSpec    `yaml:"scoring,omitempty"`
+}
+
+// ScoringSpec configures the penalties applied on top of hint costs when an
+// exercise is completed. OvertimePenalty is charged per full minute spent
+// beyond estimatedTime.
+type ScoringSpec struct {
+	ResetPenalty    int `yaml:"resetPenalty,omitempty"`
+	OvertimePenalty int `yaml:"overtimePenalty,omitempty"`
+	MinScore        int `yaml:"minScore,omitempty"`
 }
 
 type EnvironmentSpec struct {
@@ -40,12 +50,25 @@ type EnvironmentSpec struct {
 	CustomSetup []CustomSetupStep `yaml:"customSetup,omitempty"`
 }
 
+// UsesDocker reports whether the environment provisions Docker resources,
+// which docker and hybrid exercises do.
+func (e EnvironmentSpec) UsesDocker() bool {
+	return e.Type == "docker" || e.Type == "hybrid"
+}
+
+// UsesKubernetes reports whether the environment provisions a cluster,
+// which kubernetes and hybrid exercises do.
+func (e EnvironmentSpec) UsesKubernetes() bool {
+	return e.Type == "kubernetes" || e.Type == "hybrid"
+}
+
 type KubernetesSpec struct {
 	CreateCluster  *bool           `yaml:"createCluster,omitempty"`
 	KindConfig     string          `yaml:"kindConfig,omitempty"`
 	Namespace      string          `yaml:"namespace,omitempty"`
 	SetupManifests []string        `yaml:"setupManifests,omitempty"`
 	WaitFor        []WaitCondition `yaml:"waitFor,omitempty"`
+	LoadImages     []string        `yaml:"loadImages,omitempty"`
 }
 
 type WaitCondition struct {
@@ -84,6 +107,10 @@ type Check struct {
 	Condition      string            `yaml:"condition,omitempty"`
 	Status         string            `yaml:"status,omitempty"`
 	Timeout        string            `yaml:"timeout,omitempty"`
+	Retries        int               `yaml:"retries,omitempty"`
+	Interval       string            `yaml:"interval,omitempty"`
+	DependsOn      []string          `yaml:"dependsOn,omitempty"`
+	Serial         bool              `yaml:"serial,omitempty"`
 	Script         string            `yaml:"script,omitempty"`
 	Selector       string            `yaml:"selector,omitempty"`
 	Container      string            `yaml:"container,omitempty"`
@@ -99,8 +126,22 @@ type Check struct {
 	Headers        map[string]string `yaml:"headers,omitempty"`
 	Path           string            `yaml:"path,omitempty"`
 	Check          string            `yaml:"check,omitempty"`
+	Stage          string            `yaml:"stage,omitempty"`
 	Recursive      *bool             `yaml:"recursive,omitempty"`
 	Exists         *bool             `yaml:"exists,omitempty"`
+	// Checks holds the nested checks of an allOf, anyOf or not check.
+	Checks []Check `yaml:"checks,omitempty"`
+	// Points fixes what the check is worth. Checks without points share
+	// the rest of the exercise's points by Weight, which defaults to 1.
+	Points int `yaml:"points,omitempty"`
+	Weight int `yaml:"weight,omitempty"`
+	// Required defaults to true. An exercise completes once its required
+	// checks pass; failed optional checks only cost their points.
+	Required *bool `yaml:"required,omitempty"`
+	// Feedback is shown to the student instead of the raw failure message,
+	// and Hint points at the 1-based index of the hint that helps.
+	Feedback string `yaml:"feedback,omitempty"`
+	Hint     int    `yaml:"hint,omitempty"`
 }
 
 type ExpectOutput struct {
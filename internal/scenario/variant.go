@@ -0,0 +1,117 @@
+package scenario
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+)
+
+// Variant selection policies accepted in spec.variantSelection.
+const (
+	VariantSelectionRandom     = "random"
+	VariantSelectionRoundRobin = "roundRobin"
+)
+
+// SelectVariant chooses the variant for a new attempt at an exercise. A pinned
+// name always wins. Otherwise the exercise policy applies: random picks any
+// variant, roundRobin moves on from the previously used variant and, for a
+// first attempt, starts at a position derived from the user name so that
+// different students get different variants. Exercises without variants
+// return nil.
+func SelectVariant(spec ExerciseSpec, pinned string, previous string, user string) (*Variant, error) {
+	if pinned != "" {
+		variant, ok := spec.FindVariant(pinned)
+		if !ok {
+			if len(spec.Variants) == 0 {
+				return nil, fmt.Errorf("exercise has no variants")
+			}
+			return nil, fmt.Errorf("unknown variant %q (available: %s)", pinned, strings.Join(spec.VariantNames(), ", "))
+		}
+		return variant, nil
+	}
+	if len(spec.Variants) == 0 {
+		return nil, nil
+	}
+
+	switch spec.VariantSelection {
+	case "", VariantSelectionRandom:
+		return &spec.Variants[rand.Intn(len(spec.Variants))], nil
+	case VariantSelectionRoundRobin:
+		index := -1
+		for i := range spec.Variants {
+			if spec.Variants[i].Name == previous {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			hash := fnv.New32a()
+			hash.Write([]byte(user))
+			index = int(hash.Sum32() % uint32(len(spec.Variants)))
+		} else {
+			index = (index + 1) % len(spec.Variants)
+		}
+		return &spec.Variants[index], nil
+	default:
+		return nil, fmt.Errorf("unsupported variantSelection: %s", spec.VariantSelection)
+	}
+}
+
+func (s ExerciseSpec) FindVariant(name string) (*Variant, bool) {
+	for i := range s.Variants {
+		if s.Variants[i].Name == name {
+			return &s.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s ExerciseSpec) VariantNames() []string {
+	names := make([]string, 0, len(s.Variants))
+	for _, variant := range s.Variants {
+		names = append(names, variant.Name)
+	}
+	return names
+}
+
+// WithVariant returns a copy of the exercise with the named variant applied:
+// its setup manifests are appended to the base Kubernetes manifests and its
+// checks are merged into the base checks, replacing base checks of the same
+// name. An empty name returns the exercise unchanged.
+func (e *Exercise) WithVariant(name string) (*Exercise, error) {
+	if name == "" {
+		return e, nil
+	}
+	variant, ok := e.Spec.FindVariant(name)
+	if !ok {
+		return nil, fmt.Errorf("exercise %s has no variant %q", e.Metadata.Name, name)
+	}
+
+	merged := *e
+	if len(variant.SetupManifests) > 0 && e.Spec.Environment.Kubernetes != nil {
+		k8s := *e.Spec.Environment.Kubernetes
+		k8s.SetupManifests = append(append([]string{}, k8s.SetupManifests...), variant.SetupManifests...)
+		merged.Spec.Environment.Kubernetes = &k8s
+	}
+	merged.Spec.Checks = mergeChecks(e.Spec.Checks, variant.Checks)
+	return &merged, nil
+}
+
+func mergeChecks(base []Check, overrides []Check) []Check {
+	checks := append([]Check{}, base...)
+	for _, override := range overrides {
+		replaced := false
+		for i := range checks {
+			if override.Name != "" && checks[i].Name == override.Name {
+				checks[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			checks = append(checks, override)
+		}
+	}
+	return checks
+}
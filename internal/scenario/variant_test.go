@@ -0,0 +1,132 @@
+package scenario
+
+import (
+	"testing"
+)
+
+func variantSpec(policy string) ExerciseSpec {
+	return ExerciseSpec{
+		VariantSelection: policy,
+		Variants: []Variant{
+			{Name: "red"},
+			{Name: "green"},
+			{Name: "blue"},
+		},
+	}
+}
+
+func TestSelectVariant(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     ExerciseSpec
+		pinned   string
+		previous string
+		want     string
+		wantErr  bool
+	}{
+		{name: "no variants", spec: ExerciseSpec{}, want: ""},
+		{name: "pinned without variants", spec: ExerciseSpec{}, pinned: "red", wantErr: true},
+		{name: "pinned", spec: variantSpec(VariantSelectionRandom), pinned: "green", want: "green"},
+		{name: "pinned unknown", spec: variantSpec(VariantSelectionRandom), pinned: "purple", wantErr: true},
+		{name: "round robin advances", spec: variantSpec(VariantSelectionRoundRobin), previous: "red", want: "green"},
+		{name: "round robin wraps", spec: variantSpec(VariantSelectionRoundRobin), previous: "blue", want: "red"},
+		{name: "unsupported policy", spec: variantSpec("sticky"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, err := SelectVariant(tt.spec, tt.pinned, tt.previous, "student")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectVariant() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := ""
+			if variant != nil {
+				got = variant.Name
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("SelectVariant() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectVariantRoundRobinFirstAttemptIsStablePerUser(t *testing.T) {
+	spec := variantSpec(VariantSelectionRoundRobin)
+	first, err := SelectVariant(spec, "", "", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := SelectVariant(spec, "", "", "alice")
+	if first.Name != again.Name {
+		t.Errorf("first attempt variant changed between calls: %s vs %s", first.Name, again.Name)
+	}
+}
+
+func TestSelectVariantRandomPicksKnownVariant(t *testing.T) {
+	spec := variantSpec("")
+	variant, err := SelectVariant(spec, "", "", "student")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spec.FindVariant(variant.Name); !ok {
+		t.Errorf("random selection returned unknown variant %q", variant.Name)
+	}
+}
+
+func TestWithVariant(t *testing.T) {
+	exercise := &Exercise{
+		Metadata: ExerciseMeta{Name: "demo"},
+		Spec: ExerciseSpec{
+			Environment: EnvironmentSpec{
+				Type:       "kubernetes",
+				Kubernetes: &KubernetesSpec{SetupManifests: []string{"setup/base.yaml"}},
+			},
+			Checks: []Check{
+				{Name: "limit", Type: "jsonpath", Value: "256Mi"},
+				{Name: "healthy", Type: "condition"},
+			},
+			Variants: []Variant{{
+				Name:           "tight",
+				SetupManifests: []string{"setup/tight.yaml"},
+				Checks: []Check{
+					{Name: "limit", Type: "jsonpath", Value: "128Mi"},
+					{Name: "quota", Type: "resourceExists"},
+				},
+			}},
+		},
+	}
+
+	merged, err := exercise.WithVariant("tight")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifests := merged.Spec.Environment.Kubernetes.SetupManifests
+	if len(manifests) != 2 || manifests[1] != "setup/tight.yaml" {
+		t.Errorf("unexpected manifests: %v", manifests)
+	}
+	if len(merged.Spec.Checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(merged.Spec.Checks))
+	}
+	if merged.Spec.Checks[0].Value != "128Mi" {
+		t.Errorf("variant check did not replace base check: %v", merged.Spec.Checks[0].Value)
+	}
+	if merged.Spec.Checks[2].Name != "quota" {
+		t.Errorf("variant check not appended: %s", merged.Spec.Checks[2].Name)
+	}
+
+	// The original exercise must be left untouched.
+	if len(exercise.Spec.Checks) != 2 || exercise.Spec.Checks[0].Value != "256Mi" {
+		t.Errorf("base checks were modified: %+v", exercise.Spec.Checks)
+	}
+	if len(exercise.Spec.Environment.Kubernetes.SetupManifests) != 1 {
+		t.Errorf("base manifests were modified: %v", exercise.Spec.Environment.Kubernetes.SetupManifests)
+	}
+
+	if same, _ := exercise.WithVariant(""); same != exercise {
+		t.Errorf("empty variant should return the exercise unchanged")
+	}
+	if _, err := exercise.WithVariant("loose"); err == nil {
+		t.Errorf("expected error for unknown variant")
+	}
+}
@@ -0,0 +1,86 @@
+package scoring
+
+import (
+	"time"
+
+	"gymctl/internal/progress"
+	"gymctl/internal/scenario"
+)
+
+// DefaultPoints is awarded for exercises that do not declare spec.points.
+const DefaultPoints = scenario.DefaultPoints
+
+func BasePoints(points int) int {
+	if points == 0 {
+		return DefaultPoints
+	}
+	return points
+}
+
+// Calculate derives the score for a completed exercise. passed holds the
+// outcome of each of the exercise's checks, in order; nil counts them all as
+// passed. Failed checks cost the points they are worth. Revealed hints cost
+// what they declare; resets and time beyond estimatedTime are only charged
+// when the exercise opts in through spec.scoring. The total never drops below
+// the configured floor, nor does the floor award missed points.
+func Calculate(exercise *scenario.Exercise, status progress.ExerciseStatus, elapsed time.Duration, passed []bool) progress.ScoreBreakdown {
+	spec := exercise.Spec
+	breakdown := progress.ScoreBreakdown{
+		Base:        BasePoints(spec.Points),
+		HintPenalty: hintPenalty(spec.Hints, status.HintsUsed),
+	}
+	if points, total, err := spec.CheckPoints(); err == nil {
+		breakdown.Base = total
+		for i, check := range spec.Checks {
+			score := progress.CheckScore{
+				Name:     check.Name,
+				Points:   points[i],
+				Passed:   passed == nil || (i < len(passed) && passed[i]),
+				Optional: !check.IsRequired(),
+			}
+			if !score.Passed {
+				breakdown.Missed += score.Points
+			}
+			breakdown.Checks = append(breakdown.Checks, score)
+		}
+	}
+
+	floor := 0
+	if spec.Scoring != nil {
+		breakdown.ResetPenalty = status.Resets * spec.Scoring.ResetPenalty
+		breakdown.TimePenalty = overtimeMinutes(spec.EstimatedTime, elapsed) * spec.Scoring.OvertimePenalty
+		floor = spec.Scoring.MinScore
+	}
+	if earned := breakdown.Base - breakdown.Missed; floor > earned {
+		floor = earned
+	}
+
+	total := breakdown.Base - breakdown.Missed - breakdown.HintPenalty - breakdown.ResetPenalty - breakdown.TimePenalty
+	if total < floor {
+		total = floor
+	}
+	breakdown.Total = total
+	return breakdown
+}
+
+func hintPenalty(hints []scenario.Hint, used int) int {
+	if used > len(hints) {
+		used = len(hints)
+	}
+	penalty := 0
+	for _, hint := range hints[:used] {
+		penalty += hint.Cost
+	}
+	return penalty
+}
+
+func overtimeMinutes(estimated string, elapsed time.Duration) int {
+	if estimated == "" {
+		return 0
+	}
+	budget, err := time.ParseDuration(estimated)
+	if err != nil || elapsed <= budget {
+		return 0
+	}
+	return int((elapsed - budget) / time.Minute)
+}
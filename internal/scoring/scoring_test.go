@@ -0,0 +1,145 @@
+package scoring
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gymctl/internal/progress"
+	"gymctl/internal/scenario"
+)
+
+func TestCalculate(t *testing.T) {
+	hints := []scenario.Hint{{Cost: 0}, {Cost: 25}, {Cost: 50}}
+
+	tests := []struct {
+		name    string
+		spec    scenario.ExerciseSpec
+		status  progress.ExerciseStatus
+		elapsed time.Duration
+		want    progress.ScoreBreakdown
+	}{
+		{
+			name: "no hints",
+			spec: scenario.ExerciseSpec{Points: 100, Hints: hints},
+			want: progress.ScoreBreakdown{Base: 100, Total: 100},
+		},
+		{
+			name:   "default points",
+			spec:   scenario.ExerciseSpec{Hints: hints},
+			status: progress.ExerciseStatus{HintsUsed: 1},
+			want:   progress.ScoreBreakdown{Base: 100, Total: 100},
+		},
+		{
+			name:   "revealed hints are charged",
+			spec:   scenario.ExerciseSpec{Points: 100, Hints: hints},
+			status: progress.ExerciseStatus{HintsUsed: 2},
+			want:   progress.ScoreBreakdown{Base: 100, HintPenalty: 25, Total: 75},
+		},
+		{
+			name:   "hints used beyond available",
+			spec:   scenario.ExerciseSpec{Points: 100, Hints: hints},
+			status: progress.ExerciseStatus{HintsUsed: 5},
+			want:   progress.ScoreBreakdown{Base: 100, HintPenalty: 75, Total: 25},
+		},
+		{
+			name:   "resets ignored without scoring spec",
+			spec:   scenario.ExerciseSpec{Points: 100, Hints: hints},
+			status: progress.ExerciseStatus{Resets: 3},
+			want:   progress.ScoreBreakdown{Base: 100, Total: 100},
+		},
+		{
+			name: "reset and overtime penalties",
+			spec: scenario.ExerciseSpec{
+				Points:        100,
+				EstimatedTime: "20m",
+				Hints:         hints,
+				Scoring:       &scenario.ScoringSpec{ResetPenalty: 5, OvertimePenalty: 2},
+			},
+			status:  progress.ExerciseStatus{Resets: 2},
+			elapsed: 25*time.Minute + 30*time.Second,
+			want:    progress.ScoreBreakdown{Base: 100, ResetPenalty: 10, TimePenalty: 10, Total: 80},
+		},
+		{
+			name: "within estimated time",
+			spec: scenario.ExerciseSpec{
+				Points:        100,
+				EstimatedTime: "20m",
+				Hints:         hints,
+				Scoring:       &scenario.ScoringSpec{OvertimePenalty: 2},
+			},
+			elapsed: 19 * time.Minute,
+			want:    progress.ScoreBreakdown{Base: 100, Total: 100},
+		},
+		{
+			name: "floor",
+			spec: scenario.ExerciseSpec{
+				Points:  100,
+				Hints:   hints,
+				Scoring: &scenario.ScoringSpec{ResetPenalty: 50, MinScore: 20},
+			},
+			status: progress.ExerciseStatus{HintsUsed: 3, Resets: 1},
+			want:   progress.ScoreBreakdown{Base: 100, HintPenalty: 75, ResetPenalty: 50, Total: 20},
+		},
+		{
+			name: "floor never exceeds base",
+			spec: scenario.ExerciseSpec{
+				Points:  10,
+				Hints:   hints,
+				Scoring: &scenario.ScoringSpec{MinScore: 50},
+			},
+			status: progress.ExerciseStatus{HintsUsed: 3},
+			want:   progress.ScoreBreakdown{Base: 10, HintPenalty: 75, Total: 10},
+		},
+		{
+			name:   "never negative",
+			spec:   scenario.ExerciseSpec{Points: 50, Hints: hints},
+			status: progress.ExerciseStatus{HintsUsed: 3},
+			want:   progress.ScoreBreakdown{Base: 50, HintPenalty: 75, Total: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exercise := &scenario.Exercise{Spec: tt.spec}
+			got := Calculate(exercise, tt.status, tt.elapsed, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculatePartialCredit(t *testing.T) {
+	optional := false
+	exercise := &scenario.Exercise{Spec: scenario.ExerciseSpec{
+		Points: 100,
+		Hints:  []scenario.Hint{{Cost: 10}},
+		Checks: []scenario.Check{
+			{Name: "builds", Points: 50},
+			{Name: "small image", Required: &optional},
+			{Name: "healthcheck", Required: &optional},
+		},
+		Scoring: &scenario.ScoringSpec{MinScore: 90},
+	}}
+
+	got := Calculate(exercise, progress.ExerciseStatus{HintsUsed: 1}, 0, []bool{true, false, true})
+	want := progress.ScoreBreakdown{
+		Base:        100,
+		Missed:      25,
+		HintPenalty: 10,
+		Total:       75,
+		Checks: []progress.CheckScore{
+			{Name: "builds", Points: 50, Passed: true},
+			{Name: "small image", Points: 25, Optional: true},
+			{Name: "healthcheck", Points: 25, Passed: true, Optional: true},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Calculate() = %+v, want %+v", got, want)
+	}
+
+	if got := Calculate(exercise, progress.ExerciseStatus{}, 0, nil); got.Total != 100 || got.Missed != 0 {
+		t.Errorf("all passed: got %+v", got)
+	}
+}
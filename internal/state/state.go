@@ -0,0 +1,142 @@
+// Package state records which environment resources gymctl created for
+// each exercise, so later commands act on the right cluster and project.
+// It lives in ~/.gym/state.yaml, next to the progress file.
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"gymctl/internal/atomicfile"
+)
+
+// Environment describes the resources backing one exercise.
+type Environment struct {
+	// Cluster is the kind cluster name, empty when the exercise runs
+	// against an existing cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// KubeContext is the kubeconfig context of Cluster.
+	KubeContext string `json:"kubeContext,omitempty"`
+	// KindConfigHash fingerprints the kind configuration Cluster was
+	// created with, to tell whether it can be reused.
+	KindConfigHash string `json:"kindConfigHash,omitempty"`
+	// ComposeProject is the docker compose project name.
+	ComposeProject string `json:"composeProject,omitempty"`
+	// Variant is the exercise variant the environment was set up for.
+	Variant string `json:"variant,omitempty"`
+	// Session identifies this provisioning in resource labels.
+	Session   string `json:"session,omitempty"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+// File is the state file.
+type File struct {
+	Version      int                    `json:"version"`
+	Environments map[string]Environment `json:"environments"`
+}
+
+// Load reads the state file, returning an empty state when it is missing.
+func Load(path string) (*File, error) {
+	file := &File{Version: 1, Environments: map[string]Environment{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("parse state: %w", err)
+	}
+	if file.Environments == nil {
+		file.Environments = map[string]Environment{}
+	}
+	return file, nil
+}
+
+// Save writes the state file atomically. It does not lock; callers
+// changing a loaded file use Update.
+func Save(path string, file *File) error {
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	if err := atomicfile.Write(path, data); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	return nil
+}
+
+// Update loads the state file, applies change and saves the result while
+// holding the state lock. Nothing is written when change fails.
+func Update(path string, change func(*File) error) error {
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := change(file); err != nil {
+		return err
+	}
+	return Save(path, file)
+}
+
+// ClusterUsers returns the exercises, other than except, whose environment
+// uses cluster. A shared cluster must survive while any remain.
+func (f *File) ClusterUsers(cluster, except string) []string {
+	var users []string
+	for exercise, env := range f.Environments {
+		if exercise != except && env.Cluster == cluster {
+			users = append(users, exercise)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// ClusterConfigHash returns the kind configuration hash recorded for
+// cluster by any exercise using it.
+func (f *File) ClusterConfigHash(cluster string) (string, bool) {
+	for _, exercise := range sortedKeys(f.Environments) {
+		env := f.Environments[exercise]
+		if env.Cluster == cluster && env.KindConfigHash != "" {
+			return env.KindConfigHash, true
+		}
+	}
+	return "", false
+}
+
+// Clusters returns every tracked cluster name once.
+func (f *File) Clusters() []string {
+	seen := map[string]bool{}
+	var clusters []string
+	for _, env := range f.Environments {
+		if env.Cluster != "" && !seen[env.Cluster] {
+			seen[env.Cluster] = true
+			clusters = append(clusters, env.Cluster)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+func sortedKeys(environments map[string]Environment) []string {
+	keys := make([]string, 0, len(environments))
+	for key := range environments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
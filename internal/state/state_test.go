@@ -0,0 +1,76 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	file, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Environments["01-pods"] = Environment{Cluster: "jerry-gym-01-pods", KubeContext: "kind-jerry-gym-01-pods"}
+	file.Environments["02-probes"] = Environment{Cluster: "shared", KubeContext: "kind-shared", KindConfigHash: "abc"}
+	file.Environments["03-more"] = Environment{Cluster: "shared", KubeContext: "kind-shared"}
+	file.Environments["04-docker"] = Environment{ComposeProject: "jerry-gym-04-docker"}
+	if err := Save(path, file); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Environments, file.Environments) {
+		t.Errorf("round trip = %+v", loaded.Environments)
+	}
+	if got := loaded.ClusterUsers("shared", "02-probes"); !reflect.DeepEqual(got, []string{"03-more"}) {
+		t.Errorf("ClusterUsers = %v", got)
+	}
+	if hash, ok := loaded.ClusterConfigHash("shared"); !ok || hash != "abc" {
+		t.Errorf("ClusterConfigHash(shared) = %q, %v", hash, ok)
+	}
+	if _, ok := loaded.ClusterConfigHash("jerry-gym-01-pods"); ok {
+		t.Error("a cluster without a recorded hash should report none")
+	}
+	if got := loaded.Clusters(); !reflect.DeepEqual(got, []string{"jerry-gym-01-pods", "shared"}) {
+		t.Errorf("Clusters = %v", got)
+	}
+}
+
+func TestUpdateSerializesWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	const writers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Update(path, func(file *File) error {
+				file.Environments[fmt.Sprintf("exercise-%d", i)] = Environment{ComposeProject: "jerry-gym"}
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Environments) != writers {
+		t.Errorf("got %d environments after %d updates, want no lost updates", len(file.Environments), writers)
+	}
+}
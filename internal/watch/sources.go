@@ -0,0 +1,234 @@
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gymctl/internal/dockerapi"
+	"gymctl/internal/kube"
+	"gymctl/internal/runner"
+	"gymctl/internal/scenario"
+)
+
+// Polling intervals: files are cheap to stat, CLI fallbacks are not.
+const (
+	fileInterval = 300 * time.Millisecond
+	cliInterval  = 2 * time.Second
+	retryPause   = time.Second
+)
+
+// Files reports changes below root: files created, removed, resized or
+// touched. It polls rather than relying on platform file notifications,
+// which behave differently in editors, containers and network mounts.
+func Files(root string) Source {
+	return func(ctx context.Context, changed func(string)) {
+		last, _ := fingerprint(root)
+		ticker := time.NewTicker(fileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, err := fingerprint(root)
+			if err != nil {
+				continue
+			}
+			if name := firstDifference(last, current); name != "" {
+				changed("file " + name)
+			}
+			last = current
+		}
+	}
+}
+
+// fingerprint maps each path below root to its size, mode and mtime.
+func fingerprint(root string) (map[string]string, error) {
+	prints := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		prints[filepath.ToSlash(rel)] = fmt.Sprintf("%d %s %d", info.Size(), info.Mode(), info.ModTime().UnixNano())
+		return nil
+	})
+	return prints, err
+}
+
+// firstDifference names the first path, in order, that differs between two
+// fingerprints, or "" when they match.
+func firstDifference(before, after map[string]string) string {
+	var names []string
+	for name, print := range after {
+		if before[name] != print {
+			names = append(names, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// Kubernetes reports changes to objects of resourceType in namespace,
+// watching through the API when possible and polling kubectl otherwise.
+func Kubernetes(namespace, resourceType string) Source {
+	return func(ctx context.Context, changed func(string)) {
+		if client, err := kube.ClientFor(ctx); err == nil {
+			retry(ctx, retryPause, func() error {
+				return client.Watch(ctx, namespace, resourceType, func(event kube.WatchEvent) {
+					metadata, _ := event.Object["metadata"].(kube.Object)
+					name, _ := metadata["name"].(string)
+					changed(fmt.Sprintf("%s/%s %s", resourceType, name, strings.ToLower(event.Type)))
+				})
+			})
+			return
+		}
+
+		args := []string{"get", resourceType, "-o", "json"}
+		if namespace != "" {
+			args = append(args, "-n", namespace)
+		}
+		read := func() (string, error) {
+			output, err := runner.Output(ctx, "kubectl", kube.KubectlArgs(ctx, args...)...)
+			return digest(output), err
+		}
+		poll(ctx, cliInterval, read, changed, resourceType+" changed")
+	}
+}
+
+// Docker reports containers and images changing state, from the daemon's
+// event stream when possible and by polling the docker CLI otherwise.
+func Docker() Source {
+	return func(ctx context.Context, changed func(string)) {
+		if client, err := dockerapi.ClientFor(ctx); err == nil {
+			filters := dockerapi.Filters{"type": {"container", "image"}}
+			retry(ctx, retryPause, func() error {
+				return client.Events(ctx, filters, func(event dockerapi.Event) {
+					// Health probes and exec sessions fire constantly and
+					// say nothing about the exercise.
+					if strings.HasPrefix(event.Action, "exec_") || strings.HasPrefix(event.Action, "health_status") {
+						return
+					}
+					name := event.Actor.Attributes["name"]
+					if name == "" {
+						name = event.Actor.ID
+						if len(name) > 12 {
+							name = name[:12]
+						}
+					}
+					changed(fmt.Sprintf("%s %s %s", event.Type, name, event.Action))
+				})
+			})
+			return
+		}
+
+		read := func() (string, error) {
+			containers, err := runner.Output(ctx, "docker", "ps", "-a", "--no-trunc", "--format", "{{.ID}} {{.State}} {{.Image}}")
+			if err != nil {
+				return "", err
+			}
+			images, err := runner.Output(ctx, "docker", "images", "-q", "--no-trunc")
+			return digest(containers + "\n" + images), err
+		}
+		poll(ctx, cliInterval, read, changed, "docker state changed")
+	}
+}
+
+func digest(output string) string {
+	sum := sha256.Sum256([]byte(output))
+	return hex.EncodeToString(sum[:])
+}
+
+// ForExercise returns the sources worth watching for an exercise: its work
+// directory, the resource types its Kubernetes checks read in the
+// namespaces they read them from, and Docker for Docker exercises.
+func ForExercise(exercise *scenario.Exercise, workDir string) []Source {
+	var sources []Source
+	if workDir != "" {
+		sources = append(sources, Files(workDir))
+	}
+	if exercise.Spec.Environment.UsesDocker() {
+		sources = append(sources, Docker())
+	}
+	for _, watched := range watchedResources(exercise) {
+		sources = append(sources, Kubernetes(watched.namespace, watched.resourceType))
+	}
+	return sources
+}
+
+type watchedResource struct {
+	namespace    string
+	resourceType string
+}
+
+// watchedResources lists the resource types the exercise's checks read,
+// once per namespace.
+func watchedResources(exercise *scenario.Exercise) []watchedResource {
+	env := exercise.Spec.Environment
+	if !env.UsesKubernetes() || env.Kubernetes == nil {
+		return nil
+	}
+	defaultNamespace := env.Kubernetes.Namespace
+	if defaultNamespace == "" {
+		defaultNamespace = "default"
+	}
+	var watched []watchedResource
+	seen := map[watchedResource]bool{}
+	add := func(namespace, resourceType string) {
+		key := watchedResource{namespace, resourceType}
+		if resourceType != "" && !seen[key] {
+			seen[key] = true
+			watched = append(watched, key)
+		}
+	}
+	var visit func(checks []scenario.Check)
+	visit = func(checks []scenario.Check) {
+		for _, check := range checks {
+			if check.IsComposite() {
+				visit(check.Checks)
+				continue
+			}
+			namespace := defaultNamespace
+			if check.Namespace != "" {
+				namespace = check.Namespace
+			}
+			if check.Resource != "" {
+				resourceType, _ := kube.SplitRef(check.Resource)
+				add(namespace, resourceType)
+			}
+			// Selector based checks read pods, and workloads act through them.
+			if check.Selector != "" || check.Resource != "" {
+				add(namespace, "pods")
+			}
+		}
+	}
+	visit(exercise.Spec.Checks)
+	return watched
+}
@@ -0,0 +1,126 @@
+// Package watch notices when an exercise's state may have changed: files in
+// its work directory, the Kubernetes objects its checks read, or Docker
+// containers and images. Bursts of changes are debounced into one trigger
+// so checks re-run once per edit rather than once per event.
+package watch
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// DefaultQuiet is how long things must stay unchanged before a trigger.
+const DefaultQuiet = 500 * time.Millisecond
+
+// DefaultSettle is how long a trigger can lag behind its change when
+// reported by an event stream or the file poller: the poll interval plus
+// the quiet period.
+const DefaultSettle = fileInterval + DefaultQuiet
+
+// Source reports changes by calling changed with a short description until
+// ctx ends. Sources recover from their own errors.
+type Source func(ctx context.Context, changed func(reason string))
+
+// Run starts the sources and sends the reasons gathered during each burst
+// of changes once nothing has changed for quiet. The channel is closed when
+// ctx ends.
+func Run(ctx context.Context, quiet time.Duration, sources ...Source) <-chan []string {
+	changes := make(chan string, 64)
+	changed := func(reason string) {
+		select {
+		case changes <- reason:
+		case <-ctx.Done():
+		}
+	}
+	for _, source := range sources {
+		go source(ctx, changed)
+	}
+
+	triggers := make(chan []string)
+	go func() {
+		defer close(triggers)
+		pending := map[string]bool{}
+		timer := time.NewTimer(quiet)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case reason := <-changes:
+				pending[reason] = true
+				timer.Reset(quiet)
+			case <-timer.C:
+				reasons := make([]string, 0, len(pending))
+				for reason := range pending {
+					reasons = append(reasons, reason)
+				}
+				sort.Strings(reasons)
+				pending = map[string]bool{}
+				select {
+				case triggers <- reasons:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return triggers
+}
+
+// Drain discards the triggers for changes made up to now, both those
+// already sent and those still waiting out the quiet period, and returns
+// once settle has passed without holding one back. Checks change things
+// themselves, building images and starting containers, so callers drain
+// after each run to react only to what changed since. It returns false when
+// triggers is closed.
+func Drain(triggers <-chan []string, settle time.Duration) bool {
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-triggers:
+			if !ok {
+				return false
+			}
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// poll calls read every interval and reports a change whenever its result
+// differs from the previous one. Failed reads are skipped.
+func poll(ctx context.Context, interval time.Duration, read func() (string, error), changed func(string), reason string) {
+	last, err := read()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current, readErr := read()
+		if readErr != nil {
+			continue
+		}
+		if err != nil || current != last {
+			changed(reason)
+		}
+		last, err = current, nil
+	}
+}
+
+// retry runs stream until ctx ends, pausing between attempts so a broken
+// connection does not spin.
+func retry(ctx context.Context, pause time.Duration, stream func() error) {
+	for ctx.Err() == nil {
+		_ = stream()
+		select {
+		case <-ctx.Done():
+		case <-time.After(pause):
+		}
+	}
+}
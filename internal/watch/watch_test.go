@@ -0,0 +1,181 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gymctl/internal/kube"
+	"gymctl/internal/kube/kubetest"
+	"gymctl/internal/scenario"
+)
+
+func TestRunDebouncesBursts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	burst := func(ctx context.Context, changed func(string)) {
+		for _, reason := range []string{"b", "a", "b"} {
+			changed(reason)
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	triggers := Run(ctx, 50*time.Millisecond, burst)
+
+	select {
+	case reasons := <-triggers:
+		if want := []string{"a", "b"}; !reflect.DeepEqual(reasons, want) {
+			t.Errorf("reasons = %v, want %v", reasons, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("no trigger")
+	}
+	select {
+	case reasons := <-triggers:
+		t.Errorf("one burst gave a second trigger: %v", reasons)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	cancel()
+	for range triggers {
+	}
+}
+
+func TestDrainIgnoresChangesDuringRun(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reports := make(chan string)
+	source := func(ctx context.Context, changed func(string)) {
+		for {
+			select {
+			case reason := <-reports:
+				changed(reason)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	const quiet = 50 * time.Millisecond
+	triggers := Run(ctx, quiet, source)
+
+	// A check run starts a container; its events arrive both before and
+	// after the run ends.
+	reports <- "container app start"
+	time.Sleep(2 * quiet)
+	reports <- "container app die"
+	if !Drain(triggers, 2*quiet) {
+		t.Fatal("triggers closed")
+	}
+	select {
+	case reasons := <-triggers:
+		t.Fatalf("changes made by the run gave a trigger: %v", reasons)
+	case <-time.After(3 * quiet):
+	}
+
+	reports <- "file Dockerfile"
+	select {
+	case reasons := <-triggers:
+		if want := []string{"file Dockerfile"}; !reflect.DeepEqual(reasons, want) {
+			t.Errorf("reasons = %v, want %v", reasons, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("change after the run not noticed")
+	}
+
+	cancel()
+	if Drain(triggers, time.Second) {
+		t.Error("Drain = true after triggers closed")
+	}
+}
+
+func TestFilesReportsEdits(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	triggers := Run(ctx, 20*time.Millisecond, Files(dir))
+
+	// Let the first fingerprint be taken before editing.
+	time.Sleep(2 * fileInterval)
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine:3.19\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case reasons := <-triggers:
+		if want := []string{"file Dockerfile"}; !reflect.DeepEqual(reasons, want) {
+			t.Errorf("reasons = %v, want %v", reasons, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("edit not noticed")
+	}
+}
+
+func TestKubernetesWatchesNatively(t *testing.T) {
+	server := kubetest.NewServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = kube.WithClient(ctx, server.Client())
+	triggers := Run(ctx, 20*time.Millisecond, Kubernetes("jerry", "configmaps"))
+
+	configMap := func(value string) kube.Object {
+		return kube.Object{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "app-config", "namespace": "jerry"},
+			"data":       map[string]interface{}{"mode": value},
+		}
+	}
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case reasons := <-triggers:
+			if len(reasons) == 0 || reasons[0] == "" {
+				t.Errorf("reasons = %v", reasons)
+			}
+			return
+		case <-ticker.C:
+			// The watch starts asynchronously; keep changing until seen.
+			server.Set(configMap(time.Now().String()))
+		case <-ctx.Done():
+			t.Fatal("change not noticed")
+		}
+	}
+}
+
+func TestWatchedResources(t *testing.T) {
+	exercise := &scenario.Exercise{}
+	exercise.Spec.Environment = scenario.EnvironmentSpec{
+		Type:       "kubernetes",
+		Kubernetes: &scenario.KubernetesSpec{Namespace: "jerry"},
+	}
+	exercise.Spec.Checks = []scenario.Check{
+		{Type: "jsonpath", Resource: "deployment/jerry-app"},
+		{Type: "jsonpath", Resource: "deployment/jerry-other"},
+		{Type: "exists", Resource: "namespace/backend", Namespace: "backend"},
+		{Type: "log", Selector: "app=web"},
+		{Type: "script", Script: "true"},
+		{Type: scenario.CheckAnyOf, Checks: []scenario.Check{
+			{Type: "jsonpath", Resource: "service/jerry-svc"},
+		}},
+	}
+	want := []watchedResource{
+		{"jerry", "deployment"},
+		{"jerry", "pods"},
+		{"backend", "namespace"},
+		{"backend", "pods"},
+		{"jerry", "service"},
+	}
+	if got := watchedResources(exercise); !reflect.DeepEqual(got, want) {
+		t.Errorf("watchedResources = %v, want %v", got, want)
+	}
+	if got := len(ForExercise(exercise, t.TempDir())); got != len(want)+1 {
+		t.Errorf("ForExercise gave %d sources, want %d", got, len(want)+1)
+	}
+}